@@ -0,0 +1,94 @@
+package main
+
+import (
+	"event_registration/internal/config"
+	"event_registration/internal/payments"
+	"event_registration/internal/repositories"
+	"event_registration/internal/services"
+	"gorm.io/gorm"
+)
+
+// app wires repositories and services once so that `serve` and the ops
+// subcommands share the same construction.
+type app struct {
+	db *gorm.DB
+
+	userRepo  repositories.UserRepository
+	eventRepo repositories.EventRepository
+
+	authService   services.AuthService
+	eventService  services.EventService
+	regService    services.RegistrationService
+	queueService  services.BookingQueueService
+	waitingRoom   services.WaitingRoomService
+	importService services.ImportService
+	statsService  services.StatsService
+	simService    services.SimulationService
+	reconciler    services.ReconciliationService
+	orderService  services.OrderService
+	refunds       services.RefundService
+	promoService  services.PromoService
+	groups        services.GroupBookingService
+	transfers     services.TransferService
+	seating       services.SeatingService
+	venues        services.VenueService
+	series        services.SeriesService
+
+	// fakePayments is set when the fake provider is configured, for the
+	// development checkout route.
+	fakePayments *payments.FakeProvider
+}
+
+func newApp(cfg *config.Config, database *gorm.DB) *app {
+	a := &app{db: database}
+
+	// Validated by config.Load, so this cannot fail.
+	allocators, _ := services.NewSeatAllocators(cfg.Booking.Strategy, cfg.Booking.OptimisticRetries)
+
+	// Repositories
+	a.userRepo = repositories.NewUserRepository(database)
+	a.eventRepo = repositories.NewEventRepository(database)
+	regRepo := repositories.NewRegistrationRepository(database)
+	waitRepo := repositories.NewWaitlistRepository(database)
+	importRepo := repositories.NewImportRepository(database)
+	auditRepo := repositories.NewAuditLogRepository(database)
+	statsRepo := repositories.NewStatsRepository(database)
+	reconcileRepo := repositories.NewReconciliationRepository(database)
+	queueRepo := repositories.NewBookingQueueRepository(database)
+	roomRepo := repositories.NewWaitingRoomRepository(database)
+	simRepo := repositories.NewSimulationRepository(database)
+	orderRepo := repositories.NewOrderRepository(database)
+	refundRepo := repositories.NewRefundRepository(database)
+	promoRepo := repositories.NewPromoRepository(database)
+	ticketTypeRepo := repositories.NewTicketTypeRepository(database)
+	groupRepo := repositories.NewGroupBookingRepository(database)
+	transferRepo := repositories.NewTransferRepository(database)
+	seatMapRepo := repositories.NewSeatMapRepository(database)
+	eventSeatRepo := repositories.NewEventSeatRepository(database)
+	venueRepo := repositories.NewVenueRepository(database)
+	seriesRepo := repositories.NewSeriesRepository(database)
+
+	// Validated by config.Load: "fake" is the only provider.
+	a.fakePayments = payments.NewFakeProvider(cfg.Payments.WebhookSecret)
+
+	// Services
+	a.authService = services.NewAuthService(a.userRepo, cfg.Auth.TokenTTL)
+	a.refunds = services.NewRefundService(database, refundRepo, orderRepo, promoRepo, auditRepo, a.fakePayments)
+	a.eventService = services.NewEventService(database, a.eventRepo, venueRepo, waitRepo, auditRepo, a.refunds)
+	a.regService = services.NewRegistrationService(database, regRepo, waitRepo, a.eventRepo, auditRepo, orderRepo, refundRepo, allocators, a.refunds)
+	a.groups = services.NewGroupBookingService(database, groupRepo, waitRepo, auditRepo, cfg.Booking.MaxGroupSize)
+	a.seating = services.NewSeatingService(database, seatMapRepo, eventSeatRepo, a.eventRepo, groupRepo, auditRepo, cfg.Booking.MaxGroupSize, cfg.Booking.OptimisticRetries)
+	a.venues = services.NewVenueService(database, venueRepo)
+	a.series = services.NewSeriesService(database, seriesRepo, a.eventRepo, venueRepo, waitRepo, auditRepo)
+	a.transfers = services.NewTransferService(database, transferRepo, a.userRepo, orderRepo, waitRepo, auditRepo)
+	a.queueService = services.NewBookingQueueService(database, queueRepo, a.eventRepo, auditRepo, cfg.Booking.QueueBatchSize)
+	a.waitingRoom = services.NewWaitingRoomService(database, roomRepo, a.eventRepo, cfg.Auth.JWTSecret, cfg.WaitingRoom.TokenTTL)
+	a.importService = services.NewImportService(importRepo, a.userRepo, a.eventRepo, regRepo, waitRepo, a.regService)
+	a.statsService = services.NewStatsService(statsRepo)
+	a.reconciler = services.NewReconciliationService(database, reconcileRepo, regRepo, waitRepo, orderRepo, auditRepo)
+	a.simService = services.NewSimulationService(simRepo, a.eventRepo, a.regService, a.reconciler, allocators)
+	a.promoService = services.NewPromoService(promoRepo, ticketTypeRepo, a.eventRepo)
+	a.orderService = services.NewOrderService(database, orderRepo, a.eventRepo, auditRepo, refundRepo, promoRepo, ticketTypeRepo, allocators, a.fakePayments, a.refunds, cfg.Payments.HoldTTL)
+
+	return a
+}
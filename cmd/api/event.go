@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"event_registration/internal/config"
+	"event_registration/internal/db"
+	"event_registration/internal/models"
+)
+
+const eventUsage = `usage: api event <command>
+
+commands:
+  export -id EVENT_ID [-format csv|json] [-o FILE]   export the attendee list (stdout by default)`
+
+func runEvent(ctx context.Context, cfg *config.Config, args []string) int {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Fprintln(os.Stderr, eventUsage)
+		return 2
+	}
+	return runEventExport(ctx, cfg, args[1:])
+}
+
+// exportRow is one attendee line in an event export.
+type exportRow struct {
+	RegistrationID string     `json:"registration_id"`
+	Name           string     `json:"name"`
+	Email          string     `json:"email"`
+	Status         string     `json:"status"`
+	RegisteredAt   time.Time  `json:"registered_at"`
+	CheckedInAt    *time.Time `json:"checked_in_at,omitempty"`
+}
+
+func runEventExport(ctx context.Context, cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("event export", flag.ContinueOnError)
+	eventID := fs.String("id", "", "event ID (required)")
+	format := fs.String("format", "csv", "csv or json")
+	output := fs.String("o", "", "output file (default stdout)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *eventID == "" {
+		fmt.Fprintln(os.Stderr, "-id is required")
+		return 2
+	}
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "-format must be csv or json")
+		return 2
+	}
+
+	a := newApp(cfg, db.Connect(cfg))
+	event, err := a.eventService.GetEvent(ctx, *eventID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "event not found")
+		return 1
+	}
+	registrations, err := a.regService.ListEventRegistrations(ctx, *eventID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "export failed:", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "export failed:", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	rows := make([]exportRow, 0, len(registrations))
+	for _, reg := range registrations {
+		rows = append(rows, exportRow{
+			RegistrationID: reg.ID.String(),
+			Name:           reg.User.Name,
+			Email:          reg.User.Email,
+			Status:         string(reg.Status),
+			RegisteredAt:   reg.CreatedAt,
+			CheckedInAt:    reg.CheckedInAt,
+		})
+	}
+
+	if *format == "json" {
+		err = writeExportJSON(w, event, rows)
+	} else {
+		err = writeExportCSV(w, rows)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "export failed:", err)
+		return 1
+	}
+	return 0
+}
+
+func writeExportJSON(w io.Writer, event *models.Event, rows []exportRow) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"event_id":        event.ID,
+		"title":           event.Title,
+		"event_date":      event.EventDate,
+		"ends_at":         event.EndsAt,
+		"timezone":        event.Timezone,
+		"capacity":        event.Capacity,
+		"seats_remaining": event.SeatsRemaining,
+		"attendees":       rows,
+	})
+}
+
+func writeExportCSV(w io.Writer, rows []exportRow) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"registration_id", "name", "email", "status", "registered_at", "checked_in_at"})
+	for _, row := range rows {
+		checkedIn := ""
+		if row.CheckedInAt != nil {
+			checkedIn = row.CheckedInAt.UTC().Format(time.RFC3339)
+		}
+		cw.Write([]string{
+			row.RegistrationID,
+			row.Name,
+			row.Email,
+			row.Status,
+			row.RegisteredAt.UTC().Format(time.RFC3339),
+			checkedIn,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -1,55 +1,93 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"event_registration/internal/config"
-	"event_registration/internal/db"
-	"event_registration/internal/handlers"
-	"event_registration/internal/repositories"
-	"event_registration/internal/router"
-	"event_registration/internal/services"
+	"event_registration/internal/logging"
+	"event_registration/internal/utils"
 )
 
+const usage = `usage: api [config flags] <command> [args]
+
+commands:
+  serve              run the HTTP API (default)
+  migrate            apply or inspect schema migrations
+  seed               load sample, fixture or synthetic data
+  user create-admin  create an administrator account
+  user set-role      change a user's role
+  event export       export an event's attendee list as CSV or JSON
+  reconcile-seats    check (or -repair) seat counters and orphaned waitlists
+  simulate           run the booking concurrency simulation
+
+Run "api -help" for the config flags and "api <command> -help" for command options.`
+
 func main() {
-	log.Println("Loading Configuration...")
-	cfg := config.LoadConfig()
-
-	log.Println("Initializing Database...")
-	database := db.InitDB(cfg)
-
-	// Seed Sample Data Automatically
-	db.SeedDatabase(database)
-
-	// Repositories
-	userRepo := repositories.NewUserRepository(database)
-	eventRepo := repositories.NewEventRepository(database)
-	regRepo := repositories.NewRegistrationRepository(database)
-	waitRepo := repositories.NewWaitlistRepository(database)
-
-	// Services
-	authService := services.NewAuthService(userRepo)
-	eventService := services.NewEventService(eventRepo)
-	regService := services.NewRegistrationService(database, regRepo, waitRepo, eventRepo)
-
-	// Handlers
-	authHandler := handlers.NewAuthHandler(authService, cfg.JWTSecret)
-	eventHandler := handlers.NewEventHandler(eventService, regService)
-	organizerHandler := handlers.NewOrganizerHandler(eventService, regService)
-	adminHandler := handlers.NewAdminHandler(database, regService, eventService)
-
-	// Router
-	log.Println("Setting up Router...")
-	r := router.SetupRouter(
-		cfg.JWTSecret,
-		authHandler,
-		eventHandler,
-		organizerHandler,
-		adminHandler,
-	)
-
-	log.Printf("Starting Server on port %s...", cfg.ServerPort)
-	if err := r.Run(":" + cfg.ServerPort); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	logging.Setup(cfg.Logging.Level)
+	utils.BcryptCost = cfg.Auth.BcryptCost
+
+	// Cancelled on SIGINT/SIGTERM; drives graceful shutdown and background jobs.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// Restore default signal handling once cancelled so a second Ctrl-C
+	// kills the process instead of waiting for the drain.
+	context.AfterFunc(ctx, stop)
+
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	var code int
+	switch command {
+	case "serve":
+		code = runServe(ctx, cfg)
+	case "migrate":
+		code = runMigrate(ctx, cfg, args)
+	case "seed":
+		code = runSeed(ctx, cfg, args)
+	case "user":
+		code = runUser(ctx, cfg, args)
+	case "event":
+		code = runEvent(ctx, cfg, args)
+	case "reconcile-seats":
+		code = runReconcileSeats(ctx, cfg, args)
+	case "simulate":
+		code = runSimulate(ctx, cfg, args)
+	case "help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", command, usage)
+		code = 2
+	}
+
+	stop()
+	os.Exit(code)
+}
+
+// parseFlags parses a subcommand's flags, mapping -help to exit code 0 and
+// any other parse error to 2. ok is false when the caller should return code.
+func parseFlags(fs *flag.FlagSet, args []string) (code int, ok bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, false
+		}
+		return 2, false
 	}
+	return 0, true
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"event_registration/internal/config"
+	"event_registration/internal/db"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [N]        roll back the last N migrations (default 1)
+  status          list migrations and whether they are applied
+  to <version>    migrate up or down to exactly <version> (0 rolls back everything)`
+
+// runMigrate implements the `migrate` subcommand and returns the exit code.
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	database := db.Connect(cfg)
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to load migrations:", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				fmt.Fprintln(os.Stderr, "invalid step count:", args[1])
+				return 2
+			}
+		}
+		err = migrator.Down(ctx, steps)
+	case "to":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil {
+			fmt.Fprintln(os.Stderr, "invalid version:", args[1])
+			return 2
+		}
+		err = migrator.To(ctx, version)
+	case "status":
+		err = printMigrationStatus(ctx, migrator)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migration failed:", err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrationStatus(ctx context.Context, migrator *db.Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"event_registration/internal/config"
+	"event_registration/internal/db"
+	"event_registration/internal/services"
+)
+
+// runReconcileSeats reports events whose seats_remaining counter disagrees
+// with capacity minus confirmed registrations, and waitlists left behind
+// while seats are free. Without -repair it exits 1 when anything is found so
+// it can gate deploy scripts.
+func runReconcileSeats(ctx context.Context, cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("reconcile-seats", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "fix inconsistencies under the event row lock")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	a := newApp(cfg, db.Connect(cfg))
+	var report *services.ReconciliationReport
+	var err error
+	if *repair {
+		report, err = a.reconciler.Repair(ctx, "")
+	} else {
+		report, err = a.reconciler.Check(ctx)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "reconcile failed:", err)
+		return 1
+	}
+	if report.Consistent() {
+		fmt.Println("all seat counters are consistent")
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(report.SeatDrift) > 0 {
+		fmt.Fprintln(w, "SEAT DRIFT\nEVENT\tTITLE\tCAPACITY\tCONFIRMED\tSEATS REMAINING\tEXPECTED")
+		for _, d := range report.SeatDrift {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%d\n", d.EventID, d.Title, d.Capacity, d.ConfirmedCount, d.SeatsRemaining, d.ExpectedSeatsRemaining())
+		}
+		fmt.Fprintln(w)
+	}
+	if len(report.OrphanedWaitlists) > 0 {
+		fmt.Fprintln(w, "WAITLISTED WHILE SEATS ARE FREE\nEVENT\tTITLE\tFREE SEATS\tWAITLISTED")
+		for _, o := range report.OrphanedWaitlists {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", o.EventID, o.Title, o.FreeSeats, o.WaitlistCount)
+		}
+		fmt.Fprintln(w)
+	}
+	if *repair {
+		fmt.Fprintln(w, "REPAIRS\nEVENT\tSEATS BEFORE\tSEATS AFTER\tPROMOTED\tOVERBOOKED")
+		for _, r := range report.Repairs {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%t\n", r.EventID, r.SeatsBefore, r.SeatsAfter, r.Promoted, r.Overbooked)
+		}
+	}
+	w.Flush()
+
+	if *repair {
+		return 0
+	}
+	return 1
+}
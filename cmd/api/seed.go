@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"event_registration/internal/config"
+	"event_registration/internal/db"
+)
+
+// runSeed implements `seed`. Without options it loads the built-in sample
+// data set (skipped when users already exist).
+func runSeed(ctx context.Context, cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	fixture := fs.String("fixture", "", "YAML or JSON fixture file to load")
+	users := fs.Int("users", 0, "number of synthetic audience users to generate")
+	events := fs.Int("events", 0, "number of synthetic published events to generate")
+	capacity := fs.Int("capacity", 100, "capacity of each synthetic event")
+	force := fs.Bool("force", false, "allow seeding when running in production mode")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: api seed [-fixture file] [-users N] [-events N] [-capacity N] [-force]")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if cfg.IsProduction() && !*force {
+		fmt.Fprintln(os.Stderr, "refusing to seed in production mode without -force")
+		return 1
+	}
+
+	database := db.InitDB(cfg)
+
+	var err error
+	switch {
+	case *fixture != "":
+		var f *db.Fixture
+		if f, err = db.LoadFixture(*fixture); err == nil {
+			err = db.SeedFixture(ctx, database, f)
+		}
+	case *users > 0 || *events > 0:
+		err = db.SeedSynthetic(ctx, database, db.SyntheticSize{Users: *users, Events: *events, Capacity: *capacity})
+	default:
+		db.SeedDatabase(database)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "seed failed:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"event_registration/internal/config"
+	"event_registration/internal/db"
+	"event_registration/internal/handlers"
+	"event_registration/internal/logging"
+	"event_registration/internal/metrics"
+	"event_registration/internal/payments"
+	"event_registration/internal/router"
+	"event_registration/internal/tracing"
+)
+
+// runServe runs the HTTP API until ctx is cancelled, then drains.
+func runServe(ctx context.Context, cfg *config.Config) int {
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Exporter, cfg.Tracing.SampleRatio)
+	if err != nil {
+		logging.Fatal("Failed to initialize tracing", "error", err)
+	}
+
+	slog.Info("Initializing Database...", "environment", cfg.Environment)
+	database := db.InitDB(cfg)
+	if err := metrics.RegisterDBStats(database); err != nil {
+		logging.Fatal("Failed to register database metrics", "error", err)
+	}
+	migrator, err := db.NewMigrator(database)
+	if err != nil {
+		logging.Fatal("Failed to load migrations", "error", err)
+	}
+
+	// Sample data only when explicitly enabled; production config refuses it.
+	if cfg.Features.SeedData {
+		db.SeedDatabase(database)
+	}
+
+	a := newApp(cfg, database)
+
+	// Background Jobs
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		a.statsService.RunRefreshJob(ctx, cfg.Stats.RefreshInterval)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		a.queueService.RunWorker(ctx, cfg.Booking.QueuePollInterval)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		a.waitingRoom.RunAdmitter(ctx, cfg.WaitingRoom.AdmitInterval)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		a.orderService.RunExpiryJob(ctx, cfg.Payments.ExpiryInterval)
+	}()
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		a.refunds.RunJob(ctx, cfg.Payments.RefundRetryInterval)
+	}()
+	if cfg.Reconcile.Interval > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			a.reconciler.RunJob(ctx, cfg.Reconcile.Interval, cfg.Reconcile.AutoRepair)
+		}()
+	}
+
+	// Handlers
+	authHandler := handlers.NewAuthHandler(a.authService, cfg.Auth.JWTSecret)
+	eventHandler := handlers.NewEventHandler(a.eventService, a.regService, a.queueService, a.waitingRoom, a.groups, a.seating)
+	organizerHandler := handlers.NewOrganizerHandler(a.eventService, a.regService, a.importService, a.waitingRoom, a.promoService, a.seating)
+	adminHandler := handlers.NewAdminHandler(a.eventService, a.simService, a.statsService, a.reconciler, a.refunds)
+	healthHandler := handlers.NewHealthHandler(database, migrator)
+	// The stand-in checkout page confirms payments, so never outside development.
+	var fakeCheckout *payments.FakeProvider
+	if !cfg.IsProduction() {
+		fakeCheckout = a.fakePayments
+	}
+	orderHandler := handlers.NewOrderHandler(a.orderService, a.promoService, a.waitingRoom, fakeCheckout)
+	transferHandler := handlers.NewTransferHandler(a.transfers)
+	venueHandler := handlers.NewVenueHandler(a.venues)
+	seriesHandler := handlers.NewSeriesHandler(a.series)
+
+	// Router
+	slog.Info("Setting up Router...")
+	r := router.SetupRouter(
+		cfg,
+		authHandler,
+		eventHandler,
+		organizerHandler,
+		adminHandler,
+		orderHandler,
+		transferHandler,
+		venueHandler,
+		seriesHandler,
+		healthHandler,
+	)
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           r,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	srv.RegisterOnShutdown(eventHandler.CloseStreams)
+
+	go func() {
+		slog.Info("Starting Server...", "port", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Fatal("Server failed to start", "error", err)
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("Shutdown signal received, draining...", "timeout", cfg.Server.ShutdownTimeout.String())
+
+	// Fail readiness first so no new traffic is routed here, then let
+	// in-flight requests (and their BookEvent transactions) complete.
+	healthHandler.SetDraining()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("HTTP server did not drain cleanly", "error", err)
+	}
+	workers.Wait()
+	if err := a.importService.Close(shutdownCtx); err != nil {
+		slog.Error("Background imports did not finish before shutdown", "error", err)
+	}
+	if err := a.simService.Close(shutdownCtx); err != nil {
+		slog.Error("Simulations did not clean up before shutdown", "error", err)
+	}
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+	if sqlDB, err := database.DB(); err == nil {
+		sqlDB.Close()
+	}
+
+	slog.Info("Server stopped")
+	return 0
+}
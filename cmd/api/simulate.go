@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"event_registration/internal/config"
+	"event_registration/internal/db"
+	"event_registration/internal/models"
+)
+
+// runSimulate runs a simulation scenario in the foreground and prints its
+// report. It exits 1 when the run fails or any invariant does not hold, so it
+// can gate load tests in CI.
+func runSimulate(ctx context.Context, cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	eventID := fs.String("event", "", "event ID to book (required)")
+	users := fs.Int("users", 100, "number of simulated users")
+	workers := fs.Int("workers", 0, "concurrent workers (default min(users, 50))")
+	cancelRatio := fs.Float64("cancel-ratio", 0, "share of confirmed users who cancel")
+	rebookRatio := fs.Float64("rebook-ratio", 0, "share of cancelled users who book again")
+	burstSize := fs.Int("burst-size", 0, "users released per burst (0 releases everyone at once)")
+	burstInterval := fs.Duration("burst-interval", 0, "pause between bursts")
+	strategy := fs.String("strategy", "", "allocation strategy to force for the run")
+	seed := fs.Int64("seed", 0, "seed for the cancel/rebook dice (0 picks one)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *eventID == "" {
+		fmt.Fprintln(os.Stderr, "-event is required")
+		return 2
+	}
+
+	scenario := models.SimulationScenario{
+		Users:           *users,
+		Workers:         *workers,
+		CancelRatio:     *cancelRatio,
+		RebookRatio:     *rebookRatio,
+		BurstSize:       *burstSize,
+		BurstIntervalMs: int(*burstInterval / time.Millisecond),
+		Strategy:        models.AllocationStrategy(*strategy),
+		Seed:            *seed,
+	}
+
+	a := newApp(cfg, db.Connect(cfg))
+	job, err := a.simService.Run(ctx, *eventID, "", scenario)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "simulation failed:", err)
+		return 1
+	}
+	if job.Status == models.SimulationJobFailed || job.Report == nil {
+		fmt.Fprintln(os.Stderr, "simulation failed:", job.Error)
+		return 1
+	}
+
+	report := job.Report
+	fmt.Printf("job:             %s\n", job.ID)
+	fmt.Printf("strategy:        %s (seed %d)\n", report.Strategy, job.Scenario.Seed)
+	fmt.Printf("attempted:       %d\n", report.TotalAttempted)
+	fmt.Printf("booked:          %d\n", report.SuccessCount)
+	fmt.Printf("waitlisted:      %d\n", report.WaitlistedCount)
+	fmt.Printf("failed:          %d\n", report.FailedCount)
+	fmt.Printf("cancelled:       %d\n", report.CancelledCount)
+	fmt.Printf("rebooked:        %d\n", report.RebookedCount)
+	fmt.Printf("seats remaining: %d\n", report.FinalSeatsRemaining)
+	fmt.Printf("duration:        %dms\n\n", report.DurationMs)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OPERATION\tCOUNT\tP50 MS\tP95 MS\tP99 MS\tMAX MS")
+	for _, op := range sortedKeys(report.Latency) {
+		l := report.Latency[op]
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\n", op, l.Count, l.P50Ms, l.P95Ms, l.P99Ms, l.MaxMs)
+	}
+	if len(report.Errors) > 0 {
+		fmt.Fprintln(w, "\nOPERATION\tERROR\tCOUNT")
+		for _, op := range sortedKeys(report.Errors) {
+			for _, reason := range sortedKeys(report.Errors[op]) {
+				fmt.Fprintf(w, "%s\t%s\t%d\n", op, reason, report.Errors[op][reason])
+			}
+		}
+	}
+	fmt.Fprintln(w, "\nINVARIANT\tOK\tDETAIL")
+	healthy := true
+	for _, inv := range report.Invariants {
+		fmt.Fprintf(w, "%s\t%t\t%s\n", inv.Name, inv.OK, inv.Detail)
+		healthy = healthy && inv.OK
+	}
+	w.Flush()
+
+	if !healthy {
+		return 1
+	}
+	return 0
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"event_registration/internal/config"
+	"event_registration/internal/db"
+	"event_registration/internal/models"
+)
+
+const userUsage = `usage: api user <command>
+
+commands:
+  create-admin -email E -name N [-password P]   create an administrator (password defaults to $ADMIN_PASSWORD)
+  set-role -email E -role ROLE                   set a user's role to AUDIENCE, ORGANIZER or ADMIN`
+
+// Matches the minimum enforced by POST /auth/register.
+const minPasswordLength = 6
+
+func runUser(ctx context.Context, cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, userUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "create-admin":
+		return runCreateAdmin(ctx, cfg, args[1:])
+	case "set-role":
+		return runSetRole(ctx, cfg, args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, userUsage)
+		return 2
+	}
+}
+
+func runCreateAdmin(ctx context.Context, cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("user create-admin", flag.ContinueOnError)
+	email := fs.String("email", "", "email address (required)")
+	name := fs.String("name", "Administrator", "display name")
+	password := fs.String("password", os.Getenv("ADMIN_PASSWORD"), "password; prefer ADMIN_PASSWORD to keep it out of shell history")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *email == "" {
+		fmt.Fprintln(os.Stderr, "-email is required")
+		return 2
+	}
+	if len(*password) < minPasswordLength {
+		fmt.Fprintf(os.Stderr, "password must be at least %d characters (use -password or ADMIN_PASSWORD)\n", minPasswordLength)
+		return 2
+	}
+
+	a := newApp(cfg, db.Connect(cfg))
+	user, err := a.authService.Register(ctx, *name, *email, *password, models.RoleAdmin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "create admin failed:", err)
+		return 1
+	}
+	fmt.Printf("created admin %s (%s)\n", user.Email, user.ID)
+	return 0
+}
+
+func runSetRole(ctx context.Context, cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("user set-role", flag.ContinueOnError)
+	email := fs.String("email", "", "email address (required)")
+	role := fs.String("role", "", "AUDIENCE, ORGANIZER or ADMIN (required)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *email == "" || *role == "" {
+		fmt.Fprintln(os.Stderr, "-email and -role are required")
+		return 2
+	}
+
+	a := newApp(cfg, db.Connect(cfg))
+	user, err := a.authService.SetRole(ctx, *email, models.Role(strings.ToUpper(*role)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "set role failed:", err)
+		return 1
+	}
+	fmt.Printf("%s is now %s\n", user.Email, user.Role)
+	return 0
+}
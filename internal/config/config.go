@@ -1,42 +1,426 @@
 package config
 
 import (
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"event_registration/internal/models"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Config is the fully resolved application configuration. Values are layered
+// with increasing precedence: built-in defaults, the YAML file given by
+// --config / CONFIG_FILE, environment variables (including .env), and flags.
 type Config struct {
-	DBHost     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	DBPort     string
-	JWTSecret  string
-	ServerPort string
+	Environment string `yaml:"environment"`
+
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Auth        AuthConfig        `yaml:"auth"`
+	CORS        CORSConfig        `yaml:"cors"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Booking     BookingConfig     `yaml:"booking"`
+	WaitingRoom WaitingRoomConfig `yaml:"waiting_room"`
+	Payments    PaymentsConfig    `yaml:"payments"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Stats       StatsConfig       `yaml:"stats"`
+	Reconcile   ReconcileConfig   `yaml:"reconcile"`
+	Features    FeatureConfig     `yaml:"features"`
 }
 
-func LoadConfig() *Config {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("No .env file found, relying on environment variables.")
-	}
+type ServerConfig struct {
+	Port            string        `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type DatabaseConfig struct {
+	// DSN, when set, is used verbatim and the discrete fields are ignored.
+	DSN      string `yaml:"dsn"`
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+
+	// Apply pending migrations on boot. Disable to run `migrate up` as a
+	// separate deploy step instead.
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+type AuthConfig struct {
+	JWTSecret  string        `yaml:"jwt_secret"`
+	TokenTTL   time.Duration `yaml:"token_ttl"`
+	BcryptCost int           `yaml:"bcrypt_cost"`
+}
 
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+type RateLimitConfig struct {
+	// Requests per second allowed per client IP; 0 disables limiting.
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+}
+
+type BookingConfig struct {
+	// Default seat allocation strategy: pessimistic, optimistic or atomic.
+	// Individual events can override it.
+	Strategy          models.AllocationStrategy `yaml:"strategy"`
+	OptimisticRetries int                       `yaml:"optimistic_retries"`
+
+	// Queued admission: requests resolved per lock acquisition, and how
+	// often the worker looks for requests enqueued on other instances.
+	QueueBatchSize    int           `yaml:"queue_batch_size"`
+	QueuePollInterval time.Duration `yaml:"queue_poll_interval"`
+
+	// Most seats one group booking may reserve.
+	MaxGroupSize int `yaml:"max_group_size"`
+}
+
+type WaitingRoomConfig struct {
+	// How often open rooms admit their next users, and how long an
+	// admission token stays valid after the user is admitted.
+	AdmitInterval time.Duration `yaml:"admit_interval"`
+	TokenTTL      time.Duration `yaml:"token_ttl"`
+}
+
+type PaymentsConfig struct {
+	// Payment provider for paid events. Only "fake", which approves
+	// payments without moving money, is built in.
+	Provider      string `yaml:"provider"`
+	WebhookSecret string `yaml:"webhook_secret"`
+	// How long a pending order holds its seat, and how often lapsed holds
+	// are released.
+	HoldTTL        time.Duration `yaml:"hold_ttl"`
+	ExpiryInterval time.Duration `yaml:"expiry_interval"`
+	// How often refunds the provider rejected are retried.
+	RefundRetryInterval time.Duration `yaml:"refund_retry_interval"`
+}
+
+type LoggingConfig struct {
+	Level string `yaml:"level"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type StatsConfig struct {
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+type ReconcileConfig struct {
+	// How often the seat reconciler runs; 0 disables the scheduled job.
+	Interval   time.Duration `yaml:"interval"`
+	AutoRepair bool          `yaml:"auto_repair"`
+}
+
+type FeatureConfig struct {
+	SeedData   bool `yaml:"seed_data"`
+	Simulation bool `yaml:"simulation"`
+	CSVImport  bool `yaml:"csv_import"`
+}
+
+// Insecure values that are tolerated in development only.
+const (
+	defaultJWTSecret  = "supersecret"
+	defaultDBPassword = "postgres"
+)
+
+func defaults() *Config {
 	return &Config{
-		DBHost:     getEnv("DB_HOST", "localhost"),
-		DBUser:     getEnv("DB_USER", "postgres"),
-		DBPassword: getEnv("DB_PASSWORD", "postgres"),
-		DBName:     getEnv("DB_NAME", "event_registration"),
-		DBPort:     getEnv("DB_PORT", "5432"),
-		JWTSecret:  getEnv("JWT_SECRET", "supersecret"),
-		ServerPort: getEnv("PORT", "8080"),
+		Environment: EnvDevelopment,
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            "5432",
+			User:            "postgres",
+			Password:        defaultDBPassword,
+			Name:            "event_registration",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			AutoMigrate:     true,
+		},
+		Auth: AuthConfig{
+			JWTSecret:  defaultJWTSecret,
+			TokenTTL:   24 * time.Hour,
+			BcryptCost: 14,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"*"},
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: 20,
+			Burst:             40,
+		},
+		Booking: BookingConfig{
+			Strategy:          models.AllocationPessimistic,
+			OptimisticRetries: 5,
+			QueueBatchSize:    100,
+			QueuePollInterval: 250 * time.Millisecond,
+			MaxGroupSize:      10,
+		},
+		WaitingRoom: WaitingRoomConfig{
+			AdmitInterval: 5 * time.Second,
+			TokenTTL:      10 * time.Minute,
+		},
+		Payments: PaymentsConfig{
+			Provider:            "fake",
+			HoldTTL:             15 * time.Minute,
+			ExpiryInterval:      time.Minute,
+			RefundRetryInterval: time.Minute,
+		},
+		Logging: LoggingConfig{Level: "info"},
+		Tracing: TracingConfig{Exporter: "none", SampleRatio: 1.0},
+		Stats:   StatsConfig{RefreshInterval: 5 * time.Minute},
+		Reconcile: ReconcileConfig{
+			Interval: 15 * time.Minute,
+		},
+		Features: FeatureConfig{
+			SeedData:   true,
+			Simulation: true,
+			CSVImport:  true,
+		},
+	}
+}
+
+// setting binds one config field to its environment variable and flag.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(string) error
+}
+
+func (c *Config) settings() []setting {
+	return []setting{
+		{"APP_ENV", "env", "runtime environment: development or production", stringVar(&c.Environment)},
+
+		{"PORT", "port", "HTTP listen port", stringVar(&c.Server.Port)},
+		{"SERVER_READ_TIMEOUT", "server-read-timeout", "HTTP read timeout", durationVar(&c.Server.ReadTimeout)},
+		{"SERVER_WRITE_TIMEOUT", "server-write-timeout", "HTTP write timeout", durationVar(&c.Server.WriteTimeout)},
+		{"SERVER_IDLE_TIMEOUT", "server-idle-timeout", "HTTP keep-alive idle timeout", durationVar(&c.Server.IdleTimeout)},
+		{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "graceful shutdown drain window", durationVar(&c.Server.ShutdownTimeout)},
+
+		{"DB_DSN", "db-dsn", "full Postgres DSN (overrides the discrete DB settings)", stringVar(&c.Database.DSN)},
+		{"DB_HOST", "db-host", "Postgres host", stringVar(&c.Database.Host)},
+		{"DB_PORT", "db-port", "Postgres port", stringVar(&c.Database.Port)},
+		{"DB_USER", "db-user", "Postgres user", stringVar(&c.Database.User)},
+		{"DB_PASSWORD", "db-password", "Postgres password", stringVar(&c.Database.Password)},
+		{"DB_NAME", "db-name", "Postgres database name", stringVar(&c.Database.Name)},
+		{"DB_SSLMODE", "db-sslmode", "Postgres sslmode", stringVar(&c.Database.SSLMode)},
+		{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "connection pool size", intVar(&c.Database.MaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "idle connections kept in the pool", intVar(&c.Database.MaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "maximum connection lifetime", durationVar(&c.Database.ConnMaxLifetime)},
+		{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "maximum connection idle time", durationVar(&c.Database.ConnMaxIdleTime)},
+		{"DB_AUTO_MIGRATE", "db-auto-migrate", "apply pending migrations on boot", boolVar(&c.Database.AutoMigrate)},
+
+		{"JWT_SECRET", "jwt-secret", "HMAC secret for signing tokens", stringVar(&c.Auth.JWTSecret)},
+		{"JWT_TTL", "jwt-ttl", "lifetime of issued tokens", durationVar(&c.Auth.TokenTTL)},
+		{"BCRYPT_COST", "bcrypt-cost", "bcrypt work factor for password hashes", intVar(&c.Auth.BcryptCost)},
+
+		{"CORS_ALLOWED_ORIGINS", "cors-allowed-origins", "comma-separated allowed origins, or *", listVar(&c.CORS.AllowedOrigins)},
+
+		{"RATE_LIMIT_RPS", "rate-limit-rps", "requests per second per client IP (0 disables)", floatVar(&c.RateLimit.RequestsPerSecond)},
+		{"RATE_LIMIT_BURST", "rate-limit-burst", "burst size per client IP", intVar(&c.RateLimit.Burst)},
+
+		{"BOOKING_STRATEGY", "booking-strategy", "default seat allocation: pessimistic, optimistic or atomic", func(v string) error {
+			c.Booking.Strategy = models.AllocationStrategy(v)
+			return nil
+		}},
+		{"BOOKING_OPTIMISTIC_RETRIES", "booking-optimistic-retries", "retries after a lost optimistic version check", intVar(&c.Booking.OptimisticRetries)},
+		{"BOOKING_QUEUE_BATCH_SIZE", "booking-queue-batch-size", "queued booking requests resolved per event lock", intVar(&c.Booking.QueueBatchSize)},
+		{"BOOKING_QUEUE_POLL_INTERVAL", "booking-queue-poll-interval", "how often the booking queue worker polls for requests", durationVar(&c.Booking.QueuePollInterval)},
+		{"BOOKING_MAX_GROUP_SIZE", "booking-max-group-size", "most seats one group booking may reserve", intVar(&c.Booking.MaxGroupSize)},
+		{"WAITING_ROOM_ADMIT_INTERVAL", "waiting-room-admit-interval", "how often waiting rooms admit users", durationVar(&c.WaitingRoom.AdmitInterval)},
+		{"WAITING_ROOM_TOKEN_TTL", "waiting-room-token-ttl", "how long a waiting room admission token is valid", durationVar(&c.WaitingRoom.TokenTTL)},
+
+		{"PAYMENT_PROVIDER", "payment-provider", "payment provider for paid events", stringVar(&c.Payments.Provider)},
+		{"PAYMENT_WEBHOOK_SECRET", "payment-webhook-secret", "secret that signs payment webhooks", stringVar(&c.Payments.WebhookSecret)},
+		{"PAYMENT_HOLD_TTL", "payment-hold-ttl", "how long a pending order holds its seat", durationVar(&c.Payments.HoldTTL)},
+		{"PAYMENT_EXPIRY_INTERVAL", "payment-expiry-interval", "how often expired seat holds are released", durationVar(&c.Payments.ExpiryInterval)},
+		{"PAYMENT_REFUND_RETRY_INTERVAL", "payment-refund-retry-interval", "how often pending refunds are retried", durationVar(&c.Payments.RefundRetryInterval)},
+
+		{"LOG_LEVEL", "log-level", "debug, info, warn or error", stringVar(&c.Logging.Level)},
+		{"TRACING_EXPORTER", "tracing-exporter", "none, stdout or otlp", stringVar(&c.Tracing.Exporter)},
+		{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "fraction of traces sampled", floatVar(&c.Tracing.SampleRatio)},
+		{"STATS_REFRESH_INTERVAL", "stats-refresh-interval", "admin dashboard refresh interval", durationVar(&c.Stats.RefreshInterval)},
+
+		{"RECONCILE_INTERVAL", "reconcile-interval", "seat reconciliation interval (0 disables)", durationVar(&c.Reconcile.Interval)},
+		{"RECONCILE_AUTO_REPAIR", "reconcile-auto-repair", "repair inconsistencies found by the scheduled reconciler", boolVar(&c.Reconcile.AutoRepair)},
+
+		{"FEATURE_SEED_DATA", "feature-seed-data", "seed sample data into an empty database on boot", boolVar(&c.Features.SeedData)},
+		{"FEATURE_SIMULATION", "feature-simulation", "enable the admin concurrency simulator", boolVar(&c.Features.Simulation)},
+		{"FEATURE_CSV_IMPORT", "feature-csv-import", "enable organizer CSV attendee import", boolVar(&c.Features.CSVImport)},
+	}
+}
+
+// Load resolves the configuration from args (normally os.Args[1:]) and the
+// environment. Flag parsing stops at the first non-flag argument; those
+// remaining arguments (e.g. a subcommand) are returned unchanged.
+func Load(args []string) (*Config, []string, error) {
+	if err := godotenv.Load(); err != nil {
+		slog.Info("No .env file found, relying on environment variables.")
+	}
+
+	cfg := defaults()
+	settings := cfg.settings()
+
+	// Flags have the highest precedence but must be parsed first to learn
+	// the config file path, so record them and apply them last.
+	type flagValue struct {
+		s     setting
+		value string
+	}
+	var flagValues []flagValue
+	fs := flag.NewFlagSet("api", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file (env CONFIG_FILE)")
+	for _, s := range settings {
+		s := s
+		fs.Func(s.flag, s.usage+" (env "+s.env+")", func(v string) error {
+			flagValues = append(flagValues, flagValue{s: s, value: v})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, nil, fmt.Errorf("parse config file: %w", err)
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(value); err != nil {
+				return nil, nil, fmt.Errorf("env %s: %w", s.env, err)
+			}
+		}
+	}
+
+	for _, fv := range flagValues {
+		if err := fv.s.set(fv.value); err != nil {
+			return nil, nil, fmt.Errorf("flag -%s: %w", fv.s.flag, err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// ConnectionString returns the Postgres DSN.
+func (d DatabaseConfig) ConnectionString() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode)
+}
+
+func (c *Config) IsProduction() bool {
+	return c.Environment == EnvProduction
+}
+
+func stringVar(p *string) func(string) error {
+	return func(v string) error {
+		*p = v
+		return nil
+	}
+}
+
+func intVar(p *int) func(string) error {
+	return func(v string) error {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		*p = n
+		return nil
+	}
+}
+
+func floatVar(p *float64) func(string) error {
+	return func(v string) error {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", v)
+		}
+		*p = f
+		return nil
+	}
+}
+
+func boolVar(p *bool) func(string) error {
+	return func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", v)
+		}
+		*p = b
+		return nil
+	}
+}
+
+func durationVar(p *time.Duration) func(string) error {
+	return func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", v)
+		}
+		*p = d
+		return nil
 	}
 }
 
-func getEnv(key, defaultVal string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+func listVar(p *[]string) func(string) error {
+	return func(v string) error {
+		var items []string
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		*p = items
+		return nil
 	}
-	return defaultVal
 }
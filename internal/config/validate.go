@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Validate reports every invalid or unsafe setting at once. Production mode
+// additionally refuses the development defaults.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Environment != EnvDevelopment && c.Environment != EnvProduction {
+		fail("environment must be %q or %q", EnvDevelopment, EnvProduction)
+	}
+
+	if c.Server.Port == "" {
+		fail("server port is required")
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		fail("server timeouts must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		fail("shutdown timeout must be positive")
+	}
+
+	if c.Database.DSN == "" && (c.Database.Host == "" || c.Database.Name == "" || c.Database.User == "") {
+		fail("database host, name and user are required unless a DSN is given")
+	}
+	if c.Database.MaxOpenConns <= 0 {
+		fail("database max open connections must be positive")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		fail("database max idle connections must be between 0 and max open connections")
+	}
+	if c.Database.ConnMaxLifetime < 0 || c.Database.ConnMaxIdleTime < 0 {
+		fail("database connection lifetimes must not be negative")
+	}
+
+	if c.Auth.JWTSecret == "" {
+		fail("JWT secret is required")
+	}
+	if c.Auth.TokenTTL <= 0 {
+		fail("JWT TTL must be positive")
+	}
+	if c.Auth.BcryptCost < bcrypt.MinCost || c.Auth.BcryptCost > bcrypt.MaxCost {
+		fail("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		fail("at least one CORS origin is required")
+	}
+
+	if c.RateLimit.RequestsPerSecond < 0 {
+		fail("rate limit must not be negative")
+	}
+	if c.RateLimit.RequestsPerSecond > 0 && c.RateLimit.Burst < 1 {
+		fail("rate limit burst must be at least 1")
+	}
+
+	if !c.Booking.Strategy.Valid() {
+		fail("booking strategy must be pessimistic, optimistic or atomic")
+	}
+	if c.Booking.OptimisticRetries < 0 {
+		fail("optimistic retries must not be negative")
+	}
+	if c.Booking.QueueBatchSize < 1 {
+		fail("booking queue batch size must be at least 1")
+	}
+	if c.Booking.QueuePollInterval <= 0 {
+		fail("booking queue poll interval must be positive")
+	}
+	if c.Booking.MaxGroupSize < 1 {
+		fail("booking max group size must be at least 1")
+	}
+	if c.WaitingRoom.AdmitInterval <= 0 {
+		fail("waiting room admit interval must be positive")
+	}
+	if c.WaitingRoom.TokenTTL <= 0 {
+		fail("waiting room token TTL must be positive")
+	}
+
+	if c.Payments.Provider != "fake" {
+		fail("payment provider must be fake")
+	}
+	if c.Payments.HoldTTL <= 0 {
+		fail("payment hold TTL must be positive")
+	}
+	if c.Payments.ExpiryInterval <= 0 {
+		fail("payment expiry interval must be positive")
+	}
+	if c.Payments.RefundRetryInterval <= 0 {
+		fail("payment refund retry interval must be positive")
+	}
+
+	switch strings.ToLower(c.Logging.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("log level must be debug, info, warn or error")
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		fail("tracing exporter must be none, stdout or otlp")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		fail("tracing sample ratio must be between 0 and 1")
+	}
+	if c.Stats.RefreshInterval <= 0 {
+		fail("stats refresh interval must be positive")
+	}
+
+	if c.Reconcile.Interval < 0 {
+		fail("reconcile interval must not be negative")
+	}
+
+	if c.IsProduction() {
+		if c.Auth.JWTSecret == defaultJWTSecret || len(c.Auth.JWTSecret) < 32 {
+			fail("production requires a JWT secret of at least 32 characters")
+		}
+		if c.Database.DSN == "" {
+			if c.Database.Password == defaultDBPassword {
+				fail("production refuses the default database password")
+			}
+			if c.Database.SSLMode == "disable" {
+				fail("production requires database TLS (sslmode other than disable)")
+			}
+		}
+		for _, origin := range c.CORS.AllowedOrigins {
+			if origin == "*" {
+				fail("production requires explicit CORS origins")
+			}
+		}
+		if c.Features.SeedData {
+			fail("production must not seed sample data")
+		}
+		if len(c.Payments.WebhookSecret) < 32 {
+			fail("production requires a payment webhook secret of at least 32 characters")
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -1,36 +1,55 @@
 package db
 
 import (
-	"fmt"
-	"log"
+	"context"
+	"time"
 
 	"event_registration/internal/config"
-	"event_registration/internal/models"
+	"event_registration/internal/logging"
+	"event_registration/internal/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func InitDB(cfg *config.Config) *gorm.DB {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
-		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort)
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+// Connect opens the connection pool without touching the schema.
+func Connect(cfg *config.Config) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(cfg.Database.ConnectionString()), &gorm.Config{
 		SkipDefaultTransaction: false, // Ensure transactions are active
+		Logger:                 logging.NewGormLogger(200 * time.Millisecond),
 	})
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		logging.Fatal("Failed to connect to database", "error", err)
+	}
+
+	if err := tracing.RegisterGormCallbacks(db); err != nil {
+		logging.Fatal("Failed to register tracing callbacks", "error", err)
 	}
 
-	// Auto Migrate the schemas
-	err = db.AutoMigrate(
-		&models.User{},
-		&models.Event{},
-		&models.Registration{},
-		&models.Waitlist{},
-		&models.AuditLog{},
-	)
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Failed to auto-migrate database schemas: %v", err)
+		logging.Fatal("Failed to access database pool", "error", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.Database.ConnMaxIdleTime)
+
+	return db
+}
+
+// InitDB connects and, unless disabled, applies pending migrations. The
+// migration advisory lock makes this safe when many replicas boot at once.
+func InitDB(cfg *config.Config) *gorm.DB {
+	db := Connect(cfg)
+
+	if cfg.Database.AutoMigrate {
+		migrator, err := NewMigrator(db)
+		if err != nil {
+			logging.Fatal("Failed to load migrations", "error", err)
+		}
+		if err := migrator.Up(context.Background()); err != nil {
+			logging.Fatal("Failed to apply database migrations", "error", err)
+		}
 	}
 
 	return db
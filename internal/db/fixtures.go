@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"event_registration/internal/models"
+	"event_registration/internal/utils"
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// Fixture is a hand-written data set loaded by `api seed -fixture`. JSON
+// files work too since JSON is valid YAML.
+type Fixture struct {
+	Users  []FixtureUser  `yaml:"users"`
+	Events []FixtureEvent `yaml:"events"`
+}
+
+type FixtureUser struct {
+	Name     string      `yaml:"name"`
+	Email    string      `yaml:"email"`
+	Password string      `yaml:"password"`
+	Role     models.Role `yaml:"role"`
+}
+
+// How long seeded events last when the fixture does not say.
+const defaultFixtureDuration = 2 * time.Hour
+
+// FixtureEvent gives either an absolute EventDate or StartsIn relative to
+// seeding time, so fixtures do not go stale. Events last Duration, two
+// hours by default.
+type FixtureEvent struct {
+	Title          string             `yaml:"title"`
+	Description    string             `yaml:"description"`
+	Location       string             `yaml:"location"`
+	EventDate      *time.Time         `yaml:"event_date"`
+	StartsIn       time.Duration      `yaml:"starts_in"`
+	Duration       time.Duration      `yaml:"duration"`
+	Timezone       string             `yaml:"timezone"`
+	Capacity       int                `yaml:"capacity"`
+	OrganizerEmail string             `yaml:"organizer"`
+	Status         models.EventStatus `yaml:"status"`
+}
+
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Fixture
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse fixture: %w", err)
+	}
+	return &f, nil
+}
+
+// SeedFixture inserts the fixture in one transaction. Users whose email
+// already exists are reused rather than duplicated.
+func SeedFixture(ctx context.Context, db *gorm.DB, f *Fixture) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, fu := range f.Users {
+			if fu.Email == "" || fu.Password == "" {
+				return errors.New("fixture users need an email and password")
+			}
+			var existing int64
+			if err := tx.Model(&models.User{}).Where("email = ?", fu.Email).Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				slog.InfoContext(ctx, "Fixture user already exists, skipping", "email", fu.Email)
+				continue
+			}
+
+			hash, err := utils.HashPassword(fu.Password)
+			if err != nil {
+				return err
+			}
+			role := fu.Role
+			if role == "" {
+				role = models.RoleAudience
+			}
+			user := models.User{Name: fu.Name, Email: fu.Email, PasswordHash: hash, Role: role}
+			if err := tx.Create(&user).Error; err != nil {
+				return fmt.Errorf("create user %s: %w", fu.Email, err)
+			}
+		}
+
+		for _, fe := range f.Events {
+			var organizer models.User
+			if err := tx.Where("email = ?", fe.OrganizerEmail).First(&organizer).Error; err != nil {
+				return fmt.Errorf("event %q: organizer %q not found", fe.Title, fe.OrganizerEmail)
+			}
+
+			eventDate := time.Now().Add(fe.StartsIn)
+			if fe.EventDate != nil {
+				eventDate = *fe.EventDate
+			}
+			if fe.Timezone != "" {
+				if _, err := time.LoadLocation(fe.Timezone); err != nil {
+					return fmt.Errorf("event %q: unknown timezone %q", fe.Title, fe.Timezone)
+				}
+			}
+			duration := fe.Duration
+			if duration <= 0 {
+				duration = defaultFixtureDuration
+			}
+			status := fe.Status
+			if status == "" {
+				status = models.EventStatusPublished
+			}
+			event := models.Event{
+				Title:          fe.Title,
+				Description:    fe.Description,
+				Location:       fe.Location,
+				EventDate:      eventDate,
+				EndsAt:         eventDate.Add(duration),
+				Timezone:       fe.Timezone,
+				Capacity:       fe.Capacity,
+				SeatsRemaining: fe.Capacity,
+				OrganizerID:    organizer.ID,
+				Status:         status,
+			}
+			if status == models.EventStatusPublished {
+				now := time.Now()
+				event.PublishedAt = &now
+			}
+			if err := tx.Create(&event).Error; err != nil {
+				return fmt.Errorf("create event %q: %w", fe.Title, err)
+			}
+		}
+
+		slog.InfoContext(ctx, "Fixture seeded", "users", len(f.Users), "events", len(f.Events))
+		return nil
+	})
+}
+
+// SyntheticSize controls the volume generated by SeedSynthetic.
+type SyntheticSize struct {
+	Users    int
+	Events   int
+	Capacity int
+}
+
+// syntheticPassword is shared by every generated user so that load tests can
+// log in as any of them.
+const syntheticPassword = "loadtest123"
+
+// SeedSynthetic generates audience users and published events owned by a
+// dedicated organizer, for load testing. Each run uses a fresh batch tag so
+// it can be repeated.
+func SeedSynthetic(ctx context.Context, db *gorm.DB, size SyntheticSize) error {
+	if size.Users < 0 || size.Events < 0 || size.Capacity < 0 {
+		return errors.New("seed sizes must not be negative")
+	}
+
+	// Hash once; bcrypt per user would dominate the run time.
+	hash, err := utils.HashPassword(syntheticPassword)
+	if err != nil {
+		return err
+	}
+	batch := uuid.New().String()[:8]
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users := make([]models.User, size.Users)
+		for i := range users {
+			users[i] = models.User{
+				Name:         fmt.Sprintf("Load Test User %d", i),
+				Email:        fmt.Sprintf("user%d_%s@seed.local", i, batch),
+				PasswordHash: hash,
+				Role:         models.RoleAudience,
+			}
+		}
+		if len(users) > 0 {
+			if err := tx.CreateInBatches(&users, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		if size.Events > 0 {
+			organizer := models.User{
+				Name:         "Load Test Organizer",
+				Email:        fmt.Sprintf("organizer_%s@seed.local", batch),
+				PasswordHash: hash,
+				Role:         models.RoleOrganizer,
+			}
+			if err := tx.Create(&organizer).Error; err != nil {
+				return err
+			}
+
+			now := time.Now()
+			events := make([]models.Event, size.Events)
+			for i := range events {
+				events[i] = models.Event{
+					Title:          fmt.Sprintf("Load Test Event %d (%s)", i, batch),
+					Description:    "Generated by api seed.",
+					Location:       "Online",
+					EventDate:      now.Add(time.Duration(i+1) * 24 * time.Hour),
+					EndsAt:         now.Add(time.Duration(i+1)*24*time.Hour + defaultFixtureDuration),
+					Capacity:       size.Capacity,
+					SeatsRemaining: size.Capacity,
+					OrganizerID:    organizer.ID,
+					Status:         models.EventStatusPublished,
+					PublishedAt:    &now,
+				}
+			}
+			if err := tx.CreateInBatches(&events, 500).Error; err != nil {
+				return err
+			}
+		}
+
+		slog.InfoContext(ctx, "Synthetic data seeded", "batch", batch, "users", size.Users, "events", size.Events)
+		return nil
+	})
+}
@@ -0,0 +1,9 @@
+DROP MATERIALIZED VIEW IF EXISTS mv_event_stats;
+DROP MATERIALIZED VIEW IF EXISTS mv_daily_bookings;
+DROP TABLE IF EXISTS import_row_results;
+DROP TABLE IF EXISTS import_jobs;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS waitlists;
+DROP TABLE IF EXISTS registrations;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,121 @@
+-- Baseline schema, equivalent to what AutoMigrate produced. Every statement
+-- is idempotent so databases created by AutoMigrate can adopt it as-is.
+
+CREATE TABLE IF NOT EXISTS users (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name          text NOT NULL,
+    email         text NOT NULL,
+    password_hash text NOT NULL,
+    role          varchar(20) NOT NULL DEFAULT 'AUDIENCE',
+    is_active     boolean DEFAULT true,
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS events (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    title           text NOT NULL,
+    description     text,
+    location        text,
+    event_date      timestamptz NOT NULL,
+    capacity        bigint NOT NULL CONSTRAINT chk_events_capacity CHECK (capacity >= 0),
+    seats_remaining bigint NOT NULL CONSTRAINT chk_events_seats_remaining CHECK (seats_remaining >= 0),
+    organizer_id    uuid NOT NULL CONSTRAINT fk_users_events REFERENCES users (id),
+    status          varchar(20) NOT NULL DEFAULT 'DRAFT',
+    published_at    timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS registrations (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       uuid NOT NULL CONSTRAINT fk_users_registrations REFERENCES users (id),
+    event_id      uuid NOT NULL CONSTRAINT fk_events_registrations REFERENCES events (id),
+    status        varchar(20) NOT NULL DEFAULT 'CONFIRMED',
+    checked_in_at timestamptz,
+    created_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_event ON registrations (user_id, event_id);
+
+CREATE TABLE IF NOT EXISTS waitlists (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    uuid NOT NULL CONSTRAINT fk_waitlists_user REFERENCES users (id),
+    event_id   uuid NOT NULL CONSTRAINT fk_events_waitlist_items REFERENCES events (id),
+    position   bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_waitlist_user_event ON waitlists (user_id, event_id);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id    uuid NOT NULL CONSTRAINT fk_audit_logs_actor REFERENCES users (id),
+    action      text NOT NULL,
+    entity_type text NOT NULL,
+    entity_id   uuid NOT NULL,
+    event_id    uuid,
+    timestamp   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_audit_event_time ON audit_logs (event_id, timestamp);
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id         uuid NOT NULL,
+    organizer_id     uuid NOT NULL,
+    dry_run          boolean NOT NULL DEFAULT false,
+    status           varchar(20) NOT NULL DEFAULT 'PENDING',
+    total_rows       bigint NOT NULL DEFAULT 0,
+    processed_rows   bigint NOT NULL DEFAULT 0,
+    booked_count     bigint NOT NULL DEFAULT 0,
+    waitlisted_count bigint NOT NULL DEFAULT 0,
+    skipped_count    bigint NOT NULL DEFAULT 0,
+    failed_count     bigint NOT NULL DEFAULT 0,
+    error            text,
+    created_at       timestamptz,
+    updated_at       timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_event_id ON import_jobs (event_id);
+
+CREATE TABLE IF NOT EXISTS import_row_results (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id       uuid NOT NULL CONSTRAINT fk_import_jobs_results REFERENCES import_jobs (id),
+    row_number   bigint NOT NULL,
+    name         text,
+    email        text,
+    user_created boolean,
+    outcome      varchar(20) NOT NULL,
+    message      text
+);
+CREATE INDEX IF NOT EXISTS idx_import_row_results_job_id ON import_row_results (job_id);
+
+-- Admin dashboard views, refreshed by the stats job.
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_daily_bookings AS
+    SELECT date_trunc('day', created_at)::date AS day,
+        COUNT(*) AS bookings,
+        COUNT(*) FILTER (WHERE status = 'CANCELLED') AS cancellations
+    FROM registrations
+    GROUP BY 1;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mv_daily_bookings_day ON mv_daily_bookings (day);
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_event_stats AS
+    SELECT e.id AS event_id,
+        e.title,
+        e.organizer_id,
+        e.status,
+        e.capacity,
+        e.seats_remaining,
+        COALESCE(r.confirmed, 0) AS confirmed_count,
+        COALESCE(w.waitlisted, 0) AS waitlist_count,
+        CASE WHEN e.capacity > 0
+            THEN (e.capacity - e.seats_remaining)::float8 / e.capacity
+            ELSE 0 END AS fill_rate
+    FROM events e
+    LEFT JOIN (
+        SELECT event_id, COUNT(*) AS confirmed FROM registrations
+        WHERE status = 'CONFIRMED' GROUP BY event_id
+    ) r ON r.event_id = e.id
+    LEFT JOIN (
+        SELECT event_id, COUNT(*) AS waitlisted FROM waitlists GROUP BY event_id
+    ) w ON w.event_id = e.id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mv_event_stats_event ON mv_event_stats (event_id);
+CREATE INDEX IF NOT EXISTS idx_mv_event_stats_organizer ON mv_event_stats (organizer_id);
@@ -0,0 +1,4 @@
+-- version backs optimistic seat allocation; every write to seats_remaining
+-- must bump it. allocation_strategy overrides the deployment default per event.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS allocation_strategy varchar(20);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS booking_requests;
+ALTER TABLE events DROP COLUMN IF EXISTS admission_mode;
@@ -0,0 +1,19 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS admission_mode varchar(20) NOT NULL DEFAULT 'DIRECT';
+
+-- Durable FIFO of booking requests for events in QUEUE admission mode. The
+-- ticket sequence gives a global arrival order.
+CREATE TABLE IF NOT EXISTS booking_requests (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    ticket          bigserial NOT NULL UNIQUE,
+    event_id        uuid NOT NULL CONSTRAINT fk_booking_requests_event REFERENCES events (id),
+    user_id         uuid NOT NULL CONSTRAINT fk_booking_requests_user REFERENCES users (id),
+    status          varchar(20) NOT NULL DEFAULT 'PENDING',
+    registration_id uuid,
+    waitlist_id     uuid,
+    failure_reason  text,
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    processed_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_booking_requests_pending ON booking_requests (event_id, ticket) WHERE status = 'PENDING';
+-- At most one open request per user and event.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_requests_open_user ON booking_requests (event_id, user_id) WHERE status = 'PENDING';
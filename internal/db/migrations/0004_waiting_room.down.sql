@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS waiting_room_entries;
+DROP TABLE IF EXISTS waiting_rooms;
@@ -0,0 +1,25 @@
+-- Waiting room settings for an event. While enabled, booking requires an
+-- admission token; last_admission_at carries the admission rate between
+-- worker ticks.
+CREATE TABLE IF NOT EXISTS waiting_rooms (
+    event_id          uuid PRIMARY KEY CONSTRAINT fk_waiting_rooms_event REFERENCES events (id),
+    enabled           boolean NOT NULL DEFAULT false,
+    sale_starts_at    timestamptz,
+    admit_per_minute  integer NOT NULL,
+    last_admission_at timestamptz,
+    created_at        timestamptz NOT NULL DEFAULT now(),
+    updated_at        timestamptz NOT NULL DEFAULT now()
+);
+
+-- One entry per user and event. rank is random for users who arrived before
+-- the sale opened and arrival-ordered after everyone else for later users.
+CREATE TABLE IF NOT EXISTS waiting_room_entries (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id    uuid NOT NULL CONSTRAINT fk_waiting_room_entries_event REFERENCES events (id),
+    user_id     uuid NOT NULL CONSTRAINT fk_waiting_room_entries_user REFERENCES users (id),
+    rank        bigint NOT NULL,
+    joined_at   timestamptz NOT NULL DEFAULT now(),
+    admitted_at timestamptz,
+    CONSTRAINT idx_waiting_room_entries_user_event UNIQUE (event_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_waiting_room_entries_waiting ON waiting_room_entries (event_id, rank) WHERE admitted_at IS NULL;
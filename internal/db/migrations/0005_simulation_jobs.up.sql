@@ -0,0 +1,13 @@
+-- Asynchronous concurrency simulations; scenario and report are JSON.
+CREATE TABLE IF NOT EXISTS simulation_jobs (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id     uuid NOT NULL CONSTRAINT fk_simulation_jobs_event REFERENCES events (id),
+    requested_by uuid,
+    status       varchar(20) NOT NULL,
+    scenario     jsonb NOT NULL,
+    report       jsonb,
+    error        text,
+    created_at   timestamptz,
+    finished_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_simulation_jobs_event_id ON simulation_jobs (event_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+ALTER TABLE events DROP COLUMN IF EXISTS price_currency;
+ALTER TABLE events DROP COLUMN IF EXISTS price_amount;
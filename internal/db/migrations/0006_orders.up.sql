@@ -0,0 +1,42 @@
+-- Ticket price in the currency's minor unit; 0 keeps the event free.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS price_amount bigint NOT NULL DEFAULT 0
+    CONSTRAINT chk_events_price_amount CHECK (price_amount >= 0);
+ALTER TABLE events ADD COLUMN IF NOT EXISTS price_currency varchar(3) NOT NULL DEFAULT '';
+
+-- Orders for paid events. A PENDING order holds one seat until expires_at.
+CREATE TABLE IF NOT EXISTS orders (
+    id               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id          uuid NOT NULL CONSTRAINT fk_orders_user REFERENCES users (id),
+    event_id         uuid NOT NULL CONSTRAINT fk_orders_event REFERENCES events (id),
+    status           varchar(20) NOT NULL,
+    total_amount     bigint NOT NULL DEFAULT 0,
+    total_currency   varchar(3) NOT NULL DEFAULT '',
+    payment_provider varchar(32) NOT NULL,
+    payment_ref      varchar(255),
+    checkout_url     text,
+    registration_id  uuid CONSTRAINT fk_orders_registration REFERENCES registrations (id),
+    failure_reason   text,
+    expires_at       timestamptz NOT NULL,
+    paid_at          timestamptz,
+    created_at       timestamptz,
+    updated_at       timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_event_id ON orders (event_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_payment_ref ON orders (payment_provider, payment_ref) WHERE payment_ref <> '';
+-- One open hold per user and event; also serves the expiry sweep.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_pending_user_event ON orders (event_id, user_id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_orders_pending_expiry ON orders (expires_at) WHERE status = 'PENDING';
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id                  uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id            uuid NOT NULL CONSTRAINT fk_order_items_order REFERENCES orders (id) ON DELETE CASCADE,
+    kind                varchar(20) NOT NULL,
+    description         text NOT NULL,
+    unit_price_amount   bigint NOT NULL DEFAULT 0,
+    unit_price_currency varchar(3) NOT NULL DEFAULT '',
+    quantity            integer NOT NULL,
+    total_amount        bigint NOT NULL DEFAULT 0,
+    total_currency      varchar(3) NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS refunds;
+DROP INDEX IF EXISTS idx_orders_registration_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS cancelled_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_amount;
+ALTER TABLE events DROP COLUMN IF EXISTS refund_no_refund_hours;
+ALTER TABLE events DROP COLUMN IF EXISTS refund_partial_percent;
+ALTER TABLE events DROP COLUMN IF EXISTS refund_full_refund_days;
@@ -0,0 +1,33 @@
+-- Per-event refund policy; the zero policy refunds in full until the event.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS refund_full_refund_days integer NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS refund_partial_percent integer NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS refund_no_refund_hours integer NOT NULL DEFAULT 0;
+
+-- Sum of succeeded refunds per order, and when its booking was cancelled.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_amount bigint NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_currency varchar(3) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS cancelled_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_orders_registration_id ON orders (registration_id) WHERE registration_id IS NOT NULL;
+
+-- Refund ledger. Rows are written PENDING inside the transaction that
+-- decides the refund and settled once the payment provider answers.
+CREATE TABLE IF NOT EXISTS refunds (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id        uuid NOT NULL CONSTRAINT fk_refunds_order REFERENCES orders (id),
+    event_id        uuid NOT NULL CONSTRAINT fk_refunds_event REFERENCES events (id),
+    user_id         uuid NOT NULL CONSTRAINT fk_refunds_user REFERENCES users (id),
+    amount_amount   bigint NOT NULL CONSTRAINT chk_refunds_amount CHECK (amount_amount > 0),
+    amount_currency varchar(3) NOT NULL,
+    reason          varchar(32) NOT NULL,
+    status          varchar(20) NOT NULL,
+    note            text,
+    requested_by    uuid,
+    provider_ref    varchar(255),
+    attempts        integer NOT NULL DEFAULT 0,
+    failure_reason  text,
+    created_at      timestamptz,
+    processed_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds (order_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_event_id ON refunds (event_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_pending ON refunds (created_at) WHERE status = 'PENDING';
@@ -0,0 +1,6 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS promo_code_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS ticket_type_id;
+DROP TABLE IF EXISTS promo_redemptions;
+DROP TABLE IF EXISTS promo_code_ticket_types;
+DROP TABLE IF EXISTS promo_codes;
+DROP TABLE IF EXISTS ticket_types;
@@ -0,0 +1,55 @@
+-- Priced tiers of a paid event; every tier draws on the event's seats.
+CREATE TABLE IF NOT EXISTS ticket_types (
+    id             uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id       uuid NOT NULL CONSTRAINT fk_ticket_types_event REFERENCES events (id),
+    name           text NOT NULL,
+    description    text,
+    price_amount   bigint NOT NULL DEFAULT 0 CONSTRAINT chk_ticket_types_price CHECK (price_amount >= 0),
+    price_currency varchar(3) NOT NULL DEFAULT '',
+    hidden         boolean NOT NULL DEFAULT false,
+    created_at     timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_ticket_types_event_id ON ticket_types (event_id);
+
+CREATE TABLE IF NOT EXISTS promo_codes (
+    id                  uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id            uuid NOT NULL CONSTRAINT fk_promo_codes_event REFERENCES events (id),
+    code                varchar(64) NOT NULL,
+    discount_type       varchar(20) NOT NULL,
+    percent_off         integer NOT NULL DEFAULT 0 CONSTRAINT chk_promo_codes_percent CHECK (percent_off BETWEEN 0 AND 100),
+    amount_off_amount   bigint NOT NULL DEFAULT 0 CONSTRAINT chk_promo_codes_amount CHECK (amount_off_amount >= 0),
+    amount_off_currency varchar(3) NOT NULL DEFAULT '',
+    max_uses            integer NOT NULL DEFAULT 0,
+    max_uses_per_user   integer NOT NULL DEFAULT 0,
+    -- Guards the usage limit even if a writer skips the row lock.
+    used_count          integer NOT NULL DEFAULT 0
+        CONSTRAINT chk_promo_codes_used CHECK (used_count >= 0 AND (max_uses = 0 OR used_count <= max_uses)),
+    valid_from          timestamptz,
+    valid_until         timestamptz,
+    active              boolean NOT NULL DEFAULT true,
+    created_at          timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_codes_event_code ON promo_codes (event_id, code);
+
+CREATE TABLE IF NOT EXISTS promo_code_ticket_types (
+    promo_code_id  uuid NOT NULL CONSTRAINT fk_promo_code_ticket_types_code REFERENCES promo_codes (id) ON DELETE CASCADE,
+    ticket_type_id uuid NOT NULL CONSTRAINT fk_promo_code_ticket_types_type REFERENCES ticket_types (id) ON DELETE CASCADE,
+    PRIMARY KEY (promo_code_id, ticket_type_id)
+);
+
+CREATE TABLE IF NOT EXISTS promo_redemptions (
+    id                uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    promo_code_id     uuid NOT NULL CONSTRAINT fk_promo_redemptions_code REFERENCES promo_codes (id),
+    order_id          uuid NOT NULL CONSTRAINT fk_promo_redemptions_order REFERENCES orders (id),
+    user_id           uuid NOT NULL CONSTRAINT fk_promo_redemptions_user REFERENCES users (id),
+    discount_amount   bigint NOT NULL DEFAULT 0,
+    discount_currency varchar(3) NOT NULL DEFAULT '',
+    created_at        timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_promo_redemptions_order_id ON promo_redemptions (order_id);
+CREATE INDEX IF NOT EXISTS idx_promo_redemptions_code_user ON promo_redemptions (promo_code_id, user_id);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS ticket_type_id uuid
+    CONSTRAINT fk_orders_ticket_type REFERENCES ticket_types (id);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS promo_code_id uuid
+    CONSTRAINT fk_orders_promo_code REFERENCES promo_codes (id);
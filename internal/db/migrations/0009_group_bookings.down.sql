@@ -0,0 +1,14 @@
+-- Group seats cannot survive the full unique index, so they go first.
+DELETE FROM waitlists WHERE group_booking_id IS NOT NULL;
+DELETE FROM registrations WHERE group_booking_id IS NOT NULL;
+
+ALTER TABLE waitlists DROP COLUMN IF EXISTS group_booking_id;
+ALTER TABLE waitlists DROP COLUMN IF EXISTS seats;
+
+DROP INDEX IF EXISTS idx_user_event;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_event ON registrations (user_id, event_id);
+ALTER TABLE registrations DROP COLUMN IF EXISTS attendee_email;
+ALTER TABLE registrations DROP COLUMN IF EXISTS attendee_name;
+ALTER TABLE registrations DROP COLUMN IF EXISTS group_booking_id;
+
+DROP TABLE IF EXISTS group_bookings;
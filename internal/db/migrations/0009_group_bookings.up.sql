@@ -0,0 +1,32 @@
+-- One purchaser booking several seats. Each confirmed seat is a registration
+-- pointing back at its group.
+CREATE TABLE IF NOT EXISTS group_bookings (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_id   uuid NOT NULL CONSTRAINT fk_group_bookings_event REFERENCES events (id),
+    user_id    uuid NOT NULL CONSTRAINT fk_group_bookings_user REFERENCES users (id),
+    seats      integer NOT NULL CONSTRAINT chk_group_bookings_seats CHECK (seats > 0),
+    shortfall  varchar(20) NOT NULL,
+    status     varchar(20) NOT NULL,
+    attendees  jsonb,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_group_bookings_event_id ON group_bookings (event_id);
+CREATE INDEX IF NOT EXISTS idx_group_bookings_user_id ON group_bookings (user_id);
+
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS group_booking_id uuid
+    CONSTRAINT fk_registrations_group_booking REFERENCES group_bookings (id);
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS attendee_name text;
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS attendee_email text;
+CREATE INDEX IF NOT EXISTS idx_registrations_group_booking_id ON registrations (group_booking_id);
+
+-- Only a user's own registration is unique per event; group seats share
+-- the purchaser's user_id.
+DROP INDEX IF EXISTS idx_user_event;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_event ON registrations (user_id, event_id) WHERE group_booking_id IS NULL;
+
+-- A waitlisted group holds one place for all its seats.
+ALTER TABLE waitlists ADD COLUMN IF NOT EXISTS seats integer NOT NULL DEFAULT 1
+    CONSTRAINT chk_waitlists_seats CHECK (seats > 0);
+ALTER TABLE waitlists ADD COLUMN IF NOT EXISTS group_booking_id uuid
+    CONSTRAINT fk_waitlists_group_booking REFERENCES group_bookings (id);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS ticket_transfers;
+
+ALTER TABLE events DROP COLUMN IF EXISTS transfer_cutoff;
+ALTER TABLE events DROP COLUMN IF EXISTS transfer_disabled;
+
+-- Fails if a user received a transfer while holding a cancelled
+-- registration for the same event; those rows must be merged by hand.
+DROP INDEX IF EXISTS idx_user_event;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_event ON registrations (user_id, event_id) WHERE group_booking_id IS NULL;
+
+DROP INDEX IF EXISTS idx_registrations_ticket_token;
+ALTER TABLE registrations DROP COLUMN IF EXISTS ticket_token;
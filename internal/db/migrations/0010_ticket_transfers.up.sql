@@ -0,0 +1,32 @@
+-- Ticket tokens identify a seat at the door and change on transfer. The
+-- volatile default gives every existing registration its own token.
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS ticket_token varchar(32) NOT NULL
+    DEFAULT replace(gen_random_uuid()::text, '-', '');
+CREATE UNIQUE INDEX IF NOT EXISTS idx_registrations_ticket_token ON registrations (ticket_token);
+
+-- A transferred seat keeps its row, so a recipient who cancelled earlier may
+-- own several cancelled rows; only confirmed ones must be unique.
+DROP INDEX IF EXISTS idx_user_event;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_event ON registrations (user_id, event_id)
+    WHERE group_booking_id IS NULL AND status = 'CONFIRMED';
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS transfer_disabled boolean NOT NULL DEFAULT false;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS transfer_cutoff timestamptz;
+
+CREATE TABLE IF NOT EXISTS ticket_transfers (
+    id              uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    registration_id uuid NOT NULL CONSTRAINT fk_ticket_transfers_registration REFERENCES registrations (id),
+    event_id        uuid NOT NULL CONSTRAINT fk_ticket_transfers_event REFERENCES events (id),
+    from_user_id    uuid NOT NULL CONSTRAINT fk_ticket_transfers_from_user REFERENCES users (id),
+    to_email        text NOT NULL,
+    to_user_id      uuid CONSTRAINT fk_ticket_transfers_to_user REFERENCES users (id),
+    status          varchar(20) NOT NULL,
+    created_at      timestamptz,
+    responded_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_registration_id ON ticket_transfers (registration_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_from_user_id ON ticket_transfers (from_user_id);
+CREATE INDEX IF NOT EXISTS idx_ticket_transfers_to_email ON ticket_transfers (to_email);
+-- At most one open offer per ticket.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_transfers_pending ON ticket_transfers (registration_id)
+    WHERE status = 'PENDING';
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS event_seats;
+ALTER TABLE events DROP COLUMN IF EXISTS seat_map_id;
+DROP TABLE IF EXISTS seats;
+DROP TABLE IF EXISTS seat_rows;
+DROP TABLE IF EXISTS seat_sections;
+DROP TABLE IF EXISTS seat_maps;
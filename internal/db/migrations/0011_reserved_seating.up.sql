@@ -0,0 +1,50 @@
+-- Venue seat maps: sections of rows of numbered seats.
+CREATE TABLE IF NOT EXISTS seat_maps (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    organizer_id uuid NOT NULL CONSTRAINT fk_seat_maps_organizer REFERENCES users (id),
+    name         text NOT NULL,
+    created_at   timestamptz,
+    updated_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_seat_maps_organizer_id ON seat_maps (organizer_id);
+
+CREATE TABLE IF NOT EXISTS seat_sections (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    seat_map_id uuid NOT NULL CONSTRAINT fk_seat_sections_seat_map REFERENCES seat_maps (id),
+    name        text NOT NULL,
+    position    integer NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_seat_sections_seat_map_id ON seat_sections (seat_map_id);
+
+CREATE TABLE IF NOT EXISTS seat_rows (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    section_id uuid NOT NULL CONSTRAINT fk_seat_rows_section REFERENCES seat_sections (id),
+    label      text NOT NULL,
+    position   integer NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_seat_rows_section_id ON seat_rows (section_id);
+
+CREATE TABLE IF NOT EXISTS seats (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    row_id     uuid NOT NULL CONSTRAINT fk_seats_row REFERENCES seat_rows (id),
+    number     integer NOT NULL,
+    wheelchair boolean NOT NULL DEFAULT false,
+    companion  boolean NOT NULL DEFAULT false
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seats_row_number ON seats (row_id, number);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS seat_map_id uuid
+    CONSTRAINT fk_events_seat_map REFERENCES seat_maps (id);
+
+-- Per-event inventory. Bookings lock these rows instead of the event row.
+CREATE TABLE IF NOT EXISTS event_seats (
+    event_id        uuid NOT NULL CONSTRAINT fk_event_seats_event REFERENCES events (id),
+    seat_id         uuid NOT NULL CONSTRAINT fk_event_seats_seat REFERENCES seats (id),
+    status          varchar(20) NOT NULL DEFAULT 'AVAILABLE',
+    registration_id uuid CONSTRAINT fk_event_seats_registration REFERENCES registrations (id),
+    updated_at      timestamptz,
+    PRIMARY KEY (event_id, seat_id),
+    -- A booked seat belongs to exactly one registration, a free one to none.
+    CONSTRAINT chk_event_seats_registration CHECK ((status = 'BOOKED') = (registration_id IS NOT NULL))
+);
+CREATE INDEX IF NOT EXISTS idx_event_seats_registration_id ON event_seats (registration_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_events_venue_date;
+ALTER TABLE events DROP COLUMN IF EXISTS venue_id;
+DROP TABLE IF EXISTS venues;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS venues (
+    id                               uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    organizer_id                     uuid NOT NULL CONSTRAINT fk_venues_organizer REFERENCES users (id),
+    name                             text NOT NULL,
+    address                          text NOT NULL,
+    city                             text,
+    country                          text,
+    latitude                         double precision,
+    longitude                        double precision,
+    timezone                         text NOT NULL,
+    max_capacity                     integer NOT NULL CONSTRAINT chk_venues_max_capacity CHECK (max_capacity > 0),
+    accessibility_step_free          boolean NOT NULL DEFAULT false,
+    accessibility_accessible_toilets boolean NOT NULL DEFAULT false,
+    accessibility_hearing_loop       boolean NOT NULL DEFAULT false,
+    accessibility_notes              text,
+    created_at                       timestamptz,
+    updated_at                       timestamptz,
+    CONSTRAINT chk_venues_coordinates CHECK ((latitude IS NULL) = (longitude IS NULL))
+);
+CREATE INDEX IF NOT EXISTS idx_venues_organizer_id ON venues (organizer_id);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS venue_id uuid
+    CONSTRAINT fk_events_venue REFERENCES venues (id);
+-- Serves the double-booking check: events at a venue around a start time.
+CREATE INDEX IF NOT EXISTS idx_events_venue_date ON events (venue_id, event_date)
+    WHERE venue_id IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Serves the bounding box of proximity search.
+CREATE INDEX IF NOT EXISTS idx_venues_location ON venues (latitude, longitude)
+    WHERE latitude IS NOT NULL;
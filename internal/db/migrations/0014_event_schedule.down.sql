@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_events_venue_schedule;
+CREATE INDEX IF NOT EXISTS idx_events_venue_date ON events (venue_id, event_date)
+    WHERE venue_id IS NOT NULL;
+ALTER TABLE events DROP COLUMN IF EXISTS timezone;
+ALTER TABLE events DROP COLUMN IF EXISTS ends_at;
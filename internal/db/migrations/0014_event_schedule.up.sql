@@ -0,0 +1,14 @@
+-- Events ran for an assumed four hours until they recorded their end.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS ends_at timestamptz
+    CONSTRAINT chk_events_ends_after_start CHECK (ends_at > event_date);
+UPDATE events SET ends_at = event_date + interval '4 hours' WHERE ends_at IS NULL;
+ALTER TABLE events ALTER COLUMN ends_at SET NOT NULL;
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS timezone text NOT NULL DEFAULT 'UTC';
+UPDATE events SET timezone = venues.timezone
+FROM venues WHERE venues.id = events.venue_id AND events.timezone = 'UTC';
+
+-- The venue double-booking check now compares whole time ranges.
+DROP INDEX IF EXISTS idx_events_venue_date;
+CREATE INDEX IF NOT EXISTS idx_events_venue_schedule ON events (venue_id, event_date, ends_at)
+    WHERE venue_id IS NOT NULL;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS event_series (
+    id                   uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    organizer_id         uuid NOT NULL CONSTRAINT fk_event_series_organizer REFERENCES users (id),
+    title                text NOT NULL,
+    description          text,
+    location             text,
+    capacity             integer NOT NULL CONSTRAINT chk_event_series_capacity CHECK (capacity > 0),
+    venue_id             uuid CONSTRAINT fk_event_series_venue REFERENCES venues (id),
+    timezone             text NOT NULL,
+    starts_at            timestamptz NOT NULL,
+    ends_at              timestamptz NOT NULL,
+    recurrence_frequency varchar(10) NOT NULL,
+    recurrence_interval  integer NOT NULL DEFAULT 1 CONSTRAINT chk_event_series_interval CHECK (recurrence_interval > 0),
+    recurrence_by_day    jsonb,
+    recurrence_until     timestamptz,
+    recurrence_count     integer,
+    created_at           timestamptz,
+    updated_at           timestamptz,
+    CONSTRAINT chk_event_series_ends_after_start CHECK (ends_at > starts_at)
+);
+CREATE INDEX IF NOT EXISTS idx_event_series_organizer_id ON event_series (organizer_id);
+
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series_id uuid
+    CONSTRAINT fk_events_series REFERENCES event_series (id);
+CREATE INDEX IF NOT EXISTS idx_events_series_id ON events (series_id);
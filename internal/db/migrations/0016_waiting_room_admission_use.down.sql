@@ -0,0 +1 @@
+ALTER TABLE waiting_room_entries DROP COLUMN IF EXISTS used_at;
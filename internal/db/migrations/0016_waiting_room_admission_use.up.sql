@@ -0,0 +1 @@
+ALTER TABLE waiting_room_entries ADD COLUMN IF NOT EXISTS used_at timestamptz;
@@ -1,7 +1,7 @@
 package db
 
 import (
-	"log"
+	"log/slog"
 	"time"
 
 	"event_registration/internal/models"
@@ -13,11 +13,11 @@ func SeedDatabase(db *gorm.DB) {
 	var count int64
 	db.Model(&models.User{}).Count(&count)
 	if count > 0 {
-		log.Println("Database already contains data. Skipping seed.")
+		slog.Info("Database already contains data. Skipping seed.")
 		return
 	}
 
-	log.Println("Seeding Database with sample Users and Events...")
+	slog.Info("Seeding Database with sample Users and Events...")
 
 	// Create Users
 	adminPwd, _ := utils.HashPassword("admin123")
@@ -40,6 +40,7 @@ func SeedDatabase(db *gorm.DB) {
 		Description:    "Advanced patterns for building highly scalable systems using Go and Docker.",
 		Location:       "Convention Center A",
 		EventDate:      time.Now().Add(24 * time.Hour),
+		EndsAt:         time.Now().Add((24 + 2) * time.Hour),
 		Capacity:       10, // Very small capacity to test concurrency easily
 		SeatsRemaining: 10,
 		OrganizerID:    org1.ID,
@@ -51,6 +52,7 @@ func SeedDatabase(db *gorm.DB) {
 		Description:    "Learn how SELECT FOR UPDATE prevents race conditions inside explicit database transactions.",
 		Location:       "Online Webinar",
 		EventDate:      time.Now().Add(72 * time.Hour),
+		EndsAt:         time.Now().Add((72 + 2) * time.Hour),
 		Capacity:       5, // Small capacity
 		SeatsRemaining: 5,
 		OrganizerID:    org1.ID,
@@ -62,6 +64,7 @@ func SeedDatabase(db *gorm.DB) {
 		Description:    "This event is still being planned.",
 		Location:       "TBD",
 		EventDate:      time.Now().Add(100 * time.Hour),
+		EndsAt:         time.Now().Add((100 + 2) * time.Hour),
 		Capacity:       100,
 		SeatsRemaining: 100,
 		OrganizerID:    org1.ID,
@@ -72,5 +75,5 @@ func SeedDatabase(db *gorm.DB) {
 	db.Create(&e2)
 	db.Create(&e3)
 
-	log.Println("Database Seeding Completed Successfully!")
+	slog.Info("Database Seeding Completed Successfully!")
 }
@@ -1,111 +1,217 @@
 package handlers
 
 import (
-	"context"
-	"fmt"
+	"errors"
 	"net/http"
 	"strconv"
-	"sync"
-	"sync/atomic"
 
 	"event_registration/internal/models"
+	"event_registration/internal/repositories"
 	"event_registration/internal/services"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"gorm.io/gorm"
 )
 
 type AdminHandler struct {
-	db           *gorm.DB
-	regService   services.RegistrationService
 	eventService services.EventService
+	simService   services.SimulationService
+	statsService services.StatsService
+	reconciler   services.ReconciliationService
+	refunds      services.RefundService
 }
 
-func NewAdminHandler(db *gorm.DB, regService services.RegistrationService, eventService services.EventService) *AdminHandler {
+func NewAdminHandler(eventService services.EventService, simService services.SimulationService, statsService services.StatsService, reconciler services.ReconciliationService, refunds services.RefundService) *AdminHandler {
 	return &AdminHandler{
-		db:           db,
-		regService:   regService,
 		eventService: eventService,
+		simService:   simService,
+		statsService: statsService,
+		reconciler:   reconciler,
+		refunds:      refunds,
 	}
 }
 
-// SimulateConcurrency hits the BookEvent method across N goroutines
-func (h *AdminHandler) SimulateConcurrency(c *gin.Context) {
-	eventID := c.Param("id")
-	usersParam := c.Query("users")
+// GetPlatformStats returns platform-wide dashboard metrics. ?days=N sets the
+// booking trend window (default 30).
+func (h *AdminHandler) GetPlatformStats(c *gin.Context) {
+	days := 0
+	if daysParam := c.Query("days"); daysParam != "" {
+		var err error
+		if days, err = strconv.Atoi(daysParam); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parameter: days"})
+			return
+		}
+	}
+
+	stats, err := h.statsService.GetPlatformStats(c.Request.Context(), days)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+// GetReconciliation reports seat-count drift and orphaned waitlists without
+// changing anything.
+func (h *AdminHandler) GetReconciliation(c *gin.Context) {
+	report, err := h.reconciler.Check(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"consistent": report.Consistent(), "report": report})
+}
+
+// RepairReconciliation fixes every inconsistency under the event row lock.
+func (h *AdminHandler) RepairReconciliation(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	adminID := userIDVal.(string)
+
+	report, err := h.reconciler.Repair(c.Request.Context(), adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reconciliation complete", "report": report})
+}
+
+type allocationStrategyRequest struct {
+	Strategy models.AllocationStrategy `json:"strategy"`
+}
 
-	numUsers, err := strconv.Atoi(usersParam)
-	if err != nil || numUsers <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parameter: users"})
+// SetAllocationStrategy overrides how seats are allocated for one event, e.g.
+// to compare strategies under the simulator. An empty strategy reverts to the
+// deployment default.
+func (h *AdminHandler) SetAllocationStrategy(c *gin.Context) {
+	var req allocationStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 1. Check if event is valid
-	ctx := context.Background()
-	event, err := h.eventService.GetEvent(ctx, eventID)
+	event, err := h.eventService.SetAllocationStrategy(c.Request.Context(), c.Param("id"), req.Strategy)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 2. We must create N dummy users to satisfy foreign keys for the simulation.
-	// Normally we wouldn't do this in a production handler, but it's part of the assignment.
-	var userIDs []string
-	
-	// Create a unique simulation batch string to prevent email collisions across runs
-	simBatch := uuid.New().String()[:8]
-	
-	for i := 0; i < numUsers; i++ {
-		user := &models.User{
-			Name:         fmt.Sprintf("Sim User %d", i),
-			Email:        fmt.Sprintf("sim%d_%s_%s@sim.local", i, simBatch, event.ID.String()[:4]), // totally unique
-			PasswordHash: "not_needed",
-			Role:         models.RoleAudience,
+	c.JSON(http.StatusOK, gin.H{"message": "Allocation strategy updated", "event": event})
+}
+
+// SimulateConcurrency starts a simulation job against the event. The JSON
+// body is a scenario; ?users=N alone runs the classic one-booking-per-user
+// race. Poll GetSimulation for the report.
+func (h *AdminHandler) SimulateConcurrency(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	adminID := userIDVal.(string)
+
+	var scenario models.SimulationScenario
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&scenario); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
 		}
-		if err := h.db.WithContext(ctx).Create(user).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create dummy users: " + err.Error()})
+	}
+	if usersParam := c.Query("users"); usersParam != "" {
+		users, err := strconv.Atoi(usersParam)
+		if err != nil || users <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parameter: users"})
 			return
 		}
-		userIDs = append(userIDs, user.ID.String())
-	}
-
-	// 3. Spawning Goroutines
-	var successCount int64
-	var waitlistCount int64
-	var failCount int64
-
-	var wg sync.WaitGroup
-	wg.Add(numUsers)
-
-	for _, uID := range userIDs {
-		go func(userID string) {
-			defer wg.Done()
-			
-			// Each goroutine represents a concurrent request
-			_, waitlist, err := h.regService.BookEvent(context.Background(), userID, eventID)
-			
-			if err != nil {
-				atomic.AddInt64(&failCount, 1)
-			} else if waitlist != nil {
-				atomic.AddInt64(&waitlistCount, 1)
-			} else {
-				atomic.AddInt64(&successCount, 1)
-			}
-		}(uID)
-	}
-
-	wg.Wait()
-
-	// 4. Fetch final remaining seats
-	finalEvent, _ := h.eventService.GetEvent(ctx, eventID)
-
-	c.JSON(http.StatusOK, gin.H{
-		"simulation_results": gin.H{
-			"total_attempted": numUsers,
-			"success_count":   successCount,
-			"waitlisted_count": waitlistCount,
-			"failed_count":    failCount,
-			"final_seats_remaining": finalEvent.SeatsRemaining,
-		},
-	})
+		scenario.Users = users
+	}
+
+	job, err := h.simService.Start(c.Request.Context(), c.Param("id"), adminID, scenario)
+	if err != nil {
+		if errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "event not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Simulation started", "simulation": job})
+}
+
+func (h *AdminHandler) GetSimulation(c *gin.Context) {
+	job, err := h.simService.GetJob(c.Request.Context(), c.Param("job_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"simulation": job})
+}
+
+// ListRefunds returns the refund ledger, newest first. Filter with ?order_id=,
+// ?event_id= and ?status=.
+func (h *AdminHandler) ListRefunds(c *gin.Context) {
+	filter := repositories.RefundFilter{
+		OrderID: c.Query("order_id"),
+		EventID: c.Query("event_id"),
+		Status:  models.RefundStatus(c.Query("status")),
+	}
+
+	refunds, err := h.refunds.ListRefunds(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
+}
+
+type refundOverrideRequest struct {
+	// Amount is in minor units of the order's currency.
+	Amount int64  `json:"amount" binding:"required"`
+	Note   string `json:"note" binding:"required"`
+}
+
+// OverrideRefund refunds part or all of a paid order outside the event's
+// refund policy.
+func (h *AdminHandler) OverrideRefund(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	adminID := userIDVal.(string)
+
+	var req refundOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refund, err := h.refunds.Override(c.Request.Context(), adminID, c.Param("order_id"), req.Amount, req.Note)
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Refund requested", "refund": refund})
+}
+
+// RetryRefund sends a refund that ran out of attempts to the provider again.
+func (h *AdminHandler) RetryRefund(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	adminID := userIDVal.(string)
+
+	refund, err := h.refunds.Retry(c.Request.Context(), adminID, c.Param("refund_id"))
+	if err != nil {
+		refundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Refund retried", "refund": refund})
+}
+
+func refundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound), errors.Is(err, services.ErrRefundNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotRefundable), errors.Is(err, services.ErrRefundExceedsPaid),
+		errors.Is(err, services.ErrRefundNotRetryable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
 }
@@ -1,26 +1,83 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	"event_registration/internal/middleware"
+	"event_registration/internal/models"
 	"event_registration/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
+// bookingStreamInterval is how often a booking request stream re-reads the
+// request while it is pending.
+const bookingStreamInterval = time.Second
+
 type EventHandler struct {
 	eventService services.EventService
 	regService   services.RegistrationService
+	queueService services.BookingQueueService
+	waitingRoom  services.WaitingRoomService
+	groups       services.GroupBookingService
+	seating      services.SeatingService
+
+	// closed on shutdown so open booking streams end instead of holding
+	// the drain until its timeout.
+	streamsDone      chan struct{}
+	closeStreamsOnce sync.Once
 }
 
-func NewEventHandler(eventService services.EventService, regService services.RegistrationService) *EventHandler {
+func NewEventHandler(eventService services.EventService, regService services.RegistrationService, queueService services.BookingQueueService, waitingRoom services.WaitingRoomService, groups services.GroupBookingService, seating services.SeatingService) *EventHandler {
 	return &EventHandler{
 		eventService: eventService,
 		regService:   regService,
+		queueService: queueService,
+		waitingRoom:  waitingRoom,
+		groups:       groups,
+		seating:      seating,
+		streamsDone:  make(chan struct{}),
 	}
 }
 
+// CloseStreams ends every open booking request stream. Clients reconnect
+// to another instance.
+func (h *EventHandler) CloseStreams() {
+	h.closeStreamsOnce.Do(func() { close(h.streamsDone) })
+}
+
+// ListEvents lists published events. Given lat and lng, and optionally a
+// radius in km, it lists only events near that point, nearest first. when
+// is "today" or "this_weekend", in each event's local time.
 func (h *EventHandler) ListEvents(c *gin.Context) {
-	events, err := h.eventService.ListPublishedEvents(c.Request.Context())
+	query := services.EventQuery{Period: models.EventPeriod(c.Query("when"))}
+	var err error
+	if query.Latitude, err = parseCoordinate(c.Query("lat")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parameter: lat"})
+		return
+	}
+	if query.Longitude, err = parseCoordinate(c.Query("lng")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parameter: lng"})
+		return
+	}
+	if radius := c.Query("radius"); radius != "" {
+		if query.RadiusKm, err = strconv.ParseFloat(radius, 64); err != nil || !(query.RadiusKm > 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parameter: radius"})
+			return
+		}
+	}
+
+	events, err := h.eventService.ListPublishedEvents(c.Request.Context(), query)
+	if errors.Is(err, services.ErrInvalidEventQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 		return
@@ -29,6 +86,34 @@ func (h *EventHandler) ListEvents(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"events": events})
 }
 
+// requireAdmission checks the caller's waiting room admission for the event
+// and writes the error response when there is none. Every route that books
+// seats calls it before booking.
+func requireAdmission(c *gin.Context, waitingRoom services.WaitingRoomService, userID, eventID string) bool {
+	token := c.GetHeader(middleware.AdmissionTokenHeader)
+	err := waitingRoom.CheckAdmission(c.Request.Context(), userID, eventID, token)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrAdmissionRequired), errors.Is(err, services.ErrAdmissionInvalid):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check admission"})
+	}
+	return false
+}
+
+func parseCoordinate(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, errors.New("invalid coordinate")
+	}
+	return &f, nil
+}
+
 func (h *EventHandler) GetEvent(c *gin.Context) {
 	id := c.Param("id")
 	event, err := h.eventService.GetEvent(c.Request.Context(), id)
@@ -45,9 +130,31 @@ func (h *EventHandler) RegisterForEvent(c *gin.Context) {
 	userIDVal, _ := c.Get("userID")
 	userID := userIDVal.(string)
 
+	if !requireAdmission(c, h.waitingRoom, userID, eventID) {
+		return
+	}
+
+	queued, err := h.queueService.IsQueued(c.Request.Context(), eventID)
+	if err != nil && !errors.Is(err, services.ErrEventNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load event"})
+		return
+	}
+	if queued {
+		h.enqueueBooking(c, userID, eventID)
+		return
+	}
+
 	reg, waitlist, err := h.regService.BookEvent(c.Request.Context(), userID, eventID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.WarnContext(c.Request.Context(), "Booking failed", "event_id", eventID, "user_id", userID, "error", err)
+		switch {
+		case errors.Is(err, services.ErrPaymentRequired):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAdmissionUsed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -65,6 +172,152 @@ func (h *EventHandler) RegisterForEvent(c *gin.Context) {
 	})
 }
 
+// BookGroup reserves several seats for the caller in one go, optionally
+// naming who sits in each. on_shortfall picks what happens when fewer seats
+// remain: REJECT (default), PARTIAL or WAITLIST.
+func (h *EventHandler) BookGroup(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	var req services.GroupBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !requireAdmission(c, h.waitingRoom, userID, eventID) {
+		return
+	}
+
+	group, err := h.groups.BookGroup(c.Request.Context(), userID, eventID, req)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Group booking failed", "event_id", eventID, "user_id", userID, "seats", req.Seats, "error", err)
+		switch {
+		case errors.Is(err, services.ErrEventNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAdmissionUsed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPaymentRequired):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrNotEnoughSeats), errors.Is(err, services.ErrAlreadyWaitlisted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	switch group.Status {
+	case models.GroupBookingWaitlisted:
+		c.JSON(http.StatusOK, gin.H{"message": "Not enough seats left. The group is on the waitlist.", "group_booking": group})
+	case models.GroupBookingPartial:
+		c.JSON(http.StatusCreated, gin.H{"message": "Booked the seats that were left.", "group_booking": group})
+	default:
+		c.JSON(http.StatusCreated, gin.H{"message": "Group booked", "group_booking": group})
+	}
+}
+
+func (h *EventHandler) ListMyGroupBookings(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	groups, err := h.groups.ListUserGroupBookings(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group bookings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_bookings": groups})
+}
+
+func (h *EventHandler) GetGroupBooking(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	group, err := h.groups.GetGroupBooking(c.Request.Context(), userID, c.Param("group_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"group_booking": group})
+}
+
+func (h *EventHandler) CancelGroupBooking(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	group, err := h.groups.CancelGroupBooking(c.Request.Context(), userID, c.Param("group_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrGroupBookingNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrGroupCancelled):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Group booking cancelled", "group_booking": group})
+}
+
+// GetSeats lists every seat of a reserved-seating event with its status.
+func (h *EventHandler) GetSeats(c *gin.Context) {
+	availability, err := h.seating.GetSeatAvailability(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEventNotFound), errors.Is(err, services.ErrNotReservedSeating):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seats"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// BookSeats books the seats named in seat_ids, or lets best-available pick
+// quantity adjacent seats, optionally within a section.
+func (h *EventHandler) BookSeats(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	var req services.SeatBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !requireAdmission(c, h.waitingRoom, userID, eventID) {
+		return
+	}
+
+	booking, err := h.seating.BookSeats(c.Request.Context(), userID, eventID, req)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Seat booking failed", "event_id", eventID, "user_id", userID, "error", err)
+		switch {
+		case errors.Is(err, services.ErrEventNotFound), errors.Is(err, services.ErrSeatNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAdmissionUsed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrSeatTaken), errors.Is(err, services.ErrNoAdjacentSeats),
+			errors.Is(err, services.ErrSeatContention), errors.Is(err, services.ErrAlreadyRegistered),
+			errors.Is(err, services.ErrAlreadyWaitlisted):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Seats booked", "booking": booking})
+}
+
 func (h *EventHandler) CancelRegistration(c *gin.Context) {
 	regID := c.Param("registration_id")
 	userIDVal, _ := c.Get("userID")
@@ -72,9 +325,130 @@ func (h *EventHandler) CancelRegistration(c *gin.Context) {
 
 	err := h.regService.CancelRegistration(c.Request.Context(), userID, regID)
 	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Cancellation failed", "registration_id", regID, "user_id", userID, "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Registration cancelled successfully"})
 }
+
+// JoinWaitingRoom enters the user into the event's waiting room, or returns
+// their current place if they are already in it.
+func (h *EventHandler) JoinWaitingRoom(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	status, err := h.waitingRoom.Join(c.Request.Context(), userID, eventID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"waiting_room": status})
+}
+
+// GetWaitingRoomStatus reports the user's position and ETA, or their
+// admission token once admitted.
+func (h *EventHandler) GetWaitingRoomStatus(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	status, err := h.waitingRoom.Status(c.Request.Context(), userID, eventID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoWaitingRoom) || errors.Is(err, services.ErrNotInWaitingRoom) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load waiting room status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"waiting_room": status})
+}
+
+// enqueueBooking answers a booking for a QUEUE event with its ticket. The
+// outcome is fetched later from the booking request endpoints.
+func (h *EventHandler) enqueueBooking(c *gin.Context, userID, eventID string) {
+	req, err := h.queueService.Enqueue(c.Request.Context(), userID, eventID)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Booking request rejected", "event_id", eventID, "user_id", userID, "error", err)
+		if errors.Is(err, services.ErrAdmissionUsed) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":         "Booking request queued",
+		"booking_request": req,
+	})
+}
+
+func (h *EventHandler) GetBookingRequest(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	req, err := h.queueService.GetRequest(c.Request.Context(), userID, c.Param("request_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking request not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"booking_request": req})
+}
+
+// StreamBookingRequest sends the request as server-sent events: a "position"
+// event whenever the queue position changes, then one "result" event once it
+// is resolved.
+func (h *EventHandler) StreamBookingRequest(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+	requestID := c.Param("request_id")
+	ctx := c.Request.Context()
+
+	req, err := h.queueService.GetRequest(ctx, userID, requestID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking request not found"})
+		return
+	}
+
+	// The stream outlives the server's write timeout, so push the deadline
+	// forward before every write.
+	rc := http.NewResponseController(c.Writer)
+	ticker := time.NewTicker(bookingStreamInterval)
+	defer ticker.Stop()
+
+	lastPosition := int64(-1)
+	first := true
+	c.Stream(func(w io.Writer) bool {
+		if !first {
+			select {
+			case <-ctx.Done():
+				return false
+			case <-h.streamsDone:
+				return false
+			case <-ticker.C:
+			}
+			if req, err = h.queueService.GetRequest(ctx, userID, requestID); err != nil {
+				return false
+			}
+		}
+		first = false
+
+		_ = rc.SetWriteDeadline(time.Now().Add(2 * bookingStreamInterval))
+		if req.Resolved() {
+			c.SSEvent("result", req)
+			return false
+		}
+		if req.Position != lastPosition {
+			c.SSEvent("position", req)
+			lastPosition = req.Position
+		}
+		return true
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const readinessCheckTimeout = 2 * time.Second
+
+// MigrationChecker reports how many schema migrations are still pending.
+type MigrationChecker interface {
+	Pending(ctx context.Context) (int, error)
+}
+
+type HealthHandler struct {
+	db       *gorm.DB
+	migrator MigrationChecker
+	draining atomic.Bool
+}
+
+func NewHealthHandler(db *gorm.DB, migrator MigrationChecker) *HealthHandler {
+	return &HealthHandler{
+		db:       db,
+		migrator: migrator,
+	}
+}
+
+// SetDraining makes readiness fail so the orchestrator stops routing new
+// traffic while in-flight requests finish.
+func (h *HealthHandler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Liveness only proves the process is serving HTTP; it never touches
+// dependencies so a database outage doesn't trigger restarts.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	checks := gin.H{}
+	ready := true
+
+	if h.draining.Load() {
+		checks["server"] = "shutting down"
+		ready = false
+	}
+
+	if sqlDB, err := h.db.DB(); err != nil {
+		checks["database"] = err.Error()
+		ready = false
+	} else if err := sqlDB.PingContext(ctx); err != nil {
+		checks["database"] = err.Error()
+		ready = false
+	} else {
+		checks["database"] = "ok"
+	}
+
+	if pending, err := h.migrator.Pending(ctx); err != nil {
+		checks["migrations"] = err.Error()
+		ready = false
+	} else if pending > 0 {
+		checks["migrations"] = gin.H{"pending": pending}
+		ready = false
+	} else {
+		checks["migrations"] = "ok"
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
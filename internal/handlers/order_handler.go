@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"event_registration/internal/payments"
+	"event_registration/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentSignatureHeader carries the provider's signature on webhooks.
+const PaymentSignatureHeader = "X-Payment-Signature"
+
+// maxWebhookBody bounds how much of a webhook body is read.
+const maxWebhookBody = 64 << 10
+
+type OrderHandler struct {
+	orderService services.OrderService
+	promoService services.PromoService
+	waitingRoom  services.WaitingRoomService
+	// fake is set only in development with the fake provider, to back the
+	// stand-in checkout page.
+	fake *payments.FakeProvider
+}
+
+func NewOrderHandler(orderService services.OrderService, promoService services.PromoService, waitingRoom services.WaitingRoomService, fake *payments.FakeProvider) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+		promoService: promoService,
+		waitingRoom:  waitingRoom,
+		fake:         fake,
+	}
+}
+
+// FakeCheckoutEnabled reports whether the development checkout route exists.
+func (h *OrderHandler) FakeCheckoutEnabled() bool {
+	return h.fake != nil
+}
+
+// PlaceOrder holds a seat for a paid event. The response carries the
+// checkout URL; the seat is confirmed once the payment webhook arrives. The
+// optional body picks a ticket type and promo code.
+func (h *OrderHandler) PlaceOrder(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	var req services.OrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if !requireAdmission(c, h.waitingRoom, userID, eventID) {
+		return
+	}
+
+	order, err := h.orderService.PlaceOrder(c.Request.Context(), userID, eventID, req)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Order rejected", "event_id", eventID, "user_id", userID, "error", err)
+		switch {
+		case errors.Is(err, services.ErrEventNotFound), errors.Is(err, services.ErrTicketTypeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrAdmissionUsed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrSoldOut), errors.Is(err, services.ErrOrderPending),
+			errors.Is(err, services.ErrAlreadyRegistered), errors.Is(err, services.ErrAlreadyWaitlisted),
+			errors.Is(err, services.ErrPromoExhausted), errors.Is(err, services.ErrPromoUserLimit):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrPaymentFailure):
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	message := "Seat held. Complete payment to confirm your registration."
+	if order.RegistrationID != nil {
+		message = "Registration confirmed; nothing to pay."
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": message,
+		"order":   order,
+	})
+}
+
+// ListTicketTypes returns the tiers on sale for an event. An access code in
+// the code query parameter also reveals the hidden tiers it unlocks.
+func (h *OrderHandler) ListTicketTypes(c *gin.Context) {
+	ticketTypes, err := h.promoService.ListTicketTypes(c.Request.Context(), c.Param("id"), c.Query("code"))
+	if err != nil {
+		if errors.Is(err, services.ErrPromoInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ticket types"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket_types": ticketTypes})
+}
+
+func (h *OrderHandler) ListMyOrders(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	orders, err := h.orderService.ListUserOrders(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": orders})
+}
+
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	order, err := h.orderService.GetOrder(c.Request.Context(), userID, c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"order": order})
+}
+
+// PaymentWebhook receives payment outcomes from the provider. It is not
+// behind login; the provider's signature authenticates it.
+func (h *OrderHandler) PaymentWebhook(c *gin.Context) {
+	payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read webhook body"})
+		return
+	}
+	h.applyWebhook(c, payload, c.GetHeader(PaymentSignatureHeader))
+}
+
+// FakeCheckout stands in for the provider's checkout page in development:
+// it sends the webhook the fake provider would send for the outcome chosen.
+func (h *OrderHandler) FakeCheckout(c *gin.Context) {
+	var req struct {
+		Succeed bool `json:"succeed"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	payload, signature := h.fake.Complete(c.Param("ref"), req.Succeed)
+	h.applyWebhook(c, payload, signature)
+}
+
+func (h *OrderHandler) applyWebhook(c *gin.Context, payload []byte, signature string) {
+	order, err := h.orderService.HandleWebhook(c.Request.Context(), payload, signature)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Payment webhook rejected", "error", err)
+		switch {
+		case errors.Is(err, payments.ErrInvalidWebhook):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOrderNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply payment"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"order": order})
+}
@@ -1,22 +1,36 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"time"
 
 	"event_registration/internal/models"
 	"event_registration/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
+// Upper bound on the size of an uploaded attendee CSV.
+const maxImportFileSize = 5 << 20
+
 type OrganizerHandler struct {
-	eventService services.EventService
-	regService   services.RegistrationService
+	eventService  services.EventService
+	regService    services.RegistrationService
+	importService services.ImportService
+	waitingRoom   services.WaitingRoomService
+	promoService  services.PromoService
+	seating       services.SeatingService
 }
 
-func NewOrganizerHandler(eventService services.EventService, regService services.RegistrationService) *OrganizerHandler {
+func NewOrganizerHandler(eventService services.EventService, regService services.RegistrationService, importService services.ImportService, waitingRoom services.WaitingRoomService, promoService services.PromoService, seating services.SeatingService) *OrganizerHandler {
 	return &OrganizerHandler{
-		eventService: eventService,
-		regService:   regService,
+		eventService:  eventService,
+		regService:    regService,
+		importService: importService,
+		waitingRoom:   waitingRoom,
+		promoService:  promoService,
+		seating:       seating,
 	}
 }
 
@@ -31,7 +45,16 @@ func (h *OrganizerHandler) CreateEvent(c *gin.Context) {
 	}
 
 	if err := h.eventService.CreateEvent(c.Request.Context(), organizerID, &event); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch {
+		case errors.Is(err, services.ErrVenueNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrVenueDoubleBooked):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrOverVenueCapacity), errors.Is(err, services.ErrVenueLocation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -41,6 +64,35 @@ func (h *OrganizerHandler) CreateEvent(c *gin.Context) {
 	})
 }
 
+// UpdateEvent edits one event, including a single session of a series.
+// Fields left out of the body are not changed.
+func (h *OrganizerHandler) UpdateEvent(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var update services.EventUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.eventService.UpdateEvent(c.Request.Context(), organizerID, eventID, update)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEventNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrVenueDoubleBooked), errors.Is(err, services.ErrEventPassed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Event updated", "event": event})
+}
+
 func (h *OrganizerHandler) PublishEvent(c *gin.Context) {
 	eventID := c.Param("id")
 	userIDVal, _ := c.Get("userID")
@@ -67,6 +119,265 @@ func (h *OrganizerHandler) CancelEvent(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Event cancelled successfully"})
 }
 
+type admissionModeRequest struct {
+	Mode models.AdmissionMode `json:"mode" binding:"required"`
+}
+
+// SetAdmissionMode switches an event to queued admission (QUEUE) ahead of a
+// flash sale, or back to direct booking (DIRECT).
+func (h *OrganizerHandler) SetAdmissionMode(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var req admissionModeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.eventService.SetAdmissionMode(c.Request.Context(), organizerID, eventID, req.Mode)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Admission mode updated", "event": event})
+}
+
+// SetRefundPolicy replaces the event's refund policy: a full refund while at
+// least full_refund_days remain, partial_percent after that, and nothing
+// within no_refund_hours of the start.
+func (h *OrganizerHandler) SetRefundPolicy(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var policy models.RefundPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.eventService.SetRefundPolicy(c.Request.Context(), organizerID, eventID, policy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Refund policy updated", "event": event})
+}
+
+// SetTransferPolicy replaces the event's transfer policy. With disabled
+// false and no cutoff, tickets may change hands until the event starts.
+func (h *OrganizerHandler) SetTransferPolicy(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var policy models.TransferPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.eventService.SetTransferPolicy(c.Request.Context(), organizerID, eventID, policy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer policy updated", "event": event})
+}
+
+// CreateSeatMap stores a venue layout that events can then use for reserved
+// seating.
+func (h *OrganizerHandler) CreateSeatMap(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var req services.SeatMapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seatMap, err := h.seating.CreateSeatMap(c.Request.Context(), organizerID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Seat map created", "seat_map": seatMap})
+}
+
+func (h *OrganizerHandler) ListSeatMaps(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	seatMaps, err := h.seating.ListSeatMaps(c.Request.Context(), organizerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seat maps"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"seat_maps": seatMaps})
+}
+
+func (h *OrganizerHandler) GetSeatMap(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	seatMap, err := h.seating.GetSeatMap(c.Request.Context(), organizerID, c.Param("map_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"seat_map": seatMap})
+}
+
+// AssignSeatMap gives a draft event reserved seating from one of the
+// organizer's seat maps. The event's capacity becomes the map's seat count.
+func (h *OrganizerHandler) AssignSeatMap(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var req struct {
+		SeatMapID string `json:"seat_map_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := h.seating.AssignSeatMap(c.Request.Context(), organizerID, eventID, req.SeatMapID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrEventNotFound), errors.Is(err, services.ErrSeatMapNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Seat map assigned", "event": event})
+}
+
+// CreateTicketType adds a priced tier to a paid event. Hidden tiers are only
+// sold with an access code that lists them.
+func (h *OrganizerHandler) CreateTicketType(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var ticketType models.TicketType
+	if err := c.ShouldBindJSON(&ticketType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.promoService.CreateTicketType(c.Request.Context(), organizerID, eventID, &ticketType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Ticket type created", "ticket_type": ticketType})
+}
+
+func (h *OrganizerHandler) CreatePromoCode(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var req services.PromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	promo, err := h.promoService.CreatePromoCode(c.Request.Context(), organizerID, eventID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPromoCodeTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrTicketTypeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Promo code created", "promo_code": promo})
+}
+
+func (h *OrganizerHandler) ListPromoCodes(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	promos, err := h.promoService.ListPromoCodes(c.Request.Context(), organizerID, eventID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"promo_codes": promos})
+}
+
+func (h *OrganizerHandler) DeactivatePromoCode(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	promo, err := h.promoService.DeactivatePromoCode(c.Request.Context(), organizerID, c.Param("code_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Promo code deactivated", "promo_code": promo})
+}
+
+// ConfigureWaitingRoom sets up or updates the event's waiting room: whether
+// it gates bookings, when the sale opens and how many users are admitted per
+// minute.
+func (h *OrganizerHandler) ConfigureWaitingRoom(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var settings services.WaitingRoomSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	room, err := h.waitingRoom.Configure(c.Request.Context(), organizerID, eventID, settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Waiting room updated", "waiting_room": room})
+}
+
+func (h *OrganizerHandler) GetWaitingRoom(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	overview, err := h.waitingRoom.Overview(c.Request.Context(), organizerID, eventID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, overview)
+}
+
 func (h *OrganizerHandler) ListMyEvents(c *gin.Context) {
 	userIDVal, _ := c.Get("userID")
 	organizerID := userIDVal.(string)
@@ -85,7 +396,18 @@ func (h *OrganizerHandler) GetAnalytics(c *gin.Context) {
 	userIDVal, _ := c.Get("userID")
 	organizerID := userIDVal.(string)
 
-	analytics, err := h.regService.GetOrganizerAnalytics(c.Request.Context(), organizerID, eventID)
+	query := services.AnalyticsQuery{Bucket: services.AnalyticsBucket(c.Query("bucket"))}
+	var err error
+	if query.From, err = parseAnalyticsTime(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parameter: from"})
+		return
+	}
+	if query.To, err = parseAnalyticsTime(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parameter: to"})
+		return
+	}
+
+	analytics, err := h.regService.GetOrganizerAnalytics(c.Request.Context(), organizerID, eventID, query)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -93,3 +415,110 @@ func (h *OrganizerHandler) GetAnalytics(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"analytics": analytics})
 }
+
+// parseAnalyticsTime accepts RFC3339 timestamps or plain YYYY-MM-DD dates.
+func parseAnalyticsTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func (h *OrganizerHandler) CheckInRegistration(c *gin.Context) {
+	regID := c.Param("registration_id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	reg, err := h.regService.CheckIn(c.Request.Context(), organizerID, regID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Attendee checked in",
+		"registration": reg,
+	})
+}
+
+// CheckInTicket checks in the holder of a scanned ticket token. Tokens
+// replaced by a transfer are rejected as unknown.
+func (h *OrganizerHandler) CheckInTicket(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var req struct {
+		TicketToken string `json:"ticket_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reg, err := h.regService.CheckInTicket(c.Request.Context(), organizerID, eventID, req.TicketToken)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Attendee checked in",
+		"registration": reg,
+	})
+}
+
+// ImportAttendees accepts a CSV either as a multipart "file" field or as the
+// raw request body. Pass ?dry_run=true to preview the report without booking.
+func (h *OrganizerHandler) ImportAttendees(c *gin.Context) {
+	eventID := c.Param("id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+	dryRun := c.Query("dry_run") == "true"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportFileSize)
+
+	var data io.Reader = c.Request.Body
+	if fileHeader, err := c.FormFile("file"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read uploaded file"})
+			return
+		}
+		defer file.Close()
+		data = file
+	}
+
+	job, err := h.importService.StartImport(c.Request.Context(), organizerID, eventID, data, dryRun)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if job.Status == models.ImportJobStatusRunning {
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Import started. Poll the job for progress.",
+			"job":     job,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
+
+func (h *OrganizerHandler) GetImportJob(c *gin.Context) {
+	jobID := c.Param("job_id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	job, err := h.importService.GetImportJob(c.Request.Context(), organizerID, jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job})
+}
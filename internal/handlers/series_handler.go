@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"event_registration/internal/models"
+	"event_registration/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SeriesHandler struct {
+	seriesService services.SeriesService
+}
+
+func NewSeriesHandler(seriesService services.SeriesService) *SeriesHandler {
+	return &SeriesHandler{seriesService: seriesService}
+}
+
+// CreateSeries creates a recurring series and its sessions as drafts.
+func (h *SeriesHandler) CreateSeries(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var series models.EventSeries
+	if err := c.ShouldBindJSON(&series); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.seriesService.CreateSeries(c.Request.Context(), organizerID, &series)
+	if err != nil {
+		writeSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Series created with sessions in Draft status",
+		"series":  created,
+	})
+}
+
+func (h *SeriesHandler) ListSeries(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	series, err := h.seriesService.ListSeries(c.Request.Context(), organizerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch series"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+func (h *SeriesHandler) GetSeries(c *gin.Context) {
+	seriesID := c.Param("series_id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	series, err := h.seriesService.GetSeries(c.Request.Context(), organizerID, seriesID)
+	if err != nil {
+		writeSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// UpdateSeries applies the change to every session that has not started.
+func (h *SeriesHandler) UpdateSeries(c *gin.Context) {
+	seriesID := c.Param("series_id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var update services.SeriesUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.seriesService.UpdateSeries(c.Request.Context(), organizerID, seriesID, update)
+	if err != nil {
+		writeSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series updated", "series": series})
+}
+
+func (h *SeriesHandler) PublishSeries(c *gin.Context) {
+	seriesID := c.Param("series_id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	series, err := h.seriesService.PublishSeries(c.Request.Context(), organizerID, seriesID)
+	if err != nil {
+		writeSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series published", "series": series})
+}
+
+// GetPublicSeries shows attendees the published sessions of a series.
+func (h *SeriesHandler) GetPublicSeries(c *gin.Context) {
+	series, err := h.seriesService.GetPublicSeries(c.Request.Context(), c.Param("series_id"))
+	if err != nil {
+		writeSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series})
+}
+
+// SubscribeSeries books the user onto every upcoming session at once.
+func (h *SeriesHandler) SubscribeSeries(c *gin.Context) {
+	seriesID := c.Param("series_id")
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	regs, err := h.seriesService.SubscribeSeries(c.Request.Context(), userID, seriesID)
+	if err != nil {
+		writeSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":       "Subscribed to every upcoming session",
+		"registrations": regs,
+	})
+}
+
+func writeSeriesError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrSeriesNotFound), errors.Is(err, services.ErrEventNotFound),
+		errors.Is(err, services.ErrVenueNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPaymentRequired):
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrSoldOut), errors.Is(err, services.ErrAlreadyRegistered),
+		errors.Is(err, services.ErrAlreadyWaitlisted), errors.Is(err, services.ErrSeriesNoSessions),
+		errors.Is(err, services.ErrSeriesQueued), errors.Is(err, services.ErrSeriesWaitingRoom),
+		errors.Is(err, services.ErrVenueDoubleBooked), errors.Is(err, services.ErrEventPassed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"event_registration/internal/models"
+	"event_registration/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TransferHandler struct {
+	transferService services.TransferService
+}
+
+func NewTransferHandler(transferService services.TransferService) *TransferHandler {
+	return &TransferHandler{transferService: transferService}
+}
+
+// OfferTransfer offers one of the caller's tickets to the given email. The
+// recipient accepts it after signing in with that address.
+func (h *TransferHandler) OfferTransfer(c *gin.Context) {
+	regID := c.Param("registration_id")
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transfer, err := h.transferService.OfferTransfer(c.Request.Context(), userID, regID, req.Email)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Transfer offered", "transfer": transfer})
+}
+
+// ListTransfers returns the transfers the caller sent or was offered.
+func (h *TransferHandler) ListTransfers(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	transfers, err := h.transferService.ListTransfers(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfers": transfers})
+}
+
+// AcceptTransfer moves the ticket to the caller. The sender's ticket token
+// stops working.
+func (h *TransferHandler) AcceptTransfer(c *gin.Context) {
+	h.respond(c, h.transferService.AcceptTransfer, "Transfer accepted")
+}
+
+func (h *TransferHandler) DeclineTransfer(c *gin.Context) {
+	h.respond(c, h.transferService.DeclineTransfer, "Transfer declined")
+}
+
+func (h *TransferHandler) CancelTransfer(c *gin.Context) {
+	h.respond(c, h.transferService.CancelTransfer, "Transfer cancelled")
+}
+
+func (h *TransferHandler) respond(c *gin.Context, action func(ctx context.Context, userID, transferID string) (*models.TicketTransfer, error), message string) {
+	transferID := c.Param("transfer_id")
+	userIDVal, _ := c.Get("userID")
+	userID := userIDVal.(string)
+
+	transfer, err := action(c.Request.Context(), userID, transferID)
+	if err != nil {
+		writeTransferError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message, "transfer": transfer})
+}
+
+func writeTransferError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrTransferNotFound), errors.Is(err, services.ErrEventNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTransferNotPending), errors.Is(err, services.ErrTransferPending),
+		errors.Is(err, services.ErrTransferStale), errors.Is(err, services.ErrAlreadyRegistered),
+		errors.Is(err, services.ErrAlreadyWaitlisted), errors.Is(err, services.ErrOrderPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrTransfersClosed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"event_registration/internal/models"
+	"event_registration/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type VenueHandler struct {
+	venueService services.VenueService
+}
+
+func NewVenueHandler(venueService services.VenueService) *VenueHandler {
+	return &VenueHandler{venueService: venueService}
+}
+
+func (h *VenueHandler) CreateVenue(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var venue models.Venue
+	if err := c.ShouldBindJSON(&venue); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.venueService.CreateVenue(c.Request.Context(), organizerID, &venue)
+	if err != nil {
+		writeVenueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Venue created", "venue": created})
+}
+
+func (h *VenueHandler) ListVenues(c *gin.Context) {
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	venues, err := h.venueService.ListVenues(c.Request.Context(), organizerID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch venues"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"venues": venues})
+}
+
+func (h *VenueHandler) GetVenue(c *gin.Context) {
+	venueID := c.Param("venue_id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	venue, err := h.venueService.GetVenue(c.Request.Context(), organizerID, venueID)
+	if err != nil {
+		writeVenueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"venue": venue})
+}
+
+// UpdateVenue replaces every field of the venue with the request body.
+func (h *VenueHandler) UpdateVenue(c *gin.Context) {
+	venueID := c.Param("venue_id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	var venue models.Venue
+	if err := c.ShouldBindJSON(&venue); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.venueService.UpdateVenue(c.Request.Context(), organizerID, venueID, &venue)
+	if err != nil {
+		writeVenueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Venue updated", "venue": updated})
+}
+
+func (h *VenueHandler) DeleteVenue(c *gin.Context) {
+	venueID := c.Param("venue_id")
+	userIDVal, _ := c.Get("userID")
+	organizerID := userIDVal.(string)
+
+	if err := h.venueService.DeleteVenue(c.Request.Context(), organizerID, venueID); err != nil {
+		writeVenueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Venue deleted"})
+}
+
+func writeVenueError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrVenueNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVenueInUse), errors.Is(err, services.ErrOverVenueCapacity):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
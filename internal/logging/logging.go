@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	traceKey
+)
+
+// TraceContext carries the W3C trace identifiers received in a traceparent header.
+type TraceContext struct {
+	TraceID  string
+	ParentID string
+	Flags    string
+}
+
+// Setup installs a JSON slog logger as the process default. Records logged
+// with a context automatically carry its request and trace IDs.
+func Setup(level string) {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: parseLevel(level)})
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+}
+
+// Fatal logs at error level and exits, replacing log.Fatalf.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+func WithTrace(ctx context.Context, trace TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey, trace)
+}
+
+func TraceFrom(ctx context.Context) (TraceContext, bool) {
+	trace, ok := ctx.Value(traceKey).(TraceContext)
+	return trace, ok
+}
+
+// contextHandler decorates records with the correlation IDs stored in ctx.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestIDFrom(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		// Prefer the active OpenTelemetry span; fall back to the raw
+		// traceparent when tracing is disabled.
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		} else if tc, ok := TraceFrom(ctx); ok {
+			r.AddAttrs(slog.String("trace_id", tc.TraceID), slog.String("parent_span_id", tc.ParentID))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
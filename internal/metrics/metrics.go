@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"gorm.io/gorm"
+)
+
+const namespace = "event_registration"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	BookingsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bookings_total",
+		Help:      "Confirmed bookings committed by BookEvent.",
+	})
+
+	WaitlistJoinsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "waitlist_joins_total",
+		Help:      "Booking attempts that were placed on the waitlist.",
+	})
+
+	CancellationsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cancellations_total",
+		Help:      "Registrations cancelled by attendees.",
+	})
+
+	PromotionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "waitlist_promotions_total",
+		Help:      "Waitlisted users promoted into a freed seat.",
+	})
+
+	BookingFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "booking_failures_total",
+		Help:      "Failed booking attempts by reason.",
+	}, []string{"reason"})
+
+	// Time spent blocked on SELECT ... FOR UPDATE for the event row. A
+	// growing tail here is the signal that pessimistic locking is hurting.
+	LockWaitDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "event_lock_wait_seconds",
+		Help:      "Time spent acquiring the event row lock.",
+		Buckets:   []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"operation"})
+
+	// Booking seat allocation latency by strategy, for comparing the
+	// pessimistic, optimistic and atomic allocators under the simulator.
+	SeatAllocationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "seat_allocation_seconds",
+		Help:      "Time spent allocating a seat, by allocation strategy.",
+		Buckets:   []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"strategy"})
+
+	OptimisticConflictsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "optimistic_conflicts_total",
+		Help:      "Optimistic seat allocations that lost the version check and retried.",
+	})
+
+	// Inconsistencies found by the last reconciliation pass, by kind
+	// (seat_drift, orphaned_waitlist). Anything non-zero deserves an alert.
+	ReconciliationIssues = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "reconciliation_issues",
+		Help:      "Events with inconsistent seat state found by the last reconciliation check.",
+	}, []string{"kind"})
+
+	ReconciliationRepairsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconciliation_repairs_total",
+		Help:      "Repairs applied by the reconciler, by kind.",
+	}, []string{"kind"})
+
+	// Time a queued booking request waited between enqueue and the worker
+	// resolving it. This is the latency clients of QUEUE events see.
+	BookingQueueWaitDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "booking_queue_wait_seconds",
+		Help:      "Time from enqueueing a booking request to its resolution.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	})
+
+	BookingQueueProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "booking_queue_processed_total",
+		Help:      "Queued booking requests resolved by the worker, by outcome.",
+	}, []string{"outcome"})
+
+	WaitingRoomAdmissionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "waiting_room_admissions_total",
+		Help:      "Users admitted from event waiting rooms.",
+	})
+
+	// Order transitions: placed, paid, failed, expired, and late_payment for
+	// payments that arrived after the seat hold was released.
+	OrdersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_total",
+		Help:      "Ticket order transitions by outcome.",
+	}, []string{"outcome"})
+
+	// Refund transitions: requested, issued, retry for a provider error that
+	// will be tried again, and failed once the attempts are used up.
+	RefundsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "refunds_total",
+		Help:      "Refund ledger transitions by outcome.",
+	}, []string{"outcome"})
+
+	// Group bookings by outcome: confirmed, partial, waitlisted, or
+	// rejected when too few seats were left.
+	GroupBookingsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "group_bookings_total",
+		Help:      "Group bookings by outcome.",
+	}, []string{"outcome"})
+)
+
+// ObserveLockWait records how long acquiring the event row lock took.
+func ObserveLockWait(operation string, start time.Time) {
+	LockWaitDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+// RegisterDBStats exposes the connection pool stats of the underlying sql.DB.
+func RegisterDBStats(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return prometheus.Register(collectors.NewDBStatsCollector(sqlDB, "postgres"))
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"event_registration/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records request latency labelled by the matched route template so
+// that path parameters don't explode label cardinality.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
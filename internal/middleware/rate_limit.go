@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Buckets idle longer than this are dropped so the map does not grow without
+// bound under many distinct client IPs.
+const rateLimitIdleTTL = 10 * time.Minute
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimit applies a per-client-IP token bucket refilled at rps tokens per
+// second up to burst. A non-positive rps disables limiting.
+func RateLimit(rps float64, burst int) gin.HandlerFunc {
+	if rps <= 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	var mu sync.Mutex
+	buckets := make(map[string]*tokenBucket)
+	lastSweep := time.Now()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		key := c.ClientIP()
+
+		mu.Lock()
+		if now.Sub(lastSweep) > rateLimitIdleTTL {
+			for k, b := range buckets {
+				if now.Sub(b.lastSeen) > rateLimitIdleTTL {
+					delete(buckets, k)
+				}
+			}
+			lastSweep = now
+		}
+		b, ok := buckets[key]
+		if !ok {
+			b = &tokenBucket{tokens: float64(burst), lastSeen: now}
+			buckets[key] = b
+		}
+		b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.lastSeen).Seconds()*rps)
+		b.lastSeen = now
+		allowed := b.tokens >= 1
+		if allowed {
+			b.tokens--
+		}
+		wait := (1 - b.tokens) / rps
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
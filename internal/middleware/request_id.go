@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"event_registration/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// version-traceid-parentid-flags, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+var traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// RequestID propagates (or generates) an X-Request-ID and any W3C traceparent
+// into the request context so downstream logs can be correlated.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+
+		ctx := logging.WithRequestID(c.Request.Context(), requestID)
+		if trace, ok := parseTraceparent(c.GetHeader("traceparent")); ok {
+			ctx = logging.WithTrace(ctx, trace)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Set("requestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+func parseTraceparent(header string) (logging.TraceContext, bool) {
+	m := traceparentPattern.FindStringSubmatch(strings.TrimSpace(strings.ToLower(header)))
+	if m == nil || m[1] == strings.Repeat("0", 32) || m[2] == strings.Repeat("0", 16) {
+		return logging.TraceContext{}, false
+	}
+	return logging.TraceContext{TraceID: m[1], ParentID: m[2], Flags: m[3]}, true
+}
+
+// RequestLogger replaces gin's text access log with one structured line per request.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		if userID, ok := c.Get("userID"); ok {
+			attrs = append(attrs, "user_id", userID)
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		slog.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery turns panics into a 500 and logs them with the request's IDs.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered", "error", err, "path", c.Request.URL.Path, "stack", string(debug.Stack()))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+	})
+}
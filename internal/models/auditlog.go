@@ -7,13 +7,40 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	AuditActionRegistrationConfirmed = "REGISTRATION_CONFIRMED"
+	AuditActionRegistrationCancelled = "REGISTRATION_CANCELLED"
+	AuditActionRegistrationCheckedIn = "REGISTRATION_CHECKED_IN"
+	AuditActionWaitlistJoined        = "WAITLIST_JOINED"
+	AuditActionWaitlistPromoted      = "WAITLIST_PROMOTED"
+	AuditActionSeatCountRepaired     = "SEAT_COUNT_REPAIRED"
+	AuditActionOrderPlaced           = "ORDER_PLACED"
+	AuditActionOrderPaid             = "ORDER_PAID"
+	AuditActionOrderFailed           = "ORDER_FAILED"
+	AuditActionRefundRequested       = "REFUND_REQUESTED"
+	AuditActionRefundIssued          = "REFUND_ISSUED"
+	AuditActionRefundFailed          = "REFUND_FAILED"
+	AuditActionTransferOffered       = "TRANSFER_OFFERED"
+	AuditActionTransferAccepted      = "TRANSFER_ACCEPTED"
+)
+
+const (
+	AuditEntityRegistration = "REGISTRATION"
+	AuditEntityWaitlist     = "WAITLIST"
+	AuditEntityEvent        = "EVENT"
+	AuditEntityOrder        = "ORDER"
+	AuditEntityRefund       = "REFUND"
+	AuditEntityTransfer     = "TRANSFER"
+)
+
 type AuditLog struct {
-	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	ActorID    uuid.UUID `gorm:"type:uuid;not null" json:"actor_id"`
-	Action     string    `gorm:"not null" json:"action"`
-	EntityType string    `gorm:"not null" json:"entity_type"`
-	EntityID   uuid.UUID `gorm:"type:uuid;not null" json:"entity_id"`
-	Timestamp  time.Time `json:"timestamp"`
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ActorID    uuid.UUID  `gorm:"type:uuid;not null" json:"actor_id"`
+	Action     string     `gorm:"not null" json:"action"`
+	EntityType string     `gorm:"not null" json:"entity_type"`
+	EntityID   uuid.UUID  `gorm:"type:uuid;not null" json:"entity_id"`
+	EventID    *uuid.UUID `gorm:"type:uuid;index:idx_audit_event_time" json:"event_id,omitempty"`
+	Timestamp  time.Time  `gorm:"index:idx_audit_event_time" json:"timestamp"`
 
 	Actor User `gorm:"foreignKey:ActorID;references:ID" json:"actor,omitempty"`
 }
@@ -22,5 +49,8 @@ func (a *AuditLog) BeforeCreate(tx *gorm.DB) (err error) {
 	if a.ID == uuid.Nil {
 		a.ID = uuid.New()
 	}
+	if a.Timestamp.IsZero() {
+		a.Timestamp = time.Now()
+	}
 	return
 }
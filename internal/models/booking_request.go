@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BookingRequestStatus string
+
+const (
+	BookingRequestPending    BookingRequestStatus = "PENDING"
+	BookingRequestConfirmed  BookingRequestStatus = "CONFIRMED"
+	BookingRequestWaitlisted BookingRequestStatus = "WAITLISTED"
+	BookingRequestFailed     BookingRequestStatus = "FAILED"
+)
+
+// BookingRequest is a queued booking for an event in QUEUE admission mode.
+// Ticket is assigned by the database on insert and orders the queue.
+type BookingRequest struct {
+	ID             uuid.UUID            `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Ticket         int64                `gorm:"autoIncrement;not null;uniqueIndex" json:"ticket"`
+	EventID        uuid.UUID            `gorm:"type:uuid;not null" json:"event_id"`
+	UserID         uuid.UUID            `gorm:"type:uuid;not null" json:"user_id"`
+	Status         BookingRequestStatus `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"`
+	RegistrationID *uuid.UUID           `gorm:"type:uuid" json:"registration_id,omitempty"`
+	WaitlistID     *uuid.UUID           `gorm:"type:uuid" json:"waitlist_id,omitempty"`
+	FailureReason  string               `json:"failure_reason,omitempty"`
+	CreatedAt      time.Time            `json:"created_at"`
+	ProcessedAt    *time.Time           `json:"processed_at,omitempty"`
+
+	// Requests still pending ahead of this one; filled in on read.
+	Position int64 `gorm:"-" json:"position,omitempty"`
+}
+
+func (r *BookingRequest) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
+
+func (r *BookingRequest) Resolved() bool {
+	return r.Status != BookingRequestPending
+}
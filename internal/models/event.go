@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,24 +17,143 @@ const (
 	EventStatusCancelled EventStatus = "CANCELLED"
 )
 
+// AllocationStrategy selects how BookEvent takes a seat under concurrency.
+type AllocationStrategy string
+
+const (
+	// SELECT ... FOR UPDATE on the event row, then decrement.
+	AllocationPessimistic AllocationStrategy = "pessimistic"
+	// Read without locking, then UPDATE ... WHERE version = ?; retry on conflict.
+	AllocationOptimistic AllocationStrategy = "optimistic"
+	// A single conditional UPDATE ... WHERE seats_remaining > 0 RETURNING.
+	AllocationAtomic AllocationStrategy = "atomic"
+)
+
+func (s AllocationStrategy) Valid() bool {
+	return s == AllocationPessimistic || s == AllocationOptimistic || s == AllocationAtomic
+}
+
+type AdmissionMode string
+
+const (
+	AdmissionDirect AdmissionMode = "DIRECT"
+	AdmissionQueue  AdmissionMode = "QUEUE"
+)
+
+func (m AdmissionMode) Valid() bool {
+	return m == AdmissionDirect || m == AdmissionQueue
+}
+
+// EventPeriod filters listings by calendar period. Periods are evaluated in
+// each event's own time zone, so "today" is the attendee-facing local day.
+type EventPeriod string
+
+const (
+	PeriodToday       EventPeriod = "today"
+	PeriodThisWeekend EventPeriod = "this_weekend"
+)
+
+func (p EventPeriod) Valid() bool {
+	return p == PeriodToday || p == PeriodThisWeekend
+}
+
 type Event struct {
-	ID             uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Title          string      `gorm:"not null" json:"title"`
-	Description    string      `json:"description"`
-	Location       string      `json:"location"`
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Title       string    `gorm:"not null" json:"title"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	// EventDate is when the event starts and EndsAt when it ends. Both are
+	// instants; Timezone, an IANA zone name, is where they are shown.
 	EventDate      time.Time   `gorm:"not null" json:"event_date"`
+	EndsAt         time.Time   `gorm:"not null;check:chk_events_ends_after_start,ends_at > event_date" json:"ends_at"`
+	Timezone       string      `gorm:"not null;default:'UTC'" json:"timezone"`
 	Capacity       int         `gorm:"not null;check:capacity >= 0" json:"capacity"`
 	SeatsRemaining int         `gorm:"not null;check:seats_remaining >= 0" json:"seats_remaining"`
 	OrganizerID    uuid.UUID   `gorm:"type:uuid;not null" json:"organizer_id"`
 	Status         EventStatus `gorm:"type:varchar(20);not null;default:'DRAFT'" json:"status"`
+	PublishedAt    *time.Time  `json:"published_at,omitempty"`
 	CreatedAt      time.Time   `json:"created_at"`
 	UpdatedAt      time.Time   `json:"updated_at"`
 
+	// AllocationStrategy overrides the deployment's seat allocation strategy
+	// for this event when set. Version is bumped on every seats_remaining
+	// write so optimistic allocation can detect conflicts.
+	AllocationStrategy AllocationStrategy `gorm:"type:varchar(20)" json:"allocation_strategy,omitempty"`
+	Version            int64              `gorm:"not null;default:0" json:"-"`
+
+	// AdmissionMode QUEUE routes bookings through the booking_requests
+	// queue instead of contending on the row lock per request.
+	AdmissionMode AdmissionMode `gorm:"type:varchar(20);not null;default:'DIRECT'" json:"admission_mode"`
+
+	// Price per ticket; a zero price makes the event free to book directly.
+	Price        Money        `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	RefundPolicy RefundPolicy `gorm:"embedded;embeddedPrefix:refund_" json:"refund_policy"`
+
+	TransferPolicy TransferPolicy `gorm:"embedded;embeddedPrefix:transfer_" json:"transfer_policy"`
+
+	// SeatMapID gives the event reserved seating: capacity is then the
+	// number of seats in the map, and seats are booked one by one.
+	SeatMapID *uuid.UUID `gorm:"type:uuid" json:"seat_map_id,omitempty"`
+
+	// VenueID is where the event is held; capacity may not exceed the
+	// venue's maximum.
+	VenueID *uuid.UUID `gorm:"type:uuid" json:"venue_id,omitempty"`
+
+	// DistanceKm is how far the venue is from the point of a proximity
+	// search. It is computed by the query, never stored.
+	DistanceKm *float64 `gorm:"->;-:migration" json:"distance_km,omitempty"`
+
+	// SeriesID is set on the sessions of a recurring series.
+	SeriesID *uuid.UUID `gorm:"type:uuid;index" json:"series_id,omitempty"`
+
 	Organizer     User           `gorm:"foreignKey:OrganizerID;references:ID" json:"organizer,omitempty"`
+	Venue         *Venue         `gorm:"foreignKey:VenueID;references:ID" json:"venue,omitempty"`
 	Registrations []Registration `gorm:"foreignKey:EventID" json:"registrations,omitempty"`
 	WaitlistItems []Waitlist     `gorm:"foreignKey:EventID" json:"waitlist_items,omitempty"`
 }
 
+// IsPaid reports whether tickets must be bought through an order.
+func (e *Event) IsPaid() bool {
+	return e.Price.Amount > 0
+}
+
+// ReservedSeating reports whether attendees book specific seats.
+func (e *Event) ReservedSeating() bool {
+	return e.SeatMapID != nil
+}
+
+// zones caches loaded locations; listings render many events per zone.
+var zones sync.Map
+
+// Zone is the event's time zone, or UTC when it is unset or unknown.
+func (e *Event) Zone() *time.Location {
+	if loc, ok := zones.Load(e.Timezone); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(e.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	zones.Store(e.Timezone, loc)
+	return loc
+}
+
+// MarshalJSON adds the start and end as local times in the event's zone,
+// e.g. "2026-12-05T20:00:00+01:00", next to the stored instants.
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	zone := e.Zone()
+	return json.Marshal(struct {
+		event
+		StartsAtLocal string `json:"starts_at_local"`
+		EndsAtLocal   string `json:"ends_at_local"`
+	}{
+		event:         event(e),
+		StartsAtLocal: e.EventDate.In(zone).Format(time.RFC3339),
+		EndsAtLocal:   e.EndsAt.In(zone).Format(time.RFC3339),
+	})
+}
+
 func (e *Event) BeforeCreate(tx *gorm.DB) (err error) {
 	if e.ID == uuid.Nil {
 		e.ID = uuid.New()
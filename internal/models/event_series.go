@@ -0,0 +1,140 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RecurrenceFrequency string
+
+const (
+	FrequencyDaily   RecurrenceFrequency = "DAILY"
+	FrequencyWeekly  RecurrenceFrequency = "WEEKLY"
+	FrequencyMonthly RecurrenceFrequency = "MONTHLY"
+)
+
+func (f RecurrenceFrequency) Valid() bool {
+	return f == FrequencyDaily || f == FrequencyWeekly || f == FrequencyMonthly
+}
+
+// Weekdays maps the RRULE day codes used in ByDay to weekdays.
+var Weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Recurrence is a subset of an iCalendar RRULE: every Interval days, weeks
+// or months, on the ByDay weekdays for weekly rules, until Until or for
+// Count sessions.
+type Recurrence struct {
+	Frequency RecurrenceFrequency `gorm:"type:varchar(10);not null" json:"frequency"`
+	Interval  int                 `gorm:"not null;default:1" json:"interval"`
+	ByDay     []string            `gorm:"type:jsonb;serializer:json" json:"by_day,omitempty"`
+	Until     *time.Time          `json:"until,omitempty"`
+	Count     int                 `json:"count,omitempty"`
+}
+
+// Occurrences lists session start times from first, keeping first's local
+// time of day in its location. It stops after Until, Count or limit
+// sessions, whichever comes first. Monthly rules skip months that do not
+// have first's day, as RRULE does.
+func (r Recurrence) Occurrences(first time.Time, limit int) []time.Time {
+	interval := max(r.Interval, 1)
+	y, m, d := first.Date()
+	hour, minute, sec := first.Clock()
+	at := func(month time.Month, day int) time.Time {
+		return time.Date(y, month, day, hour, minute, sec, 0, first.Location())
+	}
+
+	var starts []time.Time
+	// more appends t and reports whether the rule has sessions after it.
+	more := func(t time.Time) bool {
+		if r.Until != nil && t.After(*r.Until) {
+			return false
+		}
+		starts = append(starts, t)
+		return len(starts) < limit && (r.Count == 0 || len(starts) < r.Count)
+	}
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		for i := 0; ; i += interval {
+			if !more(at(m, d+i)) {
+				return starts
+			}
+		}
+	case FrequencyWeekly:
+		days := map[time.Weekday]bool{}
+		for _, code := range r.ByDay {
+			days[Weekdays[code]] = true
+		}
+		if len(days) == 0 {
+			days[first.Weekday()] = true
+		}
+		// Weeks start on Monday, the RRULE default.
+		monday := d - (int(first.Weekday())+6)%7
+		for week := 0; ; week += interval {
+			for offset := 0; offset < 7; offset++ {
+				t := at(m, monday+week*7+offset)
+				if !days[t.Weekday()] || t.Before(first) {
+					continue
+				}
+				if !more(t) {
+					return starts
+				}
+			}
+		}
+	case FrequencyMonthly:
+		for i := 0; ; i += interval {
+			t := at(m+time.Month(i), d)
+			if t.Day() != d {
+				// The month is too short; it still counts towards Until.
+				if r.Until != nil && t.After(*r.Until) {
+					return starts
+				}
+				continue
+			}
+			if !more(t) {
+				return starts
+			}
+		}
+	}
+	return starts
+}
+
+// EventSeries is a recurring event. Each session is an Event of its own,
+// with its own capacity and registrations, generated from the rule.
+type EventSeries struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizerID uuid.UUID  `gorm:"type:uuid;not null;index" json:"organizer_id"`
+	Title       string     `gorm:"not null" json:"title"`
+	Description string     `json:"description"`
+	Location    string     `json:"location"`
+	Capacity    int        `gorm:"not null;check:capacity > 0" json:"capacity"`
+	VenueID     *uuid.UUID `gorm:"type:uuid" json:"venue_id,omitempty"`
+	Timezone    string     `gorm:"not null" json:"timezone"`
+
+	// StartsAt and EndsAt are the first session's. Later sessions start at
+	// the same local time and last as long.
+	StartsAt   time.Time  `gorm:"not null" json:"starts_at"`
+	EndsAt     time.Time  `gorm:"not null" json:"ends_at"`
+	Recurrence Recurrence `gorm:"embedded;embeddedPrefix:recurrence_" json:"recurrence"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Events    []Event   `gorm:"foreignKey:SeriesID" json:"events,omitempty"`
+}
+
+func (s *EventSeries) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
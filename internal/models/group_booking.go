@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GroupBookingStatus string
+
+const (
+	GroupBookingConfirmed  GroupBookingStatus = "CONFIRMED"
+	GroupBookingPartial    GroupBookingStatus = "PARTIAL"
+	GroupBookingWaitlisted GroupBookingStatus = "WAITLISTED"
+	GroupBookingCancelled  GroupBookingStatus = "CANCELLED"
+)
+
+// GroupShortfall is what a group booking does when fewer seats remain than
+// it asks for.
+type GroupShortfall string
+
+const (
+	// ShortfallReject books nothing: the group is all-or-nothing.
+	ShortfallReject GroupShortfall = "REJECT"
+	// ShortfallPartial books the seats that are left.
+	ShortfallPartial GroupShortfall = "PARTIAL"
+	// ShortfallWaitlist waits for the whole group to fit.
+	ShortfallWaitlist GroupShortfall = "WAITLIST"
+)
+
+func (s GroupShortfall) Valid() bool {
+	return s == ShortfallReject || s == ShortfallPartial || s == ShortfallWaitlist
+}
+
+// GroupAttendee names the person using one seat of a group booking.
+type GroupAttendee struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// GroupBooking is one purchaser's booking of several seats. Every confirmed
+// seat is its own Registration owned by the purchaser, so seats are checked
+// in and cancelled one by one. Attendees keeps the names the purchaser gave
+// until a waitlisted group is promoted.
+type GroupBooking struct {
+	ID        uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EventID   uuid.UUID          `gorm:"type:uuid;not null;index" json:"event_id"`
+	UserID    uuid.UUID          `gorm:"type:uuid;not null;index" json:"user_id"`
+	Seats     int                `gorm:"not null" json:"seats"`
+	Shortfall GroupShortfall     `gorm:"type:varchar(20);not null" json:"on_shortfall"`
+	Status    GroupBookingStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Attendees []GroupAttendee    `gorm:"type:jsonb;serializer:json" json:"attendees,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+
+	Registrations []Registration `gorm:"foreignKey:GroupBookingID" json:"registrations,omitempty"`
+}
+
+func (g *GroupBooking) BeforeCreate(tx *gorm.DB) (err error) {
+	if g.ID == uuid.Nil {
+		g.ID = uuid.New()
+	}
+	return
+}
+
+// Attendee returns the name given for the i-th seat, if any.
+func (g *GroupBooking) Attendee(i int) GroupAttendee {
+	if i < len(g.Attendees) {
+		return g.Attendees[i]
+	}
+	return GroupAttendee{}
+}
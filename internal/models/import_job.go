@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ImportJobStatus string
+
+const (
+	ImportJobStatusPending   ImportJobStatus = "PENDING"
+	ImportJobStatusRunning   ImportJobStatus = "RUNNING"
+	ImportJobStatusCompleted ImportJobStatus = "COMPLETED"
+	ImportJobStatusFailed    ImportJobStatus = "FAILED"
+)
+
+type ImportRowOutcome string
+
+const (
+	ImportRowOutcomeBooked     ImportRowOutcome = "BOOKED"
+	ImportRowOutcomeWaitlisted ImportRowOutcome = "WAITLISTED"
+	ImportRowOutcomeSkipped    ImportRowOutcome = "SKIPPED"
+	ImportRowOutcomeFailed     ImportRowOutcome = "FAILED"
+)
+
+// ImportJob tracks a bulk attendee import so that large files can be
+// processed in the background while the organizer polls for progress.
+type ImportJob struct {
+	ID              uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EventID         uuid.UUID       `gorm:"type:uuid;not null;index" json:"event_id"`
+	OrganizerID     uuid.UUID       `gorm:"type:uuid;not null" json:"organizer_id"`
+	DryRun          bool            `gorm:"not null;default:false" json:"dry_run"`
+	Status          ImportJobStatus `gorm:"type:varchar(20);not null;default:'PENDING'" json:"status"`
+	TotalRows       int             `gorm:"not null;default:0" json:"total_rows"`
+	ProcessedRows   int             `gorm:"not null;default:0" json:"processed_rows"`
+	BookedCount     int             `gorm:"not null;default:0" json:"booked_count"`
+	WaitlistedCount int             `gorm:"not null;default:0" json:"waitlisted_count"`
+	SkippedCount    int             `gorm:"not null;default:0" json:"skipped_count"`
+	FailedCount     int             `gorm:"not null;default:0" json:"failed_count"`
+	Error           string          `json:"error,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+
+	Results []ImportRowResult `gorm:"foreignKey:JobID" json:"results,omitempty"`
+}
+
+func (j *ImportJob) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return
+}
+
+// ImportRowResult is the per-row entry of an import report.
+type ImportRowResult struct {
+	ID          uuid.UUID        `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	JobID       uuid.UUID        `gorm:"type:uuid;not null;index" json:"job_id"`
+	RowNumber   int              `gorm:"not null" json:"row_number"`
+	Name        string           `json:"name"`
+	Email       string           `json:"email"`
+	UserCreated bool             `json:"user_created"`
+	Outcome     ImportRowOutcome `gorm:"type:varchar(20);not null" json:"outcome"`
+	Message     string           `json:"message,omitempty"`
+}
+
+func (r *ImportRowResult) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
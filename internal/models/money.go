@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+
+// Money is an amount in the currency's minor unit (cents for USD), so prices
+// and totals are never rounded through floating point.
+type Money struct {
+	Amount   int64  `gorm:"not null;default:0" json:"amount"`
+	Currency string `gorm:"type:varchar(3);not null;default:''" json:"currency"`
+}
+
+// minorUnits lists ISO 4217 currencies whose minor unit is not 1/100.
+var minorUnits = map[string]int{
+	"JPY": 0, "KRW": 0, "VND": 0, "CLP": 0, "ISK": 0,
+	"BHD": 3, "KWD": 3, "OMR": 3, "JOD": 3, "TND": 3,
+}
+
+// ValidCurrency reports whether code looks like an ISO 4217 code.
+func ValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Add sums two amounts of the same currency. A zero amount without a
+// currency adds to anything.
+func (m Money) Add(o Money) (Money, error) {
+	switch {
+	case m.Currency == "" && m.Amount == 0:
+		return o, nil
+	case o.Currency == "" && o.Amount == 0:
+		return m, nil
+	case m.Currency != o.Currency:
+		return Money{}, fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Mul(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// String formats the amount in major units, e.g. "12.50 USD".
+func (m Money) String() string {
+	digits, ok := minorUnits[m.Currency]
+	if !ok {
+		digits = 2
+	}
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s := fmt.Sprintf("%0*d", digits+1, amount)
+	if digits > 0 {
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	return strings.TrimSpace(sign + s + " " + m.Currency)
+}
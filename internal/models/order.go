@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OrderStatus string
+
+const (
+	// PENDING holds a seat until the payment provider reports back or the
+	// hold expires.
+	OrderStatusPending  OrderStatus = "PENDING"
+	OrderStatusPaid     OrderStatus = "PAID"
+	OrderStatusFailed   OrderStatus = "FAILED"
+	OrderStatusRefunded OrderStatus = "REFUNDED"
+)
+
+// Order item kinds. A DISCOUNT item carries a negative total.
+const (
+	OrderItemTicket   = "TICKET"
+	OrderItemDiscount = "DISCOUNT"
+)
+
+// Order is a purchase of tickets for a paid event. Its registration is only
+// confirmed once the payment provider reports the payment as successful.
+type Order struct {
+	ID      uuid.UUID   `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID  uuid.UUID   `gorm:"type:uuid;not null;index" json:"user_id"`
+	EventID uuid.UUID   `gorm:"type:uuid;not null;index" json:"event_id"`
+	Status  OrderStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Total   Money       `gorm:"embedded;embeddedPrefix:total_" json:"total"`
+
+	// Refunded sums the order's succeeded refunds. The order becomes
+	// REFUNDED once it covers Total.
+	Refunded Money `gorm:"embedded;embeddedPrefix:refunded_" json:"refunded"`
+
+	PaymentProvider string `gorm:"type:varchar(32);not null" json:"payment_provider"`
+	PaymentRef      string `gorm:"type:varchar(255)" json:"payment_ref,omitempty"`
+	CheckoutURL     string `json:"checkout_url,omitempty"`
+
+	// TicketTypeID is nil for general admission at the event's price.
+	TicketTypeID *uuid.UUID `gorm:"type:uuid" json:"ticket_type_id,omitempty"`
+	PromoCodeID  *uuid.UUID `gorm:"type:uuid" json:"promo_code_id,omitempty"`
+
+	RegistrationID *uuid.UUID `gorm:"type:uuid" json:"registration_id,omitempty"`
+	FailureReason  string     `json:"failure_reason,omitempty"`
+	// ExpiresAt ends the seat hold of a PENDING order.
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	// PaidAt is set once the provider captured the payment, even when the
+	// payment arrived too late to keep the seat.
+	PaidAt *time.Time `json:"paid_at,omitempty"`
+	// CancelledAt is set when the booking a paid order bought is cancelled,
+	// by the attendee or with the event.
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	Items []OrderItem `gorm:"foreignKey:OrderID" json:"items"`
+}
+
+func (o *Order) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return
+}
+
+type OrderItem struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID     uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	Kind        string    `gorm:"type:varchar(20);not null" json:"kind"`
+	Description string    `gorm:"not null" json:"description"`
+	UnitPrice   Money     `gorm:"embedded;embeddedPrefix:unit_price_" json:"unit_price"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	Total       Money     `gorm:"embedded;embeddedPrefix:total_" json:"total"`
+}
+
+func (i *OrderItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,143 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TicketType is a priced tier of a paid event, sold next to the event's
+// general admission price. All tiers share the event's seats. A hidden tier
+// is only offered to buyers holding an access code that unlocks it.
+type TicketType struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EventID     uuid.UUID `gorm:"type:uuid;not null;index" json:"event_id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `json:"description,omitempty"`
+	Price       Money     `gorm:"embedded;embeddedPrefix:price_" json:"price"`
+	Hidden      bool      `gorm:"not null;default:false" json:"hidden"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (t *TicketType) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+type DiscountType string
+
+const (
+	DiscountPercent DiscountType = "PERCENT"
+	DiscountFixed   DiscountType = "FIXED"
+	// DiscountAccess takes nothing off; the code only unlocks hidden tiers.
+	DiscountAccess DiscountType = "ACCESS"
+)
+
+func (d DiscountType) Valid() bool {
+	return d == DiscountPercent || d == DiscountFixed || d == DiscountAccess
+}
+
+// PromoCode discounts orders for one event. UsedCount is only changed under
+// the code's row lock, in the transaction that places or releases an order.
+type PromoCode struct {
+	ID           uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EventID      uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_promo_codes_event_code" json:"event_id"`
+	Code         string       `gorm:"type:varchar(64);not null;uniqueIndex:idx_promo_codes_event_code" json:"code"`
+	DiscountType DiscountType `gorm:"type:varchar(20);not null" json:"discount_type"`
+	PercentOff   int          `gorm:"not null;default:0" json:"percent_off,omitempty"`
+	AmountOff    Money        `gorm:"embedded;embeddedPrefix:amount_off_" json:"amount_off"`
+
+	// Zero means unlimited.
+	MaxUses        int `gorm:"not null;default:0" json:"max_uses"`
+	MaxUsesPerUser int `gorm:"not null;default:0" json:"max_uses_per_user"`
+	UsedCount      int `gorm:"not null;default:0" json:"used_count"`
+
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	Active     bool       `gorm:"not null;default:true" json:"active"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// TicketTypes restricts the code to these tiers; empty means every
+	// ticket, general admission included. Hidden tiers listed here are
+	// unlocked by the code.
+	TicketTypes []TicketType `gorm:"many2many:promo_code_ticket_types" json:"ticket_types,omitempty"`
+}
+
+func (p *PromoCode) BeforeCreate(tx *gorm.DB) (err error) {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return
+}
+
+// NormalizePromoCode is how codes are stored and looked up: trimmed and
+// upper case.
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// OpenAt reports whether the code can be redeemed at t, ignoring usage.
+func (p *PromoCode) OpenAt(t time.Time) bool {
+	if !p.Active {
+		return false
+	}
+	if p.ValidFrom != nil && t.Before(*p.ValidFrom) {
+		return false
+	}
+	return p.ValidUntil == nil || t.Before(*p.ValidUntil)
+}
+
+// AppliesTo reports whether the code covers the tier; nil is general
+// admission.
+func (p *PromoCode) AppliesTo(ticketTypeID *uuid.UUID) bool {
+	if len(p.TicketTypes) == 0 {
+		return true
+	}
+	return ticketTypeID != nil && p.Unlocks(*ticketTypeID)
+}
+
+// Unlocks reports whether the code names the tier explicitly, which is what
+// opens a hidden tier.
+func (p *PromoCode) Unlocks(ticketTypeID uuid.UUID) bool {
+	for _, t := range p.TicketTypes {
+		if t.ID == ticketTypeID {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount returns what the code takes off price, never more than price.
+// Percentages round down to the minor unit.
+func (p *PromoCode) Discount(price Money) Money {
+	off := int64(0)
+	switch p.DiscountType {
+	case DiscountPercent:
+		off = price.Amount * int64(p.PercentOff) / 100
+	case DiscountFixed:
+		off = p.AmountOff.Amount
+	}
+	return Money{Amount: min(off, price.Amount), Currency: price.Currency}
+}
+
+// PromoRedemption records one use of a code by an order. Releasing the
+// order's seat hold deletes it and gives the use back.
+type PromoRedemption struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	PromoCodeID uuid.UUID `gorm:"type:uuid;not null;index" json:"promo_code_id"`
+	OrderID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"order_id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Discount    Money     `gorm:"embedded;embeddedPrefix:discount_" json:"discount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (r *PromoRedemption) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,30 @@
+package models
+
+import "github.com/google/uuid"
+
+// SeatDrift is an event whose denormalized SeatsRemaining counter disagrees
+// with Capacity minus its confirmed registrations and the seats held by
+// pending orders. Read model, not a table.
+type SeatDrift struct {
+	EventID        uuid.UUID `json:"event_id"`
+	Title          string    `json:"title"`
+	Capacity       int       `json:"capacity"`
+	SeatsRemaining int       `json:"seats_remaining"`
+	ConfirmedCount int       `json:"confirmed_count"`
+	HeldCount      int       `json:"held_count"`
+}
+
+// ExpectedSeatsRemaining is the value SeatsRemaining should hold.
+func (d SeatDrift) ExpectedSeatsRemaining() int {
+	return d.Capacity - d.ConfirmedCount - d.HeldCount
+}
+
+// OrphanedWaitlist is a published, upcoming event whose first waitlist entry
+// would fit in the seats confirmed registrations and held seats leave free.
+// Read model.
+type OrphanedWaitlist struct {
+	EventID       uuid.UUID `json:"event_id"`
+	Title         string    `json:"title"`
+	FreeSeats     int       `json:"free_seats"`
+	WaitlistCount int       `json:"waitlist_count"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefundPolicy decides how much of a paid ticket an attendee gets back when
+// they cancel. The zero policy refunds in full until the event starts.
+type RefundPolicy struct {
+	// Full refund while at least this many days remain before the event.
+	FullRefundDays int `gorm:"not null;default:0" json:"full_refund_days"`
+	// Share refunded after the full-refund window, in percent.
+	PartialPercent int `gorm:"not null;default:0" json:"partial_percent"`
+	// No refund at all within this many hours of the event.
+	NoRefundHours int `gorm:"not null;default:0" json:"no_refund_hours"`
+}
+
+func (p RefundPolicy) Valid() bool {
+	return p.FullRefundDays >= 0 && p.NoRefundHours >= 0 && p.PartialPercent >= 0 && p.PartialPercent <= 100
+}
+
+// Quote returns the refund for paid when cancelling at now. Partial amounts
+// round down to the minor unit.
+func (p RefundPolicy) Quote(paid Money, eventDate, now time.Time) Money {
+	left := eventDate.Sub(now)
+	switch {
+	case left < 0 || left < time.Duration(p.NoRefundHours)*time.Hour:
+		return Money{Currency: paid.Currency}
+	case left >= time.Duration(p.FullRefundDays)*24*time.Hour:
+		return paid
+	default:
+		return Money{Amount: paid.Amount * int64(p.PartialPercent) / 100, Currency: paid.Currency}
+	}
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "PENDING"
+	RefundStatusSucceeded RefundStatus = "SUCCEEDED"
+	RefundStatusFailed    RefundStatus = "FAILED"
+)
+
+type RefundReason string
+
+const (
+	RefundReasonAttendeeCancelled RefundReason = "ATTENDEE_CANCELLED"
+	RefundReasonEventCancelled    RefundReason = "EVENT_CANCELLED"
+	RefundReasonLatePayment       RefundReason = "LATE_PAYMENT"
+	RefundReasonAdminOverride     RefundReason = "ADMIN_OVERRIDE"
+)
+
+// Refund is one entry in the refund ledger. It is written as PENDING in the
+// transaction that decides on the refund and sent to the payment provider
+// after commit, so no refund is lost if the provider call fails.
+type Refund struct {
+	ID            uuid.UUID    `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrderID       uuid.UUID    `gorm:"type:uuid;not null;index" json:"order_id"`
+	EventID       uuid.UUID    `gorm:"type:uuid;not null;index" json:"event_id"`
+	UserID        uuid.UUID    `gorm:"type:uuid;not null" json:"user_id"`
+	Amount        Money        `gorm:"embedded;embeddedPrefix:amount_" json:"amount"`
+	Reason        RefundReason `gorm:"type:varchar(32);not null" json:"reason"`
+	Status        RefundStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Note          string       `json:"note,omitempty"`
+	RequestedBy   *uuid.UUID   `gorm:"type:uuid" json:"requested_by,omitempty"`
+	ProviderRef   string       `gorm:"type:varchar(255)" json:"provider_ref,omitempty"`
+	Attempts      int          `gorm:"not null;default:0" json:"attempts"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	ProcessedAt   *time.Time   `json:"processed_at,omitempty"`
+}
+
+func (r *Refund) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
@@ -14,12 +14,26 @@ const (
 	RegistrationStatusCancelled RegistrationStatus = "CANCELLED"
 )
 
+// Registration is one seat. A user has at most one confirmed registration
+// per event of their own; seats of a group booking also belong to the
+// purchaser but carry GroupBookingID and are exempt from that limit. The
+// partial unique index idx_user_event enforcing this is defined in
+// migration 0010_ticket_transfers.
 type Registration struct {
-	ID        uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID          `gorm:"type:uuid;not null;uniqueIndex:idx_user_event" json:"user_id"`
-	EventID   uuid.UUID          `gorm:"type:uuid;not null;uniqueIndex:idx_user_event" json:"event_id"`
-	Status    RegistrationStatus `gorm:"type:varchar(20);not null;default:'CONFIRMED'" json:"status"`
-	CreatedAt time.Time          `json:"created_at"`
+	ID          uuid.UUID          `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID          `gorm:"type:uuid;not null" json:"user_id"`
+	EventID     uuid.UUID          `gorm:"type:uuid;not null" json:"event_id"`
+	Status      RegistrationStatus `gorm:"type:varchar(20);not null;default:'CONFIRMED'" json:"status"`
+	CheckedInAt *time.Time         `json:"checked_in_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+
+	GroupBookingID *uuid.UUID `gorm:"type:uuid;index" json:"group_booking_id,omitempty"`
+	AttendeeName   string     `json:"attendee_name,omitempty"`
+	AttendeeEmail  string     `json:"attendee_email,omitempty"`
+
+	// TicketToken is what the door scans. It changes when the ticket is
+	// transferred, which invalidates the previous holder's copy.
+	TicketToken string `gorm:"type:varchar(32);not null;uniqueIndex" json:"ticket_token"`
 
 	User  User  `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
 	Event Event `gorm:"foreignKey:EventID;references:ID" json:"event,omitempty"`
@@ -29,5 +43,8 @@ func (r *Registration) BeforeCreate(tx *gorm.DB) (err error) {
 	if r.ID == uuid.Nil {
 		r.ID = uuid.New()
 	}
+	if r.TicketToken == "" {
+		r.TicketToken = NewTicketToken()
+	}
 	return
 }
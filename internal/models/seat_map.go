@@ -0,0 +1,113 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SeatMap is an organizer's layout of a venue: sections of rows of numbered
+// seats. Events with reserved seating get their own inventory of its seats.
+type SeatMap struct {
+	ID          uuid.UUID     `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizerID uuid.UUID     `gorm:"type:uuid;not null;index" json:"organizer_id"`
+	Name        string        `gorm:"not null" json:"name"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	Sections    []SeatSection `gorm:"foreignKey:SeatMapID" json:"sections,omitempty"`
+}
+
+func (m *SeatMap) BeforeCreate(tx *gorm.DB) (err error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return
+}
+
+// SeatSection is a block of rows. Position orders sections from best to
+// worst, which is the order best-available fills them in.
+type SeatSection struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SeatMapID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Name      string    `gorm:"not null" json:"name"`
+	Position  int       `gorm:"not null" json:"position"`
+	Rows      []SeatRow `gorm:"foreignKey:SectionID" json:"rows,omitempty"`
+}
+
+func (s *SeatSection) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+// SeatRow holds seats numbered left to right. A gap in the numbers is an
+// aisle: the seats on either side are not adjacent.
+type SeatRow struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	SectionID uuid.UUID `gorm:"type:uuid;not null;index" json:"-"`
+	Label     string    `gorm:"not null" json:"label"`
+	Position  int       `gorm:"not null" json:"position"`
+	Seats     []Seat    `gorm:"foreignKey:RowID" json:"seats,omitempty"`
+}
+
+func (r *SeatRow) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
+
+type Seat struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RowID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_seats_row_number" json:"-"`
+	Number int       `gorm:"not null;uniqueIndex:idx_seats_row_number" json:"number"`
+	// Wheelchair marks a space for a wheelchair user; Companion a seat
+	// kept next to one.
+	Wheelchair bool `gorm:"not null;default:false" json:"wheelchair"`
+	Companion  bool `gorm:"not null;default:false" json:"companion"`
+}
+
+func (s *Seat) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+type EventSeatStatus string
+
+const (
+	EventSeatAvailable EventSeatStatus = "AVAILABLE"
+	EventSeatBooked    EventSeatStatus = "BOOKED"
+)
+
+// EventSeat is one seat of the event's seat map. Booking locks these rows,
+// not the event row, so bookings for different seats do not queue.
+type EventSeat struct {
+	EventID        uuid.UUID       `gorm:"type:uuid;primaryKey" json:"event_id"`
+	SeatID         uuid.UUID       `gorm:"type:uuid;primaryKey" json:"seat_id"`
+	Status         EventSeatStatus `gorm:"type:varchar(20);not null;default:'AVAILABLE'" json:"status"`
+	RegistrationID *uuid.UUID      `gorm:"type:uuid;index" json:"-"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+// EventSeatView is an event seat with its place in the seat map, in the
+// order sections, rows and seat numbers are laid out.
+type EventSeatView struct {
+	SeatID     uuid.UUID       `json:"seat_id"`
+	Section    string          `json:"section"`
+	RowID      uuid.UUID       `json:"-"`
+	Row        string          `gorm:"column:row_label" json:"row"`
+	Number     int             `json:"number"`
+	Wheelchair bool            `json:"wheelchair"`
+	Companion  bool            `json:"companion"`
+	Status     EventSeatStatus `json:"status"`
+}
+
+// Label is how the seat is printed on a ticket, e.g. "F12".
+func (v EventSeatView) Label() string {
+	return v.Row + strconv.Itoa(v.Number)
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SimulationJobStatus string
+
+const (
+	SimulationJobRunning   SimulationJobStatus = "RUNNING"
+	SimulationJobCompleted SimulationJobStatus = "COMPLETED"
+	SimulationJobFailed    SimulationJobStatus = "FAILED"
+)
+
+// SimulationScenario describes the load a simulation puts on one event.
+type SimulationScenario struct {
+	// Synthetic users created for the run; each books once.
+	Users int `json:"users"`
+	// Size of the worker pool issuing requests concurrently.
+	Workers int `json:"workers"`
+	// Share of confirmed users who cancel afterwards, and of those who then
+	// book again.
+	CancelRatio float64 `json:"cancel_ratio"`
+	RebookRatio float64 `json:"rebook_ratio"`
+	// Users arrive in bursts of BurstSize every BurstIntervalMs; a zero
+	// BurstSize releases everyone at once.
+	BurstSize       int `json:"burst_size"`
+	BurstIntervalMs int `json:"burst_interval_ms"`
+	// Allocation strategy forced on the event for the run; empty keeps the
+	// event's own.
+	Strategy AllocationStrategy `json:"strategy,omitempty"`
+	// Seed for the cancel/rebook dice; 0 picks one, reported back in Seed.
+	Seed int64 `json:"seed"`
+}
+
+type LatencySummary struct {
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+type InvariantResult struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type SimulationReport struct {
+	TotalAttempted      int                         `json:"total_attempted"`
+	SuccessCount        int64                       `json:"success_count"`
+	WaitlistedCount     int64                       `json:"waitlisted_count"`
+	FailedCount         int64                       `json:"failed_count"`
+	CancelledCount      int64                       `json:"cancelled_count"`
+	RebookedCount       int64                       `json:"rebooked_count"`
+	FinalSeatsRemaining int                         `json:"final_seats_remaining"`
+	Strategy            AllocationStrategy          `json:"strategy"`
+	DurationMs          int64                       `json:"duration_ms"`
+	Latency             map[string]LatencySummary   `json:"latency"`
+	Errors              map[string]map[string]int64 `json:"errors"`
+	Invariants          []InvariantResult           `json:"invariants"`
+	CleanedUpUsers      int                         `json:"cleaned_up_users"`
+}
+
+// SimulationJob is one asynchronous simulation run and its report.
+type SimulationJob struct {
+	ID          uuid.UUID           `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EventID     uuid.UUID           `gorm:"type:uuid;not null;index" json:"event_id"`
+	RequestedBy *uuid.UUID          `gorm:"type:uuid" json:"requested_by,omitempty"`
+	Status      SimulationJobStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Scenario    SimulationScenario  `gorm:"type:jsonb;serializer:json;not null" json:"scenario"`
+	Report      *SimulationReport   `gorm:"type:jsonb;serializer:json" json:"report,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	CreatedAt   time.Time           `json:"created_at"`
+	FinishedAt  *time.Time          `json:"finished_at,omitempty"`
+}
+
+func (j *SimulationJob) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return
+}
+
+// SeatSnapshot is the seat-related state of one event, read in a single
+// statement so the invariants checked against it are consistent.
+type SeatSnapshot struct {
+	Capacity               int   `json:"capacity"`
+	SeatsRemaining         int   `json:"seats_remaining"`
+	Confirmed              int64 `json:"confirmed"`
+	Held                   int64 `json:"held"`
+	Waitlisted             int64 `json:"waitlisted"`
+	DuplicatePositions     int64 `json:"duplicate_positions"`
+	ConfirmedAndWaitlisted int64 `json:"confirmed_and_waitlisted"`
+}
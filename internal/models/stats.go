@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Read models for the admin dashboard. They are not tables; rows come from
+// aggregate queries and the mv_* materialized views.
+
+type RoleCount struct {
+	Role  Role  `json:"role"`
+	Count int64 `json:"count"`
+}
+
+type EventStatusCount struct {
+	Status EventStatus `json:"status"`
+	Count  int64       `json:"count"`
+}
+
+type DailyBookings struct {
+	Day           time.Time `json:"day"`
+	Bookings      int64     `json:"bookings"`
+	Cancellations int64     `json:"cancellations"`
+}
+
+type EventStats struct {
+	EventID        uuid.UUID   `json:"event_id"`
+	Title          string      `json:"title"`
+	OrganizerID    uuid.UUID   `json:"organizer_id"`
+	Status         EventStatus `json:"status"`
+	Capacity       int         `json:"capacity"`
+	SeatsRemaining int         `json:"seats_remaining"`
+	ConfirmedCount int64       `json:"confirmed_count"`
+	WaitlistCount  int64       `json:"waitlist_count"`
+	FillRate       float64     `json:"fill_rate"`
+}
+
+type OrganizerVolume struct {
+	OrganizerID    uuid.UUID `json:"organizer_id"`
+	Name           string    `json:"name"`
+	EventCount     int64     `json:"event_count"`
+	ConfirmedCount int64     `json:"confirmed_count"`
+	TotalCapacity  int64     `json:"total_capacity"`
+}
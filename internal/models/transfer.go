@@ -0,0 +1,73 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TransferPolicy decides whether attendees may hand their ticket to someone
+// else. The zero policy allows transfers until the event starts.
+type TransferPolicy struct {
+	Disabled bool `gorm:"not null;default:false" json:"disabled"`
+	// Transfers must be accepted before Cutoff, when set.
+	Cutoff *time.Time `json:"cutoff,omitempty"`
+}
+
+// Open reports whether a transfer for an event starting at eventDate may be
+// started or accepted at now.
+func (p TransferPolicy) Open(eventDate, now time.Time) bool {
+	if p.Disabled || !now.Before(eventDate) {
+		return false
+	}
+	return p.Cutoff == nil || now.Before(*p.Cutoff)
+}
+
+type TransferStatus string
+
+const (
+	TransferStatusPending   TransferStatus = "PENDING"
+	TransferStatusAccepted  TransferStatus = "ACCEPTED"
+	TransferStatusDeclined  TransferStatus = "DECLINED"
+	TransferStatusCancelled TransferStatus = "CANCELLED"
+)
+
+// TicketTransfer offers a confirmed registration to whoever signs in with
+// ToEmail. Accepting moves the registration to them and rotates its ticket
+// token, so the sender's copy of the ticket stops working.
+type TicketTransfer struct {
+	ID             uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RegistrationID uuid.UUID      `gorm:"type:uuid;not null;index" json:"registration_id"`
+	EventID        uuid.UUID      `gorm:"type:uuid;not null" json:"event_id"`
+	FromUserID     uuid.UUID      `gorm:"type:uuid;not null;index" json:"from_user_id"`
+	ToEmail        string         `gorm:"not null;index" json:"to_email"`
+	ToUserID       *uuid.UUID     `gorm:"type:uuid" json:"to_user_id,omitempty"`
+	Status         TransferStatus `gorm:"type:varchar(20);not null" json:"status"`
+	CreatedAt      time.Time      `json:"created_at"`
+	RespondedAt    *time.Time     `json:"responded_at,omitempty"`
+
+	Event Event `gorm:"foreignKey:EventID;references:ID" json:"event,omitempty"`
+}
+
+func (t *TicketTransfer) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// NormalizeEmail is how emails are compared: trimmed and lower case.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NewTicketToken returns a random token identifying a ticket at the door.
+func NewTicketToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Venue is a reusable location an organizer holds events at.
+type Venue struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	OrganizerID uuid.UUID `gorm:"type:uuid;not null;index" json:"organizer_id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Address     string    `gorm:"not null" json:"address"`
+	City        string    `json:"city"`
+	Country     string    `json:"country"`
+	// Coordinates are optional, but both or neither are set.
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	// Timezone is an IANA zone name such as "Europe/Lisbon".
+	Timezone      string             `gorm:"not null" json:"timezone"`
+	MaxCapacity   int                `gorm:"not null;check:max_capacity > 0" json:"max_capacity"`
+	Accessibility VenueAccessibility `gorm:"embedded;embeddedPrefix:accessibility_" json:"accessibility"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+func (v *Venue) BeforeCreate(tx *gorm.DB) (err error) {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return
+}
+
+// VenueAccessibility is what attendees with access needs should know.
+type VenueAccessibility struct {
+	StepFree          bool   `gorm:"not null;default:false" json:"step_free"`
+	AccessibleToilets bool   `gorm:"not null;default:false" json:"accessible_toilets"`
+	HearingLoop       bool   `gorm:"not null;default:false" json:"hearing_loop"`
+	Notes             string `json:"notes,omitempty"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WaitingRoom gates bookings for a high-demand event. While enabled, users
+// join the room and are admitted at AdmitPerMinute once the sale starts.
+type WaitingRoom struct {
+	EventID         uuid.UUID  `gorm:"type:uuid;primary_key" json:"event_id"`
+	Enabled         bool       `gorm:"not null;default:false" json:"enabled"`
+	SaleStartsAt    *time.Time `json:"sale_starts_at,omitempty"`
+	AdmitPerMinute  int        `gorm:"not null" json:"admit_per_minute"`
+	LastAdmissionAt *time.Time `json:"-"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Open reports whether the sale has started at t.
+func (r *WaitingRoom) Open(t time.Time) bool {
+	return r.SaleStartsAt == nil || !t.Before(*r.SaleStartsAt)
+}
+
+type WaitingRoomEntry struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	EventID    uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_waiting_room_entries_user_event" json:"event_id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_waiting_room_entries_user_event" json:"user_id"`
+	Rank       int64      `gorm:"not null" json:"-"`
+	JoinedAt   time.Time  `gorm:"not null;default:now()" json:"joined_at"`
+	AdmittedAt *time.Time `json:"admitted_at,omitempty"`
+	// UsedAt is when the admission paid for a booking; each admission books
+	// once.
+	UsedAt *time.Time `json:"used_at,omitempty"`
+}
+
+func (e *WaitingRoomEntry) BeforeCreate(tx *gorm.DB) (err error) {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return
+}
+
+// WaitingRoomStats summarizes a room for its organizer.
+type WaitingRoomStats struct {
+	Waiting  int64 `json:"waiting"`
+	Admitted int64 `json:"admitted"`
+}
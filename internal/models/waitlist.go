@@ -7,6 +7,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// Waitlist is one place in an event's waitlist. A waitlisted group booking
+// holds a single place for all its seats and is promoted only when they all
+// fit.
 type Waitlist struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID    uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_waitlist_user_event" json:"user_id"`
@@ -14,6 +17,9 @@ type Waitlist struct {
 	Position  int       `gorm:"not null" json:"position"`
 	CreatedAt time.Time `json:"created_at"`
 
+	Seats          int        `gorm:"not null;default:1" json:"seats"`
+	GroupBookingID *uuid.UUID `gorm:"type:uuid" json:"group_booking_id,omitempty"`
+
 	User  User  `gorm:"foreignKey:UserID;references:ID" json:"user,omitempty"`
 	Event Event `gorm:"foreignKey:EventID;references:ID" json:"event,omitempty"`
 }
@@ -0,0 +1,99 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const FakeProviderName = "fake"
+
+// Fake webhook statuses.
+const (
+	FakeStatusSucceeded = "succeeded"
+	FakeStatusFailed    = "failed"
+)
+
+// FakeWebhook is the body the fake provider posts to the payment webhook.
+type FakeWebhook struct {
+	PaymentRef    string `json:"payment_ref"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// FakeProvider accepts every payment without moving money. Its webhooks are
+// signed with HMAC-SHA256 over the body, hex encoded; with an empty secret
+// the signature is not checked, which is only acceptable in development.
+type FakeProvider struct {
+	secret []byte
+}
+
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{secret: []byte(webhookSecret)}
+}
+
+func (p *FakeProvider) Name() string { return FakeProviderName }
+
+func (p *FakeProvider) CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error) {
+	ref := "fake_" + uuid.NewString()
+	return &Payment{
+		Ref:         ref,
+		CheckoutURL: "/payments/fake/checkout/" + ref,
+	}, nil
+}
+
+// Refund accepts every refund. Refs derive from the refund ID, so a retried
+// request gets the same ref back.
+func (p *FakeProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	return &RefundResult{Ref: "fake_refund_" + req.RefundID.String()}, nil
+}
+
+func (p *FakeProvider) ParseWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	if len(p.secret) > 0 && !hmac.Equal([]byte(p.Sign(payload)), []byte(signature)) {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidWebhook)
+	}
+
+	var hook FakeWebhook
+	if err := json.Unmarshal(payload, &hook); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+	}
+	if hook.PaymentRef == "" {
+		return nil, fmt.Errorf("%w: payment_ref is required", ErrInvalidWebhook)
+	}
+	switch hook.Status {
+	case FakeStatusSucceeded:
+		return &WebhookEvent{Ref: hook.PaymentRef, Succeeded: true}, nil
+	case FakeStatusFailed:
+		reason := hook.FailureReason
+		if reason == "" {
+			reason = "payment declined"
+		}
+		return &WebhookEvent{Ref: hook.PaymentRef, Reason: reason}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidWebhook, hook.Status)
+	}
+}
+
+// Sign returns the signature header value for payload.
+func (p *FakeProvider) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Complete builds the signed webhook the provider would send once the buyer
+// finishes, or abandons, checkout. It backs the development checkout page.
+func (p *FakeProvider) Complete(ref string, succeeded bool) (payload []byte, signature string) {
+	hook := FakeWebhook{PaymentRef: ref, Status: FakeStatusSucceeded}
+	if !succeeded {
+		hook.Status = FakeStatusFailed
+		hook.FailureReason = "payment declined at checkout"
+	}
+	payload, _ = json.Marshal(hook)
+	return payload, p.Sign(payload)
+}
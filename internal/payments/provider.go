@@ -0,0 +1,60 @@
+package payments
+
+import (
+	"context"
+	"errors"
+
+	"event_registration/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidWebhook is returned for webhook payloads that fail signature
+// verification or cannot be parsed.
+var ErrInvalidWebhook = errors.New("invalid payment webhook")
+
+// PaymentRequest asks the provider to collect Amount for an order.
+type PaymentRequest struct {
+	OrderID     uuid.UUID
+	Amount      models.Money
+	Description string
+}
+
+// Payment is the provider's handle for a requested payment. The buyer
+// completes it at CheckoutURL; the outcome arrives later as a webhook.
+type Payment struct {
+	Ref         string
+	CheckoutURL string
+}
+
+// WebhookEvent is a verified payment outcome reported by the provider.
+type WebhookEvent struct {
+	Ref       string
+	Succeeded bool
+	// Reason is the provider's explanation for a failed payment.
+	Reason string
+}
+
+// RefundRequest asks the provider to return Amount of a captured payment.
+// RefundID identifies the ledger entry; providers use it as the idempotency
+// key, so sending the same request twice refunds once.
+type RefundRequest struct {
+	RefundID   uuid.UUID
+	PaymentRef string
+	Amount     models.Money
+}
+
+// RefundResult is the provider's handle for an accepted refund.
+type RefundResult struct {
+	Ref string
+}
+
+// PaymentProvider is the seam between orders and a payment service.
+// Implementations must be safe for concurrent use.
+type PaymentProvider interface {
+	Name() string
+	CreatePayment(ctx context.Context, req PaymentRequest) (*Payment, error)
+	// ParseWebhook verifies the signature header sent with a webhook and
+	// decodes the payment outcome. Errors wrap ErrInvalidWebhook.
+	ParseWebhook(payload []byte, signature string) (*WebhookEvent, error)
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+}
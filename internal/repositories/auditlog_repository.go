@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	FindByEvent(ctx context.Context, eventID string, actions ...string) ([]models.AuditLog, error)
+	WithTx(tx *gorm.DB) AuditLogRepository
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) WithTx(tx *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: tx}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditLogRepository) FindByEvent(ctx context.Context, eventID string, actions ...string) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	query := r.db.WithContext(ctx).Where("event_id = ?", eventID)
+	if len(actions) > 0 {
+		query = query.Where("action IN ?", actions)
+	}
+	err := query.Order("timestamp asc").Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookingQueueRepository interface {
+	Create(ctx context.Context, req *models.BookingRequest) error
+	FindByID(ctx context.Context, id string) (*models.BookingRequest, error)
+	FindPendingByEventAndUser(ctx context.Context, eventID, userID string) (*models.BookingRequest, error)
+	CountPendingAhead(ctx context.Context, eventID string, ticket int64) (int64, error)
+	EventsWithPending(ctx context.Context) ([]string, error)
+	// ClaimPending locks up to limit pending requests for the event in ticket
+	// order, skipping rows another worker already holds.
+	ClaimPending(ctx context.Context, eventID string, limit int) ([]models.BookingRequest, error)
+	Resolve(ctx context.Context, req *models.BookingRequest) error
+	WithTx(tx *gorm.DB) BookingQueueRepository
+}
+
+type bookingQueueRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingQueueRepository(db *gorm.DB) BookingQueueRepository {
+	return &bookingQueueRepository{db: db}
+}
+
+func (r *bookingQueueRepository) WithTx(tx *gorm.DB) BookingQueueRepository {
+	return &bookingQueueRepository{db: tx}
+}
+
+func (r *bookingQueueRepository) Create(ctx context.Context, req *models.BookingRequest) error {
+	return r.db.WithContext(ctx).Create(req).Error
+}
+
+func (r *bookingQueueRepository) FindByID(ctx context.Context, id string) (*models.BookingRequest, error) {
+	var req models.BookingRequest
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *bookingQueueRepository) FindPendingByEventAndUser(ctx context.Context, eventID, userID string) (*models.BookingRequest, error) {
+	var req models.BookingRequest
+	err := r.db.WithContext(ctx).
+		Where("event_id = ? AND user_id = ? AND status = ?", eventID, userID, models.BookingRequestPending).
+		First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *bookingQueueRepository) CountPendingAhead(ctx context.Context, eventID string, ticket int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.BookingRequest{}).
+		Where("event_id = ? AND status = ? AND ticket < ?", eventID, models.BookingRequestPending, ticket).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *bookingQueueRepository) EventsWithPending(ctx context.Context) ([]string, error) {
+	var eventIDs []string
+	err := r.db.WithContext(ctx).Model(&models.BookingRequest{}).
+		Where("status = ?", models.BookingRequestPending).
+		Distinct().Pluck("event_id", &eventIDs).Error
+	return eventIDs, err
+}
+
+func (r *bookingQueueRepository) ClaimPending(ctx context.Context, eventID string, limit int) ([]models.BookingRequest, error) {
+	var reqs []models.BookingRequest
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("event_id = ? AND status = ?", eventID, models.BookingRequestPending).
+		Order("ticket asc").Limit(limit).
+		Find(&reqs).Error
+	return reqs, err
+}
+
+func (r *bookingQueueRepository) Resolve(ctx context.Context, req *models.BookingRequest) error {
+	return r.db.WithContext(ctx).Model(req).Updates(map[string]any{
+		"status":          req.Status,
+		"registration_id": req.RegistrationID,
+		"waitlist_id":     req.WaitlistID,
+		"failure_reason":  req.FailureReason,
+		"processed_at":    req.ProcessedAt,
+	}).Error
+}
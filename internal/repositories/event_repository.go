@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"math"
+	"time"
 
 	"event_registration/internal/models"
 	"gorm.io/gorm"
@@ -9,10 +11,57 @@ import (
 
 type EventRepository interface {
 	Create(ctx context.Context, event *models.Event) error
-	Update(ctx context.Context, event *models.Event) error
 	FindByID(ctx context.Context, id string) (*models.Event, error)
-	FindAll(ctx context.Context, status models.EventStatus) ([]models.Event, error)
+	FindAll(ctx context.Context, filter EventFilter) ([]models.Event, error)
+	// FindNear returns events whose venue lies within radiusKm of the point,
+	// nearest first, with DistanceKm set.
+	FindNear(ctx context.Context, filter EventFilter, lat, lng, radiusKm float64) ([]models.Event, error)
 	FindByOrganizer(ctx context.Context, organizerID string) ([]models.Event, error)
+	FindAllocationStrategy(ctx context.Context, id string) models.AllocationStrategy
+	// SetAllocationStrategy writes only the strategy override column.
+	SetAllocationStrategy(ctx context.Context, id string, strategy models.AllocationStrategy) error
+	FindAdmissionMode(ctx context.Context, id string) (models.AdmissionMode, error)
+	// FindAtVenue returns the venue's events that are not cancelled and run
+	// at some point between from and to.
+	FindAtVenue(ctx context.Context, venueID string, from, to time.Time) ([]models.Event, error)
+	WithTx(tx *gorm.DB) EventRepository
+}
+
+// EventFilter narrows event listings. A Period keeps events that run at
+// some point of that period, taken in each event's time zone and relative
+// to Now, and have not ended by Now.
+type EventFilter struct {
+	Status models.EventStatus
+	Period models.EventPeriod
+	Now    time.Time
+}
+
+// Local start of the period containing the instant ?, in the event's zone.
+var periodStartSQL = map[models.EventPeriod]string{
+	models.PeriodToday:       "date_trunc('day', ?::timestamptz AT TIME ZONE events.timezone)",
+	models.PeriodThisWeekend: "date_trunc('week', ?::timestamptz AT TIME ZONE events.timezone) + interval '5 days'",
+}
+
+var periodLength = map[models.EventPeriod]string{
+	models.PeriodToday:       "1 day",
+	models.PeriodThisWeekend: "2 days",
+}
+
+func (f EventFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.Status != "" {
+		query = query.Where("events.status = ?", f.Status)
+	}
+	if start, ok := periodStartSQL[f.Period]; ok {
+		// Local midnights are converted back to instants per event, so the
+		// period follows each zone's own calendar and DST changes.
+		query = query.Where(
+			"events.event_date < ("+start+" + interval '"+periodLength[f.Period]+"') AT TIME ZONE events.timezone"+
+				" AND events.ends_at > ("+start+") AT TIME ZONE events.timezone",
+			f.Now, f.Now)
+		// Events of the period that are already over are not listed.
+		query = query.Where("events.ends_at > ?", f.Now)
+	}
+	return query
 }
 
 type eventRepository struct {
@@ -23,35 +72,102 @@ func NewEventRepository(db *gorm.DB) EventRepository {
 	return &eventRepository{db: db}
 }
 
-func (r *eventRepository) Create(ctx context.Context, event *models.Event) error {
-	return r.db.WithContext(ctx).Create(event).Error
+func (r *eventRepository) WithTx(tx *gorm.DB) EventRepository {
+	return &eventRepository{db: tx}
 }
 
-func (r *eventRepository) Update(ctx context.Context, event *models.Event) error {
-	return r.db.WithContext(ctx).Save(event).Error
+func (r *eventRepository) Create(ctx context.Context, event *models.Event) error {
+	return r.db.WithContext(ctx).Create(event).Error
 }
 
 func (r *eventRepository) FindByID(ctx context.Context, id string) (*models.Event, error) {
 	var event models.Event
-	err := r.db.WithContext(ctx).Preload("Organizer").Where("id = ?", id).First(&event).Error
+	err := r.db.WithContext(ctx).Preload("Organizer").Preload("Venue").Where("id = ?", id).First(&event).Error
 	if err != nil {
 		return nil, err
 	}
 	return &event, nil
 }
 
-func (r *eventRepository) FindAll(ctx context.Context, status models.EventStatus) ([]models.Event, error) {
+func (r *eventRepository) FindAll(ctx context.Context, filter EventFilter) ([]models.Event, error) {
 	var events []models.Event
-	query := r.db.WithContext(ctx).Preload("Organizer")
-	if status != "" {
-		query = query.Where("status = ?", status)
-	}
+	query := filter.apply(r.db.WithContext(ctx).Preload("Organizer").Preload("Venue"))
 	err := query.Find(&events).Error
 	return events, err
 }
 
+// Great-circle distance in km from the point (?, ?) to the event's venue.
+const venueDistanceSQL = `6371 * 2 * ASIN(SQRT(LEAST(1,
+	POWER(SIN(RADIANS(venues.latitude - ?) / 2), 2) +
+	COS(RADIANS(?)) * COS(RADIANS(venues.latitude)) * POWER(SIN(RADIANS(venues.longitude - ?) / 2), 2))))`
+
+const kmPerDegree = 6371 * math.Pi / 180
+
+func (r *eventRepository) FindNear(ctx context.Context, filter EventFilter, lat, lng, radiusKm float64) ([]models.Event, error) {
+	query := filter.apply(r.db.WithContext(ctx).Preload("Organizer").Preload("Venue").
+		Select("events.*, "+venueDistanceSQL+" AS distance_km", lat, lat, lng).
+		Joins("JOIN venues ON venues.id = events.venue_id"))
+
+	// A bounding box the index on (latitude, longitude) can serve narrows
+	// the rows before the exact distance is computed.
+	dLat := radiusKm / kmPerDegree
+	query = query.Where("venues.latitude BETWEEN ? AND ?", lat-dLat, lat+dLat)
+	// Near the poles, or across the antimeridian, the longitude range wraps;
+	// latitude alone bounds the search there.
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.01 {
+		dLng := dLat / cos
+		if lng-dLng >= -180 && lng+dLng <= 180 {
+			query = query.Where("venues.longitude BETWEEN ? AND ?", lng-dLng, lng+dLng)
+		}
+	}
+
+	var events []models.Event
+	err := query.Where(venueDistanceSQL+" <= ?", lat, lat, lng, radiusKm).
+		Order("distance_km, events.event_date").Find(&events).Error
+	return events, err
+}
+
 func (r *eventRepository) FindByOrganizer(ctx context.Context, organizerID string) ([]models.Event, error) {
 	var events []models.Event
-	err := r.db.WithContext(ctx).Preload("Organizer").Where("organizer_id = ?", organizerID).Find(&events).Error
+	err := r.db.WithContext(ctx).Preload("Organizer").Preload("Venue").Where("organizer_id = ?", organizerID).Find(&events).Error
+	return events, err
+}
+
+// FindAllocationStrategy returns the event's strategy override, or "" when it
+// has none or does not exist.
+func (r *eventRepository) FindAllocationStrategy(ctx context.Context, id string) models.AllocationStrategy {
+	var strategies []models.AllocationStrategy
+	r.db.WithContext(ctx).Model(&models.Event{}).Where("id = ?", id).Pluck("COALESCE(allocation_strategy, '')", &strategies)
+	if len(strategies) == 0 {
+		return ""
+	}
+	return strategies[0]
+}
+
+func (r *eventRepository) SetAllocationStrategy(ctx context.Context, id string, strategy models.AllocationStrategy) error {
+	result := r.db.WithContext(ctx).Model(&models.Event{}).Where("id = ?", id).Update("allocation_strategy", strategy)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+func (r *eventRepository) FindAdmissionMode(ctx context.Context, id string) (models.AdmissionMode, error) {
+	var modes []models.AdmissionMode
+	err := r.db.WithContext(ctx).Model(&models.Event{}).Where("id = ?", id).Pluck("admission_mode", &modes).Error
+	if err != nil {
+		return "", err
+	}
+	if len(modes) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return modes[0], nil
+}
+
+func (r *eventRepository) FindAtVenue(ctx context.Context, venueID string, from, to time.Time) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.WithContext(ctx).
+		Where("venue_id = ? AND status <> ? AND event_date < ? AND ends_at > ?", venueID, models.EventStatusCancelled, to, from).
+		Order("event_date").Find(&events).Error
 	return events, err
 }
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GroupBookingRepository interface {
+	Create(ctx context.Context, group *models.GroupBooking) error
+	Update(ctx context.Context, group *models.GroupBooking) error
+	// FindByID loads the group with its seats.
+	FindByID(ctx context.Context, id string) (*models.GroupBooking, error)
+	LockByID(ctx context.Context, id string) (*models.GroupBooking, error)
+	FindByUser(ctx context.Context, userID string) ([]models.GroupBooking, error)
+	WithTx(tx *gorm.DB) GroupBookingRepository
+}
+
+type groupBookingRepository struct {
+	db *gorm.DB
+}
+
+func NewGroupBookingRepository(db *gorm.DB) GroupBookingRepository {
+	return &groupBookingRepository{db: db}
+}
+
+func (r *groupBookingRepository) WithTx(tx *gorm.DB) GroupBookingRepository {
+	return &groupBookingRepository{db: tx}
+}
+
+func (r *groupBookingRepository) Create(ctx context.Context, group *models.GroupBooking) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(group).Error
+}
+
+func (r *groupBookingRepository) Update(ctx context.Context, group *models.GroupBooking) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(group).Error
+}
+
+func (r *groupBookingRepository) FindByID(ctx context.Context, id string) (*models.GroupBooking, error) {
+	var group models.GroupBooking
+	err := r.db.WithContext(ctx).Preload("Registrations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).Where("id = ?", id).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *groupBookingRepository) LockByID(ctx context.Context, id string) (*models.GroupBooking, error) {
+	var group models.GroupBooking
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&group).Error
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *groupBookingRepository) FindByUser(ctx context.Context, userID string) ([]models.GroupBooking, error) {
+	var groups []models.GroupBooking
+	err := r.db.WithContext(ctx).Preload("Registrations", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, id")
+	}).Where("user_id = ?", userID).Order("created_at desc").Find(&groups).Error
+	return groups, err
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+)
+
+type ImportRepository interface {
+	Create(ctx context.Context, job *models.ImportJob) error
+	Update(ctx context.Context, job *models.ImportJob) error
+	FindByID(ctx context.Context, id string) (*models.ImportJob, error)
+	AddResults(ctx context.Context, results []models.ImportRowResult) error
+}
+
+type importRepository struct {
+	db *gorm.DB
+}
+
+func NewImportRepository(db *gorm.DB) ImportRepository {
+	return &importRepository{db: db}
+}
+
+func (r *importRepository) Create(ctx context.Context, job *models.ImportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *importRepository) Update(ctx context.Context, job *models.ImportJob) error {
+	return r.db.WithContext(ctx).Omit("Results").Save(job).Error
+}
+
+func (r *importRepository) FindByID(ctx context.Context, id string) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.db.WithContext(ctx).Preload("Results", func(db *gorm.DB) *gorm.DB {
+		return db.Order("row_number asc")
+	}).Where("id = ?", id).First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *importRepository) AddResults(ctx context.Context, results []models.ImportRowResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&results).Error
+}
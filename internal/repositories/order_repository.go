@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrderRepository interface {
+	// Create inserts the order together with its items.
+	Create(ctx context.Context, order *models.Order) error
+	// Update saves the order's own columns; items are immutable.
+	Update(ctx context.Context, order *models.Order) error
+	// SetPayment records the provider's payment handle without touching the
+	// status, which a webhook or the expiry job may already have changed.
+	SetPayment(ctx context.Context, orderID, ref, checkoutURL string) error
+	FindByID(ctx context.Context, id string) (*models.Order, error)
+	FindByUser(ctx context.Context, userID string) ([]models.Order, error)
+	FindPendingByEventAndUser(ctx context.Context, eventID, userID string) (*models.Order, error)
+	// LockByID and LockByPaymentRef take the order row lock (FOR UPDATE).
+	LockByID(ctx context.Context, id string) (*models.Order, error)
+	LockByPaymentRef(ctx context.Context, provider, ref string) (*models.Order, error)
+	// FindExpiredPending returns the IDs of pending orders whose seat hold
+	// ended before t, oldest first.
+	FindExpiredPending(ctx context.Context, t time.Time, limit int) ([]string, error)
+	CountPendingByEvent(ctx context.Context, eventID string) (int64, error)
+	// LockActiveByRegistration locks the paid order behind a registration
+	// whose booking has not been cancelled.
+	LockActiveByRegistration(ctx context.Context, registrationID string) (*models.Order, error)
+	// FindUnsettledCancelled returns the IDs of orders on cancelled events
+	// that still hold a seat: pending ones, and paid ones whose booking was
+	// not cancelled. An empty eventID searches every event.
+	FindUnsettledCancelled(ctx context.Context, eventID string, limit int) ([]string, error)
+	// LockUnsettled locks the event's orders that still hold a seat, skipping
+	// rows another transaction has locked.
+	LockUnsettled(ctx context.Context, eventID string) ([]models.Order, error)
+	WithTx(tx *gorm.DB) OrderRepository
+}
+
+type orderRepository struct {
+	db *gorm.DB
+}
+
+func NewOrderRepository(db *gorm.DB) OrderRepository {
+	return &orderRepository{db: db}
+}
+
+func (r *orderRepository) WithTx(tx *gorm.DB) OrderRepository {
+	return &orderRepository{db: tx}
+}
+
+func (r *orderRepository) Create(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Create(order).Error
+}
+
+func (r *orderRepository) Update(ctx context.Context, order *models.Order) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(order).Error
+}
+
+func (r *orderRepository) SetPayment(ctx context.Context, orderID, ref, checkoutURL string) error {
+	return r.db.WithContext(ctx).Model(&models.Order{}).Where("id = ?", orderID).Updates(map[string]any{
+		"payment_ref":  ref,
+		"checkout_url": checkoutURL,
+	}).Error
+}
+
+func (r *orderRepository) FindByID(ctx context.Context, id string) (*models.Order, error) {
+	var order models.Order
+	if err := r.db.WithContext(ctx).Preload("Items").Where("id = ?", id).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) FindByUser(ctx context.Context, userID string) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).Preload("Items").Where("user_id = ?", userID).Order("created_at desc").Find(&orders).Error
+	return orders, err
+}
+
+func (r *orderRepository) FindPendingByEventAndUser(ctx context.Context, eventID, userID string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).Preload("Items").
+		Where("event_id = ? AND user_id = ? AND status = ?", eventID, userID, models.OrderStatusPending).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) LockByID(ctx context.Context, id string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) LockByPaymentRef(ctx context.Context, provider, ref string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("payment_provider = ? AND payment_ref = ?", provider, ref).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) FindExpiredPending(ctx context.Context, t time.Time, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("status = ? AND expires_at < ?", models.OrderStatusPending, t).
+		Order("expires_at").Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *orderRepository) CountPendingByEvent(ctx context.Context, eventID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Order{}).
+		Where("event_id = ? AND status = ?", eventID, models.OrderStatusPending).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *orderRepository) LockActiveByRegistration(ctx context.Context, registrationID string) (*models.Order, error) {
+	var order models.Order
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("registration_id = ? AND paid_at IS NOT NULL AND cancelled_at IS NULL", registrationID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *orderRepository) FindUnsettledCancelled(ctx context.Context, eventID string, limit int) ([]string, error) {
+	var ids []string
+	query := r.db.WithContext(ctx).Model(&models.Order{}).
+		Joins("JOIN events ON events.id = orders.event_id AND events.status = ?", models.EventStatusCancelled).
+		Where("orders.status = ? OR (orders.status = ? AND orders.cancelled_at IS NULL)", models.OrderStatusPending, models.OrderStatusPaid)
+	if eventID != "" {
+		query = query.Where("orders.event_id = ?", eventID)
+	}
+	err := query.Order("orders.created_at").Limit(limit).Pluck("orders.id", &ids).Error
+	return ids, err
+}
+
+func (r *orderRepository) LockUnsettled(ctx context.Context, eventID string) ([]models.Order, error) {
+	var orders []models.Order
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("event_id = ?", eventID).
+		Where("status = ? OR (status = ? AND cancelled_at IS NULL)", models.OrderStatusPending, models.OrderStatusPaid).
+		Order("id").Find(&orders).Error
+	return orders, err
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PromoRepository interface {
+	// Create inserts the code together with its ticket type restrictions.
+	Create(ctx context.Context, code *models.PromoCode) error
+	// Deactivate writes only the active flag, so it cannot race the
+	// used_count increments of redemptions.
+	Deactivate(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*models.PromoCode, error)
+	FindByCode(ctx context.Context, eventID, code string) (*models.PromoCode, error)
+	FindByEvent(ctx context.Context, eventID string) ([]models.PromoCode, error)
+	// LockByCode and LockByID take the code's row lock (FOR UPDATE).
+	LockByCode(ctx context.Context, eventID, code string) (*models.PromoCode, error)
+	LockByID(ctx context.Context, id string) (*models.PromoCode, error)
+	// AddUses changes used_count by delta; callers hold the row lock.
+	AddUses(ctx context.Context, codeID string, delta int) error
+
+	CreateRedemption(ctx context.Context, redemption *models.PromoRedemption) error
+	FindRedemptionByOrder(ctx context.Context, orderID string) (*models.PromoRedemption, error)
+	DeleteRedemption(ctx context.Context, redemption *models.PromoRedemption) error
+	CountUserRedemptions(ctx context.Context, codeID, userID string) (int64, error)
+	WithTx(tx *gorm.DB) PromoRepository
+}
+
+type promoRepository struct {
+	db *gorm.DB
+}
+
+func NewPromoRepository(db *gorm.DB) PromoRepository {
+	return &promoRepository{db: db}
+}
+
+func (r *promoRepository) WithTx(tx *gorm.DB) PromoRepository {
+	return &promoRepository{db: tx}
+}
+
+func (r *promoRepository) Create(ctx context.Context, code *models.PromoCode) error {
+	// The ticket types exist already; only the join rows are written.
+	return r.db.WithContext(ctx).Omit("TicketTypes.*").Create(code).Error
+}
+
+func (r *promoRepository) Deactivate(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&models.PromoCode{}).Where("id = ?", id).UpdateColumn("active", false).Error
+}
+
+func (r *promoRepository) FindByID(ctx context.Context, id string) (*models.PromoCode, error) {
+	var code models.PromoCode
+	if err := r.db.WithContext(ctx).Preload("TicketTypes").Where("id = ?", id).First(&code).Error; err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *promoRepository) FindByCode(ctx context.Context, eventID, code string) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	err := r.db.WithContext(ctx).Preload("TicketTypes").
+		Where("event_id = ? AND code = ?", eventID, code).
+		First(&promo).Error
+	if err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepository) FindByEvent(ctx context.Context, eventID string) ([]models.PromoCode, error) {
+	var codes []models.PromoCode
+	err := r.db.WithContext(ctx).Preload("TicketTypes").Where("event_id = ?", eventID).Order("created_at").Find(&codes).Error
+	return codes, err
+}
+
+func (r *promoRepository) LockByCode(ctx context.Context, eventID, code string) (*models.PromoCode, error) {
+	return r.lock(ctx, "event_id = ? AND code = ?", eventID, code)
+}
+
+func (r *promoRepository) LockByID(ctx context.Context, id string) (*models.PromoCode, error) {
+	return r.lock(ctx, "id = ?", id)
+}
+
+func (r *promoRepository) lock(ctx context.Context, query string, args ...any) (*models.PromoCode, error) {
+	var promo models.PromoCode
+	db := r.db.WithContext(ctx)
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).First(&promo).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&promo).Association("TicketTypes").Find(&promo.TicketTypes); err != nil {
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (r *promoRepository) AddUses(ctx context.Context, codeID string, delta int) error {
+	return r.db.WithContext(ctx).Model(&models.PromoCode{}).Where("id = ?", codeID).
+		UpdateColumn("used_count", gorm.Expr("used_count + ?", delta)).Error
+}
+
+func (r *promoRepository) CreateRedemption(ctx context.Context, redemption *models.PromoRedemption) error {
+	return r.db.WithContext(ctx).Create(redemption).Error
+}
+
+func (r *promoRepository) FindRedemptionByOrder(ctx context.Context, orderID string) (*models.PromoRedemption, error) {
+	var redemption models.PromoRedemption
+	if err := r.db.WithContext(ctx).Where("order_id = ?", orderID).First(&redemption).Error; err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
+func (r *promoRepository) DeleteRedemption(ctx context.Context, redemption *models.PromoRedemption) error {
+	return r.db.WithContext(ctx).Delete(redemption).Error
+}
+
+func (r *promoRepository) CountUserRedemptions(ctx context.Context, codeID, userID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PromoRedemption{}).
+		Where("promo_code_id = ? AND user_id = ?", codeID, userID).
+		Count(&count).Error
+	return count, err
+}
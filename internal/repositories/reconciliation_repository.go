@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository interface {
+	FindSeatDrift(ctx context.Context) ([]models.SeatDrift, error)
+	FindOrphanedWaitlists(ctx context.Context) ([]models.OrphanedWaitlist, error)
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+func (r *reconciliationRepository) FindSeatDrift(ctx context.Context) ([]models.SeatDrift, error) {
+	var drift []models.SeatDrift
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT e.id AS event_id, e.title, e.capacity, e.seats_remaining,
+		       COALESCE(c.confirmed, 0) AS confirmed_count,
+		       COALESCE(h.held, 0) AS held_count
+		FROM events e
+		LEFT JOIN (SELECT event_id, COUNT(*) AS confirmed FROM registrations WHERE status = ? GROUP BY event_id) c
+		  ON c.event_id = e.id
+		LEFT JOIN (SELECT event_id, COUNT(*) AS held FROM orders WHERE status = ? GROUP BY event_id) h
+		  ON h.event_id = e.id
+		WHERE e.seats_remaining <> e.capacity - COALESCE(c.confirmed, 0) - COALESCE(h.held, 0)
+		ORDER BY e.title`, models.RegistrationStatusConfirmed, models.OrderStatusPending).
+		Scan(&drift).Error
+	return drift, err
+}
+
+func (r *reconciliationRepository) FindOrphanedWaitlists(ctx context.Context) ([]models.OrphanedWaitlist, error) {
+	var orphans []models.OrphanedWaitlist
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT e.id AS event_id, e.title,
+		       e.capacity - COALESCE(c.confirmed, 0) - COALESCE(h.held, 0) AS free_seats,
+		       w.waitlist_count
+		FROM events e
+		JOIN (SELECT event_id, COUNT(*) AS waitlist_count FROM waitlists GROUP BY event_id) w
+		  ON w.event_id = e.id
+		JOIN (SELECT DISTINCT ON (event_id) event_id, seats FROM waitlists ORDER BY event_id, position) head
+		  ON head.event_id = e.id
+		LEFT JOIN (SELECT event_id, COUNT(*) AS confirmed FROM registrations WHERE status = ? GROUP BY event_id) c
+		  ON c.event_id = e.id
+		LEFT JOIN (SELECT event_id, COUNT(*) AS held FROM orders WHERE status = ? GROUP BY event_id) h
+		  ON h.event_id = e.id
+		WHERE e.status = ? AND e.event_date > now()
+		  AND e.capacity - COALESCE(c.confirmed, 0) - COALESCE(h.held, 0) >= head.seats
+		ORDER BY e.title`, models.RegistrationStatusConfirmed, models.OrderStatusPending, models.EventStatusPublished).
+		Scan(&orphans).Error
+	return orphans, err
+}
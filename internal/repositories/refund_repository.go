@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RefundFilter narrows a refund listing; empty fields match everything.
+type RefundFilter struct {
+	OrderID string
+	EventID string
+	Status  models.RefundStatus
+}
+
+type RefundRepository interface {
+	Create(ctx context.Context, refund *models.Refund) error
+	Update(ctx context.Context, refund *models.Refund) error
+	FindByID(ctx context.Context, id string) (*models.Refund, error)
+	// LockByID takes the refund row lock (FOR UPDATE).
+	LockByID(ctx context.Context, id string) (*models.Refund, error)
+	List(ctx context.Context, filter RefundFilter, limit int) ([]models.Refund, error)
+	// CommittedAmount sums the order's refunds that have not failed, i.e.
+	// money already returned or on its way back.
+	CommittedAmount(ctx context.Context, orderID string) (int64, error)
+	// FindPendingIDs returns the IDs of pending refunds, oldest first.
+	FindPendingIDs(ctx context.Context, limit int) ([]string, error)
+	WithTx(tx *gorm.DB) RefundRepository
+}
+
+type refundRepository struct {
+	db *gorm.DB
+}
+
+func NewRefundRepository(db *gorm.DB) RefundRepository {
+	return &refundRepository{db: db}
+}
+
+func (r *refundRepository) WithTx(tx *gorm.DB) RefundRepository {
+	return &refundRepository{db: tx}
+}
+
+func (r *refundRepository) Create(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Create(refund).Error
+}
+
+func (r *refundRepository) Update(ctx context.Context, refund *models.Refund) error {
+	return r.db.WithContext(ctx).Save(refund).Error
+}
+
+func (r *refundRepository) FindByID(ctx context.Context, id string) (*models.Refund, error) {
+	var refund models.Refund
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&refund).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) LockByID(ctx context.Context, id string) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&refund).Error
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) List(ctx context.Context, filter RefundFilter, limit int) ([]models.Refund, error) {
+	var refunds []models.Refund
+	query := r.db.WithContext(ctx)
+	if filter.OrderID != "" {
+		query = query.Where("order_id = ?", filter.OrderID)
+	}
+	if filter.EventID != "" {
+		query = query.Where("event_id = ?", filter.EventID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	err := query.Order("created_at desc").Limit(limit).Find(&refunds).Error
+	return refunds, err
+}
+
+func (r *refundRepository) CommittedAmount(ctx context.Context, orderID string) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&models.Refund{}).
+		Where("order_id = ? AND status <> ?", orderID, models.RefundStatusFailed).
+		Select("COALESCE(SUM(amount_amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *refundRepository) FindPendingIDs(ctx context.Context, limit int) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&models.Refund{}).
+		Where("status = ?", models.RefundStatusPending).
+		Order("created_at").Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
@@ -9,6 +9,8 @@ import (
 
 type RegistrationRepository interface {
 	Create(ctx context.Context, registration *models.Registration) error
+	// FindByEventAndUser returns the user's own registration, the confirmed
+	// one if any, not seats they booked for a group.
 	FindByEventAndUser(ctx context.Context, eventID, userID string) (*models.Registration, error)
 	FindByEvent(ctx context.Context, eventID string) ([]models.Registration, error)
 	FindByUser(ctx context.Context, userID string) ([]models.Registration, error)
@@ -36,7 +38,8 @@ func (r *registrationRepository) Create(ctx context.Context, registration *model
 
 func (r *registrationRepository) FindByEventAndUser(ctx context.Context, eventID, userID string) (*models.Registration, error) {
 	var registration models.Registration
-	err := r.db.WithContext(ctx).Where("event_id = ? AND user_id = ?", eventID, userID).First(&registration).Error
+	err := r.db.WithContext(ctx).Where("event_id = ? AND user_id = ? AND group_booking_id IS NULL", eventID, userID).
+		Order("status = 'CONFIRMED' DESC").First(&registration).Error
 	if err != nil {
 		return nil, err
 	}
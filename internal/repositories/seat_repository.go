@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const seatMapBatchSize = 1000
+
+type SeatMapRepository interface {
+	// Create inserts the map with its sections, rows and seats.
+	Create(ctx context.Context, seatMap *models.SeatMap) error
+	// FindByID loads the whole layout in seating order.
+	FindByID(ctx context.Context, id string) (*models.SeatMap, error)
+	FindByOrganizer(ctx context.Context, organizerID string) ([]models.SeatMap, error)
+}
+
+type seatMapRepository struct {
+	db *gorm.DB
+}
+
+func NewSeatMapRepository(db *gorm.DB) SeatMapRepository {
+	return &seatMapRepository{db: db}
+}
+
+func (r *seatMapRepository) Create(ctx context.Context, seatMap *models.SeatMap) error {
+	// Batched so a large hall stays under the bind parameter limit.
+	return r.db.WithContext(ctx).Session(&gorm.Session{CreateBatchSize: seatMapBatchSize}).Create(seatMap).Error
+}
+
+func (r *seatMapRepository) FindByID(ctx context.Context, id string) (*models.SeatMap, error) {
+	var seatMap models.SeatMap
+	err := r.db.WithContext(ctx).
+		Preload("Sections", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Sections.Rows", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("Sections.Rows.Seats", func(db *gorm.DB) *gorm.DB { return db.Order("number") }).
+		Where("id = ?", id).First(&seatMap).Error
+	if err != nil {
+		return nil, err
+	}
+	return &seatMap, nil
+}
+
+func (r *seatMapRepository) FindByOrganizer(ctx context.Context, organizerID string) ([]models.SeatMap, error) {
+	var seatMaps []models.SeatMap
+	err := r.db.WithContext(ctx).Where("organizer_id = ?", organizerID).Order("created_at desc").Find(&seatMaps).Error
+	return seatMaps, err
+}
+
+type EventSeatRepository interface {
+	// CreateForEvent replaces the event's inventory with one available seat
+	// per seat of the map and returns how many there are.
+	CreateForEvent(ctx context.Context, eventID, seatMapID string) (int64, error)
+	// ListByEvent returns the event's seats in seating order.
+	ListByEvent(ctx context.Context, eventID string) ([]models.EventSeatView, error)
+	// LockSeats locks the given seats of the event in seat ID order, so
+	// bookings asking for overlapping seats cannot deadlock.
+	LockSeats(ctx context.Context, eventID string, seatIDs []uuid.UUID) ([]models.EventSeat, error)
+	Book(ctx context.Context, eventID string, seatID, registrationID uuid.UUID) error
+	WithTx(tx *gorm.DB) EventSeatRepository
+}
+
+type eventSeatRepository struct {
+	db *gorm.DB
+}
+
+func NewEventSeatRepository(db *gorm.DB) EventSeatRepository {
+	return &eventSeatRepository{db: db}
+}
+
+func (r *eventSeatRepository) WithTx(tx *gorm.DB) EventSeatRepository {
+	return &eventSeatRepository{db: tx}
+}
+
+func (r *eventSeatRepository) CreateForEvent(ctx context.Context, eventID, seatMapID string) (int64, error) {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("event_id = ?", eventID).Delete(&models.EventSeat{}).Error; err != nil {
+		return 0, err
+	}
+	result := db.Exec(`
+		INSERT INTO event_seats (event_id, seat_id, status, updated_at)
+		SELECT ?, s.id, ?, NOW()
+		FROM seats s
+		JOIN seat_rows r ON r.id = s.row_id
+		JOIN seat_sections sec ON sec.id = r.section_id
+		WHERE sec.seat_map_id = ?`,
+		eventID, models.EventSeatAvailable, seatMapID)
+	return result.RowsAffected, result.Error
+}
+
+func (r *eventSeatRepository) ListByEvent(ctx context.Context, eventID string) ([]models.EventSeatView, error) {
+	var seats []models.EventSeatView
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT es.seat_id, sec.name AS section, r.id AS row_id, r.label AS row_label, s.number, s.wheelchair, s.companion, es.status
+		FROM event_seats es
+		JOIN seats s ON s.id = es.seat_id
+		JOIN seat_rows r ON r.id = s.row_id
+		JOIN seat_sections sec ON sec.id = r.section_id
+		WHERE es.event_id = ?
+		ORDER BY sec.position, r.position, s.number`, eventID).
+		Scan(&seats).Error
+	return seats, err
+}
+
+func (r *eventSeatRepository) LockSeats(ctx context.Context, eventID string, seatIDs []uuid.UUID) ([]models.EventSeat, error) {
+	var seats []models.EventSeat
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ? AND seat_id IN ?", eventID, seatIDs).
+		Order("seat_id").Find(&seats).Error
+	return seats, err
+}
+
+func (r *eventSeatRepository) Book(ctx context.Context, eventID string, seatID, registrationID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&models.EventSeat{}).
+		Where("event_id = ? AND seat_id = ?", eventID, seatID).
+		Updates(map[string]any{
+			"status":          models.EventSeatBooked,
+			"registration_id": registrationID,
+		}).Error
+}
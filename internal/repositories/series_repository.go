@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SeriesRepository interface {
+	Create(ctx context.Context, series *models.EventSeries) error
+	Update(ctx context.Context, series *models.EventSeries) error
+	// FindByID loads the series with its sessions in date order, only those
+	// with the given status unless it is empty.
+	FindByID(ctx context.Context, id string, status models.EventStatus) (*models.EventSeries, error)
+	LockByID(ctx context.Context, id string) (*models.EventSeries, error)
+	FindByOrganizer(ctx context.Context, organizerID string) ([]models.EventSeries, error)
+	// FindUpcoming returns the series' sessions in one of the statuses that
+	// start after now, in date order.
+	FindUpcoming(ctx context.Context, id string, now time.Time, statuses ...models.EventStatus) ([]models.Event, error)
+	WithTx(tx *gorm.DB) SeriesRepository
+}
+
+type seriesRepository struct {
+	db *gorm.DB
+}
+
+func NewSeriesRepository(db *gorm.DB) SeriesRepository {
+	return &seriesRepository{db: db}
+}
+
+func (r *seriesRepository) WithTx(tx *gorm.DB) SeriesRepository {
+	return &seriesRepository{db: tx}
+}
+
+// Sessions are written through the event repository.
+func (r *seriesRepository) Create(ctx context.Context, series *models.EventSeries) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(series).Error
+}
+
+func (r *seriesRepository) Update(ctx context.Context, series *models.EventSeries) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(series).Error
+}
+
+func (r *seriesRepository) FindByID(ctx context.Context, id string, status models.EventStatus) (*models.EventSeries, error) {
+	var series models.EventSeries
+	err := r.db.WithContext(ctx).
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			if status != "" {
+				db = db.Where("status = ?", status)
+			}
+			return db.Order("event_date")
+		}).
+		Where("id = ?", id).First(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) LockByID(ctx context.Context, id string) (*models.EventSeries, error) {
+	var series models.EventSeries
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) FindByOrganizer(ctx context.Context, organizerID string) ([]models.EventSeries, error) {
+	var series []models.EventSeries
+	err := r.db.WithContext(ctx).Where("organizer_id = ?", organizerID).Order("starts_at desc").Find(&series).Error
+	return series, err
+}
+
+func (r *seriesRepository) FindUpcoming(ctx context.Context, id string, now time.Time, statuses ...models.EventStatus) ([]models.Event, error) {
+	var events []models.Event
+	err := r.db.WithContext(ctx).
+		Where("series_id = ? AND event_date > ? AND status IN ?", id, now, statuses).
+		Order("event_date").Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const simulationUserBatchSize = 500
+
+type SimulationRepository interface {
+	Create(ctx context.Context, job *models.SimulationJob) error
+	Update(ctx context.Context, job *models.SimulationJob) error
+	FindByID(ctx context.Context, id string) (*models.SimulationJob, error)
+	CreateUsers(ctx context.Context, users []models.User) error
+	SeatSnapshot(ctx context.Context, eventID string) (*models.SeatSnapshot, error)
+	// DeleteUsers removes synthetic users together with every row that
+	// references them. It does not touch seat counters; callers reconcile.
+	DeleteUsers(ctx context.Context, userIDs []uuid.UUID) error
+}
+
+type simulationRepository struct {
+	db *gorm.DB
+}
+
+func NewSimulationRepository(db *gorm.DB) SimulationRepository {
+	return &simulationRepository{db: db}
+}
+
+func (r *simulationRepository) Create(ctx context.Context, job *models.SimulationJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *simulationRepository) Update(ctx context.Context, job *models.SimulationJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+func (r *simulationRepository) FindByID(ctx context.Context, id string) (*models.SimulationJob, error) {
+	var job models.SimulationJob
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *simulationRepository) CreateUsers(ctx context.Context, users []models.User) error {
+	return r.db.WithContext(ctx).CreateInBatches(users, simulationUserBatchSize).Error
+}
+
+func (r *simulationRepository) SeatSnapshot(ctx context.Context, eventID string) (*models.SeatSnapshot, error) {
+	var snapshot models.SeatSnapshot
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT e.capacity, e.seats_remaining,
+		       (SELECT COUNT(*) FROM registrations r
+		         WHERE r.event_id = e.id AND r.status = @confirmed) AS confirmed,
+		       (SELECT COUNT(*) FROM orders o
+		         WHERE o.event_id = e.id AND o.status = @pending) AS held,
+		       (SELECT COUNT(*) FROM waitlists w WHERE w.event_id = e.id) AS waitlisted,
+		       (SELECT COUNT(*) - COUNT(DISTINCT w.position) FROM waitlists w
+		         WHERE w.event_id = e.id) AS duplicate_positions,
+		       (SELECT COUNT(*) FROM waitlists w
+		          JOIN registrations r ON r.event_id = w.event_id AND r.user_id = w.user_id AND r.status = @confirmed
+		         WHERE w.event_id = e.id) AS confirmed_and_waitlisted
+		FROM events e
+		WHERE e.id = @event`,
+		map[string]any{
+			"confirmed": models.RegistrationStatusConfirmed,
+			"pending":   models.OrderStatusPending,
+			"event":     eventID,
+		}).
+		Scan(&snapshot).Error
+	return &snapshot, err
+}
+
+func (r *simulationRepository) DeleteUsers(ctx context.Context, userIDs []uuid.UUID) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("order_id IN (?)", tx.Model(&models.Order{}).Select("id").Where("user_id IN ?", userIDs)).
+			Delete(&models.OrderItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.EventSeat{}).
+			Where("registration_id IN (?)", tx.Model(&models.Registration{}).Select("id").Where("user_id IN ?", userIDs)).
+			Updates(map[string]any{"status": models.EventSeatAvailable, "registration_id": nil}).Error; err != nil {
+			return err
+		}
+		dependents := []struct {
+			model  any
+			column string
+		}{
+			{&models.AuditLog{}, "actor_id"},
+			{&models.Refund{}, "user_id"},
+			{&models.PromoRedemption{}, "user_id"},
+			{&models.Order{}, "user_id"},
+			{&models.BookingRequest{}, "user_id"},
+			{&models.WaitingRoomEntry{}, "user_id"},
+			{&models.Waitlist{}, "user_id"},
+			{&models.TicketTransfer{}, "from_user_id"},
+			{&models.TicketTransfer{}, "to_user_id"},
+			{&models.Registration{}, "user_id"},
+			{&models.GroupBooking{}, "user_id"},
+		}
+		for _, d := range dependents {
+			if err := tx.Where(d.column+" IN ?", userIDs).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("id IN ?", userIDs).Delete(&models.User{}).Error
+	})
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+)
+
+// Arbitrary key for pg_try_advisory_xact_lock so only one replica refreshes
+// the dashboard views at a time.
+const statsRefreshLockKey = 720028
+
+type StatsRepository interface {
+	CountActiveUsersByRole(ctx context.Context) ([]models.RoleCount, error)
+	CountEventsByStatus(ctx context.Context) ([]models.EventStatusCount, error)
+	DailyBookingsSince(ctx context.Context, since time.Time) ([]models.DailyBookings, error)
+	TopEventsByFillRate(ctx context.Context, limit int) ([]models.EventStats, error)
+	TopOrganizersByVolume(ctx context.Context, limit int) ([]models.OrganizerVolume, error)
+	EventsWithWaitlistPressure(ctx context.Context, limit int) ([]models.EventStats, error)
+	RefreshViews(ctx context.Context) (bool, error)
+}
+
+type statsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) StatsRepository {
+	return &statsRepository{db: db}
+}
+
+func (r *statsRepository) CountActiveUsersByRole(ctx context.Context) ([]models.RoleCount, error) {
+	var counts []models.RoleCount
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Select("role, COUNT(*) AS count").
+		Where("is_active = ?", true).
+		Group("role").Order("role").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *statsRepository) CountEventsByStatus(ctx context.Context) ([]models.EventStatusCount, error) {
+	var counts []models.EventStatusCount
+	err := r.db.WithContext(ctx).Model(&models.Event{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").Order("status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *statsRepository) DailyBookingsSince(ctx context.Context, since time.Time) ([]models.DailyBookings, error) {
+	var days []models.DailyBookings
+	err := r.db.WithContext(ctx).Table("mv_daily_bookings").
+		Where("day >= ?", since).
+		Order("day asc").
+		Scan(&days).Error
+	return days, err
+}
+
+func (r *statsRepository) TopEventsByFillRate(ctx context.Context, limit int) ([]models.EventStats, error) {
+	var events []models.EventStats
+	err := r.db.WithContext(ctx).Table("mv_event_stats").
+		Where("status = ?", models.EventStatusPublished).
+		Order("fill_rate desc, confirmed_count desc").
+		Limit(limit).
+		Scan(&events).Error
+	return events, err
+}
+
+func (r *statsRepository) TopOrganizersByVolume(ctx context.Context, limit int) ([]models.OrganizerVolume, error) {
+	var organizers []models.OrganizerVolume
+	err := r.db.WithContext(ctx).Table("mv_event_stats AS s").
+		Select("s.organizer_id, u.name, COUNT(*) AS event_count, SUM(s.confirmed_count)::bigint AS confirmed_count, SUM(s.capacity)::bigint AS total_capacity").
+		Joins("JOIN users u ON u.id = s.organizer_id").
+		Group("s.organizer_id, u.name").
+		Order("confirmed_count desc").
+		Limit(limit).
+		Scan(&organizers).Error
+	return organizers, err
+}
+
+func (r *statsRepository) EventsWithWaitlistPressure(ctx context.Context, limit int) ([]models.EventStats, error) {
+	var events []models.EventStats
+	err := r.db.WithContext(ctx).Table("mv_event_stats").
+		Where("status = ? AND waitlist_count > capacity", models.EventStatusPublished).
+		Order("waitlist_count desc").
+		Limit(limit).
+		Scan(&events).Error
+	return events, err
+}
+
+// RefreshViews rebuilds the dashboard views without blocking readers. It
+// reports false when another instance already holds the refresh lock.
+func (r *statsRepository) RefreshViews(ctx context.Context) (bool, error) {
+	refreshed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", statsRefreshLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		for _, view := range []string{"mv_daily_bookings", "mv_event_stats"} {
+			if err := tx.Exec("REFRESH MATERIALIZED VIEW CONCURRENTLY " + view).Error; err != nil {
+				return err
+			}
+		}
+		refreshed = true
+		return nil
+	})
+	return refreshed, err
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+)
+
+type TicketTypeRepository interface {
+	Create(ctx context.Context, ticketType *models.TicketType) error
+	FindByID(ctx context.Context, id string) (*models.TicketType, error)
+	FindByEvent(ctx context.Context, eventID string) ([]models.TicketType, error)
+	WithTx(tx *gorm.DB) TicketTypeRepository
+}
+
+type ticketTypeRepository struct {
+	db *gorm.DB
+}
+
+func NewTicketTypeRepository(db *gorm.DB) TicketTypeRepository {
+	return &ticketTypeRepository{db: db}
+}
+
+func (r *ticketTypeRepository) WithTx(tx *gorm.DB) TicketTypeRepository {
+	return &ticketTypeRepository{db: tx}
+}
+
+func (r *ticketTypeRepository) Create(ctx context.Context, ticketType *models.TicketType) error {
+	return r.db.WithContext(ctx).Create(ticketType).Error
+}
+
+func (r *ticketTypeRepository) FindByID(ctx context.Context, id string) (*models.TicketType, error) {
+	var ticketType models.TicketType
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&ticketType).Error; err != nil {
+		return nil, err
+	}
+	return &ticketType, nil
+}
+
+func (r *ticketTypeRepository) FindByEvent(ctx context.Context, eventID string) ([]models.TicketType, error) {
+	var ticketTypes []models.TicketType
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).Order("price_amount, name").Find(&ticketTypes).Error
+	return ticketTypes, err
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransferRepository interface {
+	Create(ctx context.Context, transfer *models.TicketTransfer) error
+	Update(ctx context.Context, transfer *models.TicketTransfer) error
+	FindByID(ctx context.Context, id string) (*models.TicketTransfer, error)
+	LockByID(ctx context.Context, id string) (*models.TicketTransfer, error)
+	// ListForUser returns the transfers the user sent or was offered,
+	// newest first.
+	ListForUser(ctx context.Context, userID, email string) ([]models.TicketTransfer, error)
+	WithTx(tx *gorm.DB) TransferRepository
+}
+
+type transferRepository struct {
+	db *gorm.DB
+}
+
+func NewTransferRepository(db *gorm.DB) TransferRepository {
+	return &transferRepository{db: db}
+}
+
+func (r *transferRepository) WithTx(tx *gorm.DB) TransferRepository {
+	return &transferRepository{db: tx}
+}
+
+func (r *transferRepository) Create(ctx context.Context, transfer *models.TicketTransfer) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(transfer).Error
+}
+
+func (r *transferRepository) Update(ctx context.Context, transfer *models.TicketTransfer) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(transfer).Error
+}
+
+func (r *transferRepository) FindByID(ctx context.Context, id string) (*models.TicketTransfer, error) {
+	var transfer models.TicketTransfer
+	if err := r.db.WithContext(ctx).Preload("Event").Where("id = ?", id).First(&transfer).Error; err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *transferRepository) LockByID(ctx context.Context, id string) (*models.TicketTransfer, error) {
+	var transfer models.TicketTransfer
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&transfer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+func (r *transferRepository) ListForUser(ctx context.Context, userID, email string) ([]models.TicketTransfer, error) {
+	var transfers []models.TicketTransfer
+	err := r.db.WithContext(ctx).Preload("Event").
+		Where("from_user_id = ? OR to_email = ?", userID, email).
+		Order("created_at desc").Find(&transfers).Error
+	return transfers, err
+}
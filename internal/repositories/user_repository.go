@@ -11,6 +11,7 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id string) (*models.User, error)
+	UpdateRole(ctx context.Context, id string, role models.Role) error
 }
 
 type userRepository struct {
@@ -42,3 +43,7 @@ func (r *userRepository) FindByID(ctx context.Context, id string) (*models.User,
 	}
 	return &user, nil
 }
+
+func (r *userRepository) UpdateRole(ctx context.Context, id string, role models.Role) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Update("role", role).Error
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VenueRepository interface {
+	Create(ctx context.Context, venue *models.Venue) error
+	Update(ctx context.Context, venue *models.Venue) error
+	Delete(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*models.Venue, error)
+	// LockByID locks the venue row; scheduling an event there holds it so
+	// two events cannot claim the same slot at once.
+	LockByID(ctx context.Context, id string) (*models.Venue, error)
+	FindByOrganizer(ctx context.Context, organizerID string) ([]models.Venue, error)
+	// CountEvents counts every event at the venue, cancelled or not.
+	CountEvents(ctx context.Context, id string) (int64, error)
+	// LargestEventCapacity is the largest capacity among the venue's events
+	// that are not cancelled, or 0.
+	LargestEventCapacity(ctx context.Context, id string) (int, error)
+	// LockEvents locks the venue's events that are not cancelled, in ID
+	// order, so a venue update takes event rows before the venue row like
+	// event updates do.
+	LockEvents(ctx context.Context, id string) error
+	// SyncEvents copies the venue's name to its events that are not
+	// cancelled and to its series, and its timezone to those still using
+	// oldTimezone, the venue's zone before the update.
+	SyncEvents(ctx context.Context, venue *models.Venue, oldTimezone string) error
+	WithTx(tx *gorm.DB) VenueRepository
+}
+
+type venueRepository struct {
+	db *gorm.DB
+}
+
+func NewVenueRepository(db *gorm.DB) VenueRepository {
+	return &venueRepository{db: db}
+}
+
+func (r *venueRepository) WithTx(tx *gorm.DB) VenueRepository {
+	return &venueRepository{db: tx}
+}
+
+func (r *venueRepository) Create(ctx context.Context, venue *models.Venue) error {
+	return r.db.WithContext(ctx).Create(venue).Error
+}
+
+func (r *venueRepository) Update(ctx context.Context, venue *models.Venue) error {
+	return r.db.WithContext(ctx).Save(venue).Error
+}
+
+func (r *venueRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&models.Venue{}).Error
+}
+
+func (r *venueRepository) FindByID(ctx context.Context, id string) (*models.Venue, error) {
+	var venue models.Venue
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&venue).Error; err != nil {
+		return nil, err
+	}
+	return &venue, nil
+}
+
+func (r *venueRepository) LockByID(ctx context.Context, id string) (*models.Venue, error) {
+	var venue models.Venue
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&venue).Error
+	if err != nil {
+		return nil, err
+	}
+	return &venue, nil
+}
+
+func (r *venueRepository) FindByOrganizer(ctx context.Context, organizerID string) ([]models.Venue, error) {
+	var venues []models.Venue
+	err := r.db.WithContext(ctx).Where("organizer_id = ?", organizerID).Order("name").Find(&venues).Error
+	return venues, err
+}
+
+func (r *venueRepository) CountEvents(ctx context.Context, id string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Event{}).Where("venue_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *venueRepository) LargestEventCapacity(ctx context.Context, id string) (int, error) {
+	var largest int
+	err := r.db.WithContext(ctx).Model(&models.Event{}).
+		Select("COALESCE(MAX(capacity), 0)").
+		Where("venue_id = ? AND status <> ?", id, models.EventStatusCancelled).
+		Scan(&largest).Error
+	return largest, err
+}
+
+func (r *venueRepository) LockEvents(ctx context.Context, id string) error {
+	var events []models.Event
+	return r.db.WithContext(ctx).Select("id").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("venue_id = ? AND status <> ?", id, models.EventStatusCancelled).
+		Order("id").
+		Find(&events).Error
+}
+
+func (r *venueRepository) SyncEvents(ctx context.Context, venue *models.Venue, oldTimezone string) error {
+	db := r.db.WithContext(ctx)
+	events := db.Model(&models.Event{}).Where("venue_id = ? AND status <> ?", venue.ID, models.EventStatusCancelled).Session(&gorm.Session{})
+	if err := events.Update("location", venue.Name).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&models.EventSeries{}).Where("venue_id = ?", venue.ID).Update("location", venue.Name).Error; err != nil {
+		return err
+	}
+	if oldTimezone == venue.Timezone {
+		return nil
+	}
+	if err := events.Where("timezone = ?", oldTimezone).Update("timezone", venue.Timezone).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.EventSeries{}).Where("venue_id = ? AND timezone = ?", venue.ID, oldTimezone).Update("timezone", venue.Timezone).Error
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WaitingRoomRepository interface {
+	FindRoom(ctx context.Context, eventID string) (*models.WaitingRoom, error)
+	// LockRoom loads the room with SELECT ... FOR UPDATE; use inside a transaction.
+	LockRoom(ctx context.Context, eventID string) (*models.WaitingRoom, error)
+	SaveRoom(ctx context.Context, room *models.WaitingRoom) error
+	FindOpenRooms(ctx context.Context, now time.Time) ([]string, error)
+	FindEntry(ctx context.Context, eventID, userID string) (*models.WaitingRoomEntry, error)
+	CreateEntry(ctx context.Context, entry *models.WaitingRoomEntry) error
+	// Requeue puts an entry back in line with a new rank.
+	Requeue(ctx context.Context, entry *models.WaitingRoomEntry) error
+	CountAhead(ctx context.Context, eventID string, rank int64) (int64, error)
+	Stats(ctx context.Context, eventID string) (*models.WaitingRoomStats, error)
+	// AdmitNext admits up to n waiting entries in rank order and returns how
+	// many were admitted.
+	AdmitNext(ctx context.Context, eventID string, n int, at time.Time) (int64, error)
+	WithTx(tx *gorm.DB) WaitingRoomRepository
+}
+
+type waitingRoomRepository struct {
+	db *gorm.DB
+}
+
+func NewWaitingRoomRepository(db *gorm.DB) WaitingRoomRepository {
+	return &waitingRoomRepository{db: db}
+}
+
+func (r *waitingRoomRepository) WithTx(tx *gorm.DB) WaitingRoomRepository {
+	return &waitingRoomRepository{db: tx}
+}
+
+func (r *waitingRoomRepository) FindRoom(ctx context.Context, eventID string) (*models.WaitingRoom, error) {
+	var room models.WaitingRoom
+	err := r.db.WithContext(ctx).Where("event_id = ?", eventID).First(&room).Error
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (r *waitingRoomRepository) LockRoom(ctx context.Context, eventID string) (*models.WaitingRoom, error) {
+	var room models.WaitingRoom
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("event_id = ?", eventID).First(&room).Error
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+func (r *waitingRoomRepository) SaveRoom(ctx context.Context, room *models.WaitingRoom) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "event_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "sale_starts_at", "admit_per_minute", "last_admission_at", "updated_at"}),
+	}).Create(room).Error
+}
+
+func (r *waitingRoomRepository) FindOpenRooms(ctx context.Context, now time.Time) ([]string, error) {
+	var eventIDs []string
+	err := r.db.WithContext(ctx).Model(&models.WaitingRoom{}).
+		Where("enabled AND (sale_starts_at IS NULL OR sale_starts_at <= ?)", now).
+		Pluck("event_id", &eventIDs).Error
+	return eventIDs, err
+}
+
+func (r *waitingRoomRepository) FindEntry(ctx context.Context, eventID, userID string) (*models.WaitingRoomEntry, error) {
+	var entry models.WaitingRoomEntry
+	err := r.db.WithContext(ctx).Where("event_id = ? AND user_id = ?", eventID, userID).First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitingRoomRepository) CreateEntry(ctx context.Context, entry *models.WaitingRoomEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *waitingRoomRepository) Requeue(ctx context.Context, entry *models.WaitingRoomEntry) error {
+	return r.db.WithContext(ctx).Model(entry).Updates(map[string]any{
+		"rank":        entry.Rank,
+		"joined_at":   entry.JoinedAt,
+		"admitted_at": nil,
+		"used_at":     nil,
+	}).Error
+}
+
+func (r *waitingRoomRepository) CountAhead(ctx context.Context, eventID string, rank int64) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.WaitingRoomEntry{}).
+		Where("event_id = ? AND admitted_at IS NULL AND rank < ?", eventID, rank).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *waitingRoomRepository) Stats(ctx context.Context, eventID string) (*models.WaitingRoomStats, error) {
+	var stats models.WaitingRoomStats
+	err := r.db.WithContext(ctx).Model(&models.WaitingRoomEntry{}).
+		Select("COUNT(*) FILTER (WHERE admitted_at IS NULL) AS waiting, COUNT(admitted_at) AS admitted").
+		Where("event_id = ?", eventID).
+		Scan(&stats).Error
+	return &stats, err
+}
+
+func (r *waitingRoomRepository) AdmitNext(ctx context.Context, eventID string, n int, at time.Time) (int64, error) {
+	next := r.db.Model(&models.WaitingRoomEntry{}).Select("id").
+		Where("event_id = ? AND admitted_at IS NULL", eventID).
+		Order("rank").Limit(n)
+	result := r.db.WithContext(ctx).Model(&models.WaitingRoomEntry{}).
+		Where("id IN (?)", next).
+		Update("admitted_at", at)
+	return result.RowsAffected, result.Error
+}
@@ -11,6 +11,7 @@ type WaitlistRepository interface {
 	Create(ctx context.Context, waitlist *models.Waitlist) error
 	GetNextInLine(ctx context.Context, eventID string) (*models.Waitlist, error)
 	Delete(ctx context.Context, waitlistID string) error
+	DeleteByGroupBooking(ctx context.Context, groupID string) error
 	CountByEvent(ctx context.Context, eventID string) (int64, error)
 	FindByEventAndUser(ctx context.Context, eventID, userID string) (*models.Waitlist, error)
 	WithTx(tx *gorm.DB) WaitlistRepository
@@ -45,6 +46,10 @@ func (r *waitlistRepository) Delete(ctx context.Context, waitlistID string) erro
 	return r.db.WithContext(ctx).Where("id = ?", waitlistID).Delete(&models.Waitlist{}).Error
 }
 
+func (r *waitlistRepository) DeleteByGroupBooking(ctx context.Context, groupID string) error {
+	return r.db.WithContext(ctx).Where("group_booking_id = ?", groupID).Delete(&models.Waitlist{}).Error
+}
+
 func (r *waitlistRepository) CountByEvent(ctx context.Context, eventID string) (int64, error) {
 	var count int64
 	err := r.db.WithContext(ctx).Model(&models.Waitlist{}).Where("event_id = ?", eventID).Count(&count).Error
@@ -1,20 +1,48 @@
 package router
 
 import (
+	"event_registration/internal/config"
 	"event_registration/internal/handlers"
 	"event_registration/internal/middleware"
 	"event_registration/internal/models"
+	"event_registration/internal/tracing"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func SetupRouter(
-	jwtSecret string,
+	cfg *config.Config,
 	authHandler *handlers.AuthHandler,
 	eventHandler *handlers.EventHandler,
 	organizerHandler *handlers.OrganizerHandler,
 	adminHandler *handlers.AdminHandler,
+	orderHandler *handlers.OrderHandler,
+	transferHandler *handlers.TransferHandler,
+	venueHandler *handlers.VenueHandler,
+	seriesHandler *handlers.SeriesHandler,
+	healthHandler *handlers.HealthHandler,
 ) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(
+		otelgin.Middleware(tracing.ServiceName),
+		middleware.RequestID(),
+		middleware.RequestLogger(),
+		middleware.Recovery(),
+		middleware.Metrics(),
+		middleware.CORS(cfg.CORS.AllowedOrigins),
+	)
+
+	// Probes and scrapes are exempt; every API group shares one limiter.
+	rateLimit := middleware.RateLimit(cfg.RateLimit.RequestsPerSecond, cfg.RateLimit.Burst)
+	authRequired := middleware.AuthRequired(cfg.Auth.JWTSecret)
+
+	// Prometheus scrape endpoint
+	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// Orchestrator Probes
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
 
 	// Serve Static Frontend
 	r.Static("/static", "./static")
@@ -24,6 +52,7 @@ func SetupRouter(
 
 	// Auth Routes
 	auth := r.Group("/auth")
+	auth.Use(rateLimit)
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
@@ -31,30 +60,113 @@ func SetupRouter(
 
 	// Audience / General Event Browsing
 	events := r.Group("/events")
-	events.Use(middleware.AuthRequired(jwtSecret))
+	events.Use(rateLimit, authRequired)
 	{
 		events.GET("", eventHandler.ListEvents)
 		events.GET("/:id", eventHandler.GetEvent)
 		events.POST("/:id/register", eventHandler.RegisterForEvent)
+		events.GET("/:id/ticket-types", orderHandler.ListTicketTypes)
+		events.POST("/:id/orders", orderHandler.PlaceOrder)
+		events.POST("/:id/waiting-room", eventHandler.JoinWaitingRoom)
+		events.GET("/:id/waiting-room", eventHandler.GetWaitingRoomStatus)
 		events.POST("/registrations/:registration_id/cancel", eventHandler.CancelRegistration)
+		events.POST("/registrations/:registration_id/transfer", transferHandler.OfferTransfer)
+		events.POST("/:id/group-bookings", eventHandler.BookGroup)
+		events.GET("/:id/seats", eventHandler.GetSeats)
+		events.POST("/:id/seats/book", eventHandler.BookSeats)
+		events.GET("/group-bookings", eventHandler.ListMyGroupBookings)
+		events.GET("/group-bookings/:group_id", eventHandler.GetGroupBooking)
+		events.POST("/group-bookings/:group_id/cancel", eventHandler.CancelGroupBooking)
+		events.GET("/booking-requests/:request_id", eventHandler.GetBookingRequest)
+		events.GET("/booking-requests/:request_id/stream", eventHandler.StreamBookingRequest)
+		events.GET("/series/:series_id", seriesHandler.GetPublicSeries)
+		events.POST("/series/:series_id/subscribe", seriesHandler.SubscribeSeries)
+	}
+
+	// Orders for paid events
+	orders := r.Group("/orders")
+	orders.Use(rateLimit, authRequired)
+	{
+		orders.GET("", orderHandler.ListMyOrders)
+		orders.GET("/:order_id", orderHandler.GetOrder)
+	}
+
+	// Ticket transfers, sent and received
+	transfers := r.Group("/transfers")
+	transfers.Use(rateLimit, authRequired)
+	{
+		transfers.GET("", transferHandler.ListTransfers)
+		transfers.POST("/:transfer_id/accept", transferHandler.AcceptTransfer)
+		transfers.POST("/:transfer_id/decline", transferHandler.DeclineTransfer)
+		transfers.POST("/:transfer_id/cancel", transferHandler.CancelTransfer)
+	}
+
+	// Payment provider callbacks, authenticated by their signature
+	paymentRoutes := r.Group("/payments")
+	paymentRoutes.Use(rateLimit)
+	{
+		paymentRoutes.POST("/webhook", orderHandler.PaymentWebhook)
+		if orderHandler.FakeCheckoutEnabled() {
+			paymentRoutes.POST("/fake/checkout/:ref", authRequired, orderHandler.FakeCheckout)
+		}
 	}
 
 	// Organizer Routes
 	organizer := r.Group("/organizer")
-	organizer.Use(middleware.AuthRequired(jwtSecret), middleware.RoleRequired(models.RoleOrganizer, models.RoleAdmin))
+	organizer.Use(rateLimit, authRequired, middleware.RoleRequired(models.RoleOrganizer, models.RoleAdmin))
 	{
 		organizer.POST("/events", organizerHandler.CreateEvent)
 		organizer.GET("/events", organizerHandler.ListMyEvents)
+		organizer.PUT("/events/:id", organizerHandler.UpdateEvent)
 		organizer.POST("/events/:id/publish", organizerHandler.PublishEvent)
 		organizer.POST("/events/:id/cancel", organizerHandler.CancelEvent)
+		organizer.PUT("/events/:id/admission-mode", organizerHandler.SetAdmissionMode)
+		organizer.PUT("/events/:id/refund-policy", organizerHandler.SetRefundPolicy)
+		organizer.PUT("/events/:id/transfer-policy", organizerHandler.SetTransferPolicy)
+		organizer.POST("/venues", venueHandler.CreateVenue)
+		organizer.GET("/venues", venueHandler.ListVenues)
+		organizer.GET("/venues/:venue_id", venueHandler.GetVenue)
+		organizer.PUT("/venues/:venue_id", venueHandler.UpdateVenue)
+		organizer.DELETE("/venues/:venue_id", venueHandler.DeleteVenue)
+		organizer.POST("/series", seriesHandler.CreateSeries)
+		organizer.GET("/series", seriesHandler.ListSeries)
+		organizer.GET("/series/:series_id", seriesHandler.GetSeries)
+		organizer.PUT("/series/:series_id", seriesHandler.UpdateSeries)
+		organizer.POST("/series/:series_id/publish", seriesHandler.PublishSeries)
+		organizer.POST("/seat-maps", organizerHandler.CreateSeatMap)
+		organizer.GET("/seat-maps", organizerHandler.ListSeatMaps)
+		organizer.GET("/seat-maps/:map_id", organizerHandler.GetSeatMap)
+		organizer.PUT("/events/:id/seat-map", organizerHandler.AssignSeatMap)
+		organizer.POST("/events/:id/ticket-types", organizerHandler.CreateTicketType)
+		organizer.POST("/events/:id/promo-codes", organizerHandler.CreatePromoCode)
+		organizer.GET("/events/:id/promo-codes", organizerHandler.ListPromoCodes)
+		organizer.POST("/promo-codes/:code_id/deactivate", organizerHandler.DeactivatePromoCode)
+		organizer.PUT("/events/:id/waiting-room", organizerHandler.ConfigureWaitingRoom)
+		organizer.GET("/events/:id/waiting-room", organizerHandler.GetWaitingRoom)
 		organizer.GET("/events/:id/analytics", organizerHandler.GetAnalytics)
+		if cfg.Features.CSVImport {
+			organizer.POST("/events/:id/import", organizerHandler.ImportAttendees)
+			organizer.GET("/imports/:job_id", organizerHandler.GetImportJob)
+		}
+		organizer.POST("/registrations/:registration_id/check-in", organizerHandler.CheckInRegistration)
+		organizer.POST("/events/:id/check-in", organizerHandler.CheckInTicket)
 	}
 
 	// Admin Routes
 	admin := r.Group("/admin")
-	admin.Use(middleware.AuthRequired(jwtSecret), middleware.RoleRequired(models.RoleAdmin))
+	admin.Use(rateLimit, authRequired, middleware.RoleRequired(models.RoleAdmin))
 	{
-		admin.POST("/events/:id/simulate", adminHandler.SimulateConcurrency)
+		if cfg.Features.Simulation {
+			admin.POST("/events/:id/simulate", adminHandler.SimulateConcurrency)
+			admin.GET("/simulations/:job_id", adminHandler.GetSimulation)
+		}
+		admin.PUT("/events/:id/allocation-strategy", adminHandler.SetAllocationStrategy)
+		admin.GET("/stats", adminHandler.GetPlatformStats)
+		admin.GET("/reconciliation", adminHandler.GetReconciliation)
+		admin.POST("/reconciliation/repair", adminHandler.RepairReconciliation)
+		admin.GET("/refunds", adminHandler.ListRefunds)
+		admin.POST("/orders/:order_id/refunds", adminHandler.OverrideRefund)
+		admin.POST("/refunds/:refund_id/retry", adminHandler.RetryRefund)
 	}
 
 	return r
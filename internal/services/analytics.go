@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"event_registration/internal/models"
+	"github.com/google/uuid"
+)
+
+type AnalyticsBucket string
+
+const (
+	AnalyticsBucketHour AnalyticsBucket = "hour"
+	AnalyticsBucketDay  AnalyticsBucket = "day"
+)
+
+// Guards against a tiny bucket over a huge range producing an enormous response.
+const maxAnalyticsBuckets = 2000
+
+// AnalyticsQuery selects the window and granularity of the time series.
+// Zero values default to the event's creation time, now, and daily buckets.
+type AnalyticsQuery struct {
+	From   time.Time
+	To     time.Time
+	Bucket AnalyticsBucket
+}
+
+// AnalyticsPoint is one bucket of the series. Bookings counts every seat
+// taken in the bucket, including seats filled by waitlist promotions.
+type AnalyticsPoint struct {
+	BucketStart         time.Time `json:"bucket_start"`
+	Bookings            int       `json:"bookings"`
+	Cancellations       int       `json:"cancellations"`
+	WaitlistJoins       int       `json:"waitlist_joins"`
+	Promotions          int       `json:"promotions"`
+	CumulativeConfirmed int       `json:"cumulative_confirmed"`
+	FillPercentage      float64   `json:"fill_percentage"`
+}
+
+// EventAnalytics is the organizer-facing report for a single event. Summary
+// counts and rates cover the event's whole lifetime; Series covers From..To.
+type EventAnalytics struct {
+	EventID uuid.UUID       `json:"event_id"`
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Bucket  AnalyticsBucket `json:"bucket"`
+
+	TotalRegistrations    int64   `json:"total_registrations"`
+	ConfirmedCount        int64   `json:"confirmed_count"`
+	CancelledCount        int64   `json:"cancelled_count"`
+	WaitlistCount         int64   `json:"waitlist_count"`
+	CheckedInCount        int64   `json:"checked_in_count"`
+	SeatsFilledPercentage float64 `json:"seats_filled_percentage"`
+
+	CancellationRate       float64 `json:"cancellation_rate"`
+	WaitlistConversionRate float64 `json:"waitlist_conversion_rate"`
+	CheckInRate            float64 `json:"check_in_rate"`
+	TimeToSellOutSeconds   *int64  `json:"time_to_sell_out_seconds"`
+
+	Series []AnalyticsPoint `json:"series"`
+}
+
+func (q AnalyticsQuery) normalize(event *models.Event) (AnalyticsQuery, error) {
+	if q.Bucket == "" {
+		q.Bucket = AnalyticsBucketDay
+	}
+	if q.Bucket != AnalyticsBucketHour && q.Bucket != AnalyticsBucketDay {
+		return q, errors.New("bucket must be hour or day")
+	}
+	if q.From.IsZero() {
+		q.From = event.CreatedAt
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	if !q.To.After(q.From) {
+		return q, errors.New("to must be after from")
+	}
+
+	q.From = q.Bucket.truncate(q.From.UTC())
+	q.To = q.To.UTC()
+	if q.To.Sub(q.From)/q.Bucket.duration() > maxAnalyticsBuckets {
+		return q, errors.New("date range too large for the selected bucket")
+	}
+	return q, nil
+}
+
+func (b AnalyticsBucket) duration() time.Duration {
+	if b == AnalyticsBucketHour {
+		return time.Hour
+	}
+	return 24 * time.Hour
+}
+
+func (b AnalyticsBucket) truncate(t time.Time) time.Time {
+	if b == AnalyticsBucketHour {
+		return t.Truncate(time.Hour)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// seatChange is a +1/-1 movement in confirmed seats at a point in time.
+type seatChange struct {
+	at    time.Time
+	delta int
+}
+
+func buildEventAnalytics(event *models.Event, q AnalyticsQuery, registrations []models.Registration, waitlistCount int64, activity []models.AuditLog) *EventAnalytics {
+	a := &EventAnalytics{
+		EventID:       event.ID,
+		From:          q.From,
+		To:            q.To,
+		Bucket:        q.Bucket,
+		WaitlistCount: waitlistCount,
+	}
+
+	// Seats are counted from the audit trail rather than registration rows:
+	// a row can be cancelled and confirmed again, or moved to another user
+	// by a transfer, and every cancellation, including those made by
+	// cancelling the event, is logged per registration.
+	var changes []seatChange
+	var joins, promotions int64
+	audited := make(map[uuid.UUID]bool)
+	for _, entry := range activity {
+		switch entry.Action {
+		case models.AuditActionRegistrationConfirmed:
+			changes = append(changes, seatChange{at: entry.Timestamp, delta: 1})
+			audited[entry.EntityID] = true
+		case models.AuditActionWaitlistPromoted:
+			changes = append(changes, seatChange{at: entry.Timestamp, delta: 1})
+			audited[entry.EntityID] = true
+			promotions++
+		case models.AuditActionRegistrationCancelled:
+			changes = append(changes, seatChange{at: entry.Timestamp, delta: -1})
+		case models.AuditActionWaitlistJoined:
+			joins++
+		}
+	}
+
+	for _, reg := range registrations {
+		// Rows booked before seat takes were audited took their seat when
+		// they were created.
+		if !audited[reg.ID] {
+			changes = append(changes, seatChange{at: reg.CreatedAt, delta: 1})
+		}
+		switch reg.Status {
+		case models.RegistrationStatusConfirmed:
+			a.ConfirmedCount++
+			if reg.CheckedInAt != nil {
+				a.CheckedInCount++
+			}
+		case models.RegistrationStatusCancelled:
+			a.CancelledCount++
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].at.Before(changes[j].at) })
+
+	a.TotalRegistrations = a.ConfirmedCount + a.WaitlistCount
+	if event.Capacity > 0 {
+		a.SeatsFilledPercentage = float64(event.Capacity-event.SeatsRemaining) / float64(event.Capacity) * 100
+	}
+	if total := a.ConfirmedCount + a.CancelledCount; total > 0 {
+		a.CancellationRate = float64(a.CancelledCount) / float64(total)
+	}
+	if joins > 0 {
+		a.WaitlistConversionRate = float64(promotions) / float64(joins)
+	}
+	if a.ConfirmedCount > 0 {
+		a.CheckInRate = float64(a.CheckedInCount) / float64(a.ConfirmedCount)
+	}
+	a.TimeToSellOutSeconds = timeToSellOut(event, changes)
+
+	a.Series = buildSeries(event, q, activity, changes)
+	return a
+}
+
+// timeToSellOut measures from publication (or creation, for events published
+// before that was recorded) to the first moment every seat was taken.
+func timeToSellOut(event *models.Event, changes []seatChange) *int64 {
+	if event.Capacity <= 0 {
+		return nil
+	}
+	start := event.CreatedAt
+	if event.PublishedAt != nil {
+		start = *event.PublishedAt
+	}
+
+	occupied := 0
+	for _, c := range changes {
+		occupied += c.delta
+		if occupied >= event.Capacity {
+			seconds := int64(c.at.Sub(start).Seconds())
+			if seconds < 0 {
+				seconds = 0
+			}
+			return &seconds
+		}
+	}
+	return nil
+}
+
+func buildSeries(event *models.Event, q AnalyticsQuery, activity []models.AuditLog, changes []seatChange) []AnalyticsPoint {
+	step := q.Bucket.duration()
+	var series []AnalyticsPoint
+	for start := q.From; start.Before(q.To); start = start.Add(step) {
+		series = append(series, AnalyticsPoint{BucketStart: start})
+	}
+
+	index := func(t time.Time) int {
+		if t.Before(q.From) || !t.Before(q.To) {
+			return -1
+		}
+		return int(t.Sub(q.From) / step)
+	}
+
+	for _, c := range changes {
+		if i := index(c.at); c.delta > 0 && i >= 0 {
+			series[i].Bookings++
+		}
+	}
+	for _, entry := range activity {
+		i := index(entry.Timestamp)
+		if i < 0 {
+			continue
+		}
+		switch entry.Action {
+		case models.AuditActionRegistrationCancelled:
+			series[i].Cancellations++
+		case models.AuditActionWaitlistJoined:
+			series[i].WaitlistJoins++
+		case models.AuditActionWaitlistPromoted:
+			series[i].Promotions++
+		}
+	}
+
+	// Cumulative fill is the confirmed seat count at the end of each bucket.
+	occupied, next := 0, 0
+	for i := range series {
+		end := series[i].BucketStart.Add(step)
+		for next < len(changes) && changes[next].at.Before(end) {
+			occupied += changes[next].delta
+			next++
+		}
+		series[i].CumulativeConfirmed = occupied
+		if event.Capacity > 0 {
+			series[i].FillPercentage = float64(occupied) / float64(event.Capacity) * 100
+		}
+	}
+
+	return series
+}
@@ -3,33 +3,43 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
 	"event_registration/internal/models"
 	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
 	"event_registration/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type AuthService interface {
 	Register(ctx context.Context, name, email, password string, role models.Role) (*models.User, error)
 	Login(ctx context.Context, email, password, jwtSecret string) (string, error)
+	SetRole(ctx context.Context, email string, role models.Role) (*models.User, error)
 }
 
 type authService struct {
 	userRepo repositories.UserRepository
+	tokenTTL time.Duration
 }
 
-func NewAuthService(userRepo repositories.UserRepository) AuthService {
-	return &authService{userRepo: userRepo}
+func NewAuthService(userRepo repositories.UserRepository, tokenTTL time.Duration) AuthService {
+	return &authService{userRepo: userRepo, tokenTTL: tokenTTL}
 }
 
-func (s *authService) Register(ctx context.Context, name, email, password string, role models.Role) (*models.User, error) {
+func (s *authService) Register(ctx context.Context, name, email, password string, role models.Role) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register", attribute.String("user.role", string(role)))
+	defer func() { tracing.End(span, err) }()
+
 	// Check if user exists
 	existingUser, _ := s.userRepo.FindByEmail(ctx, email)
 	if existingUser != nil {
 		return nil, errors.New("email already in use")
 	}
 
+	_, hashSpan := tracing.Start(ctx, "bcrypt.GenerateFromPassword")
 	hash, err := utils.HashPassword(password)
+	tracing.End(hashSpan, err)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +63,10 @@ func (s *authService) Register(ctx context.Context, name, email, password string
 	return user, nil
 }
 
-func (s *authService) Login(ctx context.Context, email, password, jwtSecret string) (string, error) {
+func (s *authService) Login(ctx context.Context, email, password, jwtSecret string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer func() { tracing.End(span, err) }()
+
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
 		return "", errors.New("invalid credentials")
@@ -63,14 +76,38 @@ func (s *authService) Login(ctx context.Context, email, password, jwtSecret stri
 		return "", errors.New("user is inactive")
 	}
 
-	if !utils.CheckPasswordHash(password, user.PasswordHash) {
+	_, compareSpan := tracing.Start(ctx, "bcrypt.CompareHashAndPassword")
+	matches := utils.CheckPasswordHash(password, user.PasswordHash)
+	compareSpan.End()
+	if !matches {
 		return "", errors.New("invalid credentials")
 	}
 
-	token, err := utils.GenerateToken(user.ID.String(), user.Role, jwtSecret)
+	token, err := utils.GenerateToken(user.ID.String(), user.Role, jwtSecret, s.tokenTTL)
 	if err != nil {
 		return "", err
 	}
 
 	return token, nil
 }
+
+func (s *authService) SetRole(ctx context.Context, email string, role models.Role) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.SetRole", attribute.String("user.role", string(role)))
+	defer func() { tracing.End(span, err) }()
+
+	switch role {
+	case models.RoleAudience, models.RoleOrganizer, models.RoleAdmin:
+	default:
+		return nil, errors.New("invalid role")
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := s.userRepo.UpdateRole(ctx, user.ID.String(), role); err != nil {
+		return nil, err
+	}
+	user.Role = role
+	return user, nil
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEventNotQueued         = errors.New("event does not use queued admission")
+	ErrBookingRequestNotFound = errors.New("booking request not found")
+)
+
+// BookingQueueService admits bookings for QUEUE events. Requests are stored
+// in arrival order and a worker resolves them in batches, taking the event
+// row lock once per batch instead of once per request.
+type BookingQueueService interface {
+	// Enqueue stores a booking request and returns it with its ticket and
+	// position. A user with a request still pending gets that one back.
+	Enqueue(ctx context.Context, userID, eventID string) (*models.BookingRequest, error)
+	GetRequest(ctx context.Context, userID, requestID string) (*models.BookingRequest, error)
+	IsQueued(ctx context.Context, eventID string) (bool, error)
+	// ProcessPending drains every event's queue and returns how many
+	// requests were resolved.
+	ProcessPending(ctx context.Context) (int, error)
+	RunWorker(ctx context.Context, interval time.Duration)
+}
+
+type bookingQueueService struct {
+	db        *gorm.DB
+	queueRepo repositories.BookingQueueRepository
+	eventRepo repositories.EventRepository
+	auditRepo repositories.AuditLogRepository
+	batchSize int
+	wake      chan struct{}
+}
+
+func NewBookingQueueService(db *gorm.DB, queueRepo repositories.BookingQueueRepository, eventRepo repositories.EventRepository, auditRepo repositories.AuditLogRepository, batchSize int) BookingQueueService {
+	return &bookingQueueService{
+		db:        db,
+		queueRepo: queueRepo,
+		eventRepo: eventRepo,
+		auditRepo: auditRepo,
+		batchSize: batchSize,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+func (s *bookingQueueService) IsQueued(ctx context.Context, eventID string) (bool, error) {
+	mode, err := s.eventRepo.FindAdmissionMode(ctx, eventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, ErrEventNotFound
+	}
+	return mode == models.AdmissionQueue, err
+}
+
+func (s *bookingQueueService) Enqueue(ctx context.Context, userID, eventID string) (_ *models.BookingRequest, err error) {
+	ctx, span := tracing.Start(ctx, "BookingQueueService.Enqueue",
+		attribute.String("event.id", eventID),
+		attribute.String("user.id", userID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	event, err := s.eventRepo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+	if event.AdmissionMode != models.AdmissionQueue {
+		return nil, ErrEventNotQueued
+	}
+	if err := validateBookable(*event); err != nil {
+		return nil, err
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := s.queueRepo.FindPendingByEventAndUser(ctx, eventID, userID); err == nil {
+		return s.withPosition(ctx, existing)
+	}
+	// Fail fast for users who already hold a seat; the worker checks again.
+	if err := ensureNotBooked(s.db.WithContext(ctx), eventID, userID); err != nil {
+		return nil, err
+	}
+
+	req := &models.BookingRequest{
+		EventID: event.ID,
+		UserID:  userUUID,
+		Status:  models.BookingRequestPending,
+	}
+	// The request spends the user's waiting room admission, so one
+	// admission queues once.
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := useAdmission(ctx, tx, eventID, userID); err != nil {
+			return err
+		}
+		return s.queueRepo.WithTx(tx).Create(ctx, req)
+	})
+	if err != nil {
+		// A concurrent request from the same user won the unique index, or
+		// spent the admission first.
+		if existing, findErr := s.queueRepo.FindPendingByEventAndUser(ctx, eventID, userID); findErr == nil {
+			return s.withPosition(ctx, existing)
+		}
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int64("queue.ticket", req.Ticket))
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return s.withPosition(ctx, req)
+}
+
+func (s *bookingQueueService) GetRequest(ctx context.Context, userID, requestID string) (*models.BookingRequest, error) {
+	req, err := s.queueRepo.FindByID(ctx, requestID)
+	if err != nil || req.UserID.String() != userID {
+		return nil, ErrBookingRequestNotFound
+	}
+	return s.withPosition(ctx, req)
+}
+
+func (s *bookingQueueService) withPosition(ctx context.Context, req *models.BookingRequest) (*models.BookingRequest, error) {
+	if req.Resolved() {
+		return req, nil
+	}
+	ahead, err := s.queueRepo.CountPendingAhead(ctx, req.EventID.String(), req.Ticket)
+	if err != nil {
+		return nil, err
+	}
+	req.Position = ahead + 1
+	return req, nil
+}
+
+func (s *bookingQueueService) ProcessPending(ctx context.Context) (processed int, err error) {
+	eventIDs, err := s.queueRepo.EventsWithPending(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, eventID := range eventIDs {
+		for {
+			n, err := s.processBatch(ctx, eventID)
+			processed += n
+			if err != nil {
+				return processed, err
+			}
+			if n < s.batchSize {
+				break
+			}
+		}
+	}
+	return processed, nil
+}
+
+// processBatch resolves up to batchSize pending requests for one event in a
+// single transaction under the event row lock. Each request runs in its own
+// savepoint so one failure does not undo the rest of the batch, and the seat
+// counter is written once for the whole batch.
+func (s *bookingQueueService) processBatch(ctx context.Context, eventID string) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "BookingQueueService.processBatch", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	var batch []models.BookingRequest
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(ctx, tx, eventID, "queue_batch")
+		if err != nil {
+			return err
+		}
+		queueRepo := s.queueRepo.WithTx(tx)
+		batch, err = queueRepo.ClaimPending(ctx, eventID, s.batchSize)
+		if err != nil {
+			return err
+		}
+
+		taken := 0
+		for i := range batch {
+			req := &batch[i]
+			err := tx.Transaction(func(tx *gorm.DB) error {
+				if err := validateBookable(event); err != nil {
+					return err
+				}
+				if err := ensureNotBooked(tx, eventID, req.UserID.String()); err != nil {
+					return err
+				}
+				if event.SeatsRemaining-taken > 0 {
+					reg, err := confirmSeat(ctx, tx, s.auditRepo, event.ID, req.UserID)
+					if err != nil {
+						return err
+					}
+					taken++
+					req.Status = models.BookingRequestConfirmed
+					req.RegistrationID = &reg.ID
+					return nil
+				}
+				entry, err := joinWaitlist(ctx, tx, s.auditRepo, event.ID, req.UserID)
+				if err != nil {
+					return err
+				}
+				req.Status = models.BookingRequestWaitlisted
+				req.WaitlistID = &entry.ID
+				return nil
+			})
+			if err != nil {
+				req.Status = models.BookingRequestFailed
+				req.FailureReason = bookingFailureReason(err)
+				if req.FailureReason == "internal" {
+					slog.ErrorContext(ctx, "Queued booking failed", "request_id", req.ID, "event_id", eventID, "error", err)
+				}
+			}
+			now := time.Now()
+			req.ProcessedAt = &now
+			if err := queueRepo.Resolve(ctx, req); err != nil {
+				return err
+			}
+		}
+
+		if taken > 0 {
+			return adjustSeats(tx, eventID, -taken)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, req := range batch {
+		outcome := strings.ToLower(string(req.Status))
+		metrics.BookingQueueProcessedTotal.WithLabelValues(outcome).Inc()
+		metrics.BookingQueueWaitDuration.Observe(req.ProcessedAt.Sub(req.CreatedAt).Seconds())
+		switch req.Status {
+		case models.BookingRequestConfirmed:
+			metrics.BookingsTotal.Inc()
+		case models.BookingRequestWaitlisted:
+			metrics.WaitlistJoinsTotal.Inc()
+		default:
+			metrics.BookingFailuresTotal.WithLabelValues(req.FailureReason).Inc()
+		}
+	}
+	span.SetAttributes(attribute.Int("queue.batch_size", len(batch)))
+	return len(batch), nil
+}
+
+// RunWorker drains the queues every interval, or sooner when a request is
+// enqueued on this instance, until ctx is done.
+func (s *bookingQueueService) RunWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+
+		if _, err := s.ProcessPending(ctx); err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Failed to process booking queue", "error", err)
+		}
+	}
+}
@@ -3,30 +3,102 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
 
 	"event_registration/internal/models"
 	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
 )
 
+// ErrQueueNotForPaid rejects queued admission for paid events: the queue
+// worker confirms seats directly, with no payment step.
+var ErrQueueNotForPaid = errors.New("queued admission is not available for paid events")
+
+// ErrInvalidEventQuery rejects listing filters that cannot be applied.
+var ErrInvalidEventQuery = errors.New("invalid event query")
+
+// Bounds on the radius of a proximity search, in km.
+const (
+	defaultSearchRadiusKm = 25
+	maxSearchRadiusKm     = 500
+)
+
+// EventQuery filters the public listing. With Latitude and Longitude set,
+// only events at venues within RadiusKm are listed, nearest first. Period
+// keeps events that run during that period in their own time zone.
+type EventQuery struct {
+	Latitude  *float64
+	Longitude *float64
+	RadiusKm  float64
+	Period    models.EventPeriod
+}
+
+// EventUpdate changes an event's details. Nil fields are left as they are.
+type EventUpdate struct {
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Location    *string    `json:"location"`
+	Capacity    *int       `json:"capacity"`
+	EventDate   *time.Time `json:"event_date"`
+	EndsAt      *time.Time `json:"ends_at"`
+}
+
+// ErrVenueLocation rejects a free-text location for an event held at a
+// venue, whose location is the venue's name.
+var ErrVenueLocation = errors.New("location comes from the venue and cannot be set on an event held at one")
+
+var errInvalidRefundPolicy = errors.New("refund policy days and hours must not be negative, and partial_percent must be between 0 and 100")
+
 type EventService interface {
 	CreateEvent(ctx context.Context, organizerID string, event *models.Event) error
+	// UpdateEvent edits an event that has not started. Capacity cannot drop
+	// below the seats already booked; extra seats go to the waitlist first.
+	UpdateEvent(ctx context.Context, organizerID, eventID string, update EventUpdate) (*models.Event, error)
 	PublishEvent(ctx context.Context, organizerID, eventID string) error
 	CancelEvent(ctx context.Context, organizerID, eventID string) error
 	GetEvent(ctx context.Context, eventID string) (*models.Event, error)
-	ListPublishedEvents(ctx context.Context) ([]models.Event, error)
+	ListPublishedEvents(ctx context.Context, query EventQuery) ([]models.Event, error)
 	ListOrganizerEvents(ctx context.Context, organizerID string) ([]models.Event, error)
+	SetAllocationStrategy(ctx context.Context, eventID string, strategy models.AllocationStrategy) (*models.Event, error)
+	SetAdmissionMode(ctx context.Context, organizerID, eventID string, mode models.AdmissionMode) (*models.Event, error)
+	// SetRefundPolicy changes how much attendees get back when they cancel.
+	// Refunds already requested keep their amount.
+	SetRefundPolicy(ctx context.Context, organizerID, eventID string, policy models.RefundPolicy) (*models.Event, error)
+	// SetTransferPolicy disables ticket transfers or sets the time by which
+	// they must be accepted. Pending offers are checked again on accept.
+	SetTransferPolicy(ctx context.Context, organizerID, eventID string, policy models.TransferPolicy) (*models.Event, error)
 }
 
 type eventService struct {
+	db        *gorm.DB
 	eventRepo repositories.EventRepository
+	venueRepo repositories.VenueRepository
+	waitRepo  repositories.WaitlistRepository
+	auditRepo repositories.AuditLogRepository
+	refunds   RefundService
 }
 
-func NewEventService(eventRepo repositories.EventRepository) EventService {
-	return &eventService{eventRepo: eventRepo}
+func NewEventService(db *gorm.DB, eventRepo repositories.EventRepository, venueRepo repositories.VenueRepository, waitRepo repositories.WaitlistRepository, auditRepo repositories.AuditLogRepository, refunds RefundService) EventService {
+	return &eventService{
+		db:        db,
+		eventRepo: eventRepo,
+		venueRepo: venueRepo,
+		waitRepo:  waitRepo,
+		auditRepo: auditRepo,
+		refunds:   refunds,
+	}
 }
 
-func (s *eventService) CreateEvent(ctx context.Context, organizerID string, event *models.Event) error {
+func (s *eventService) CreateEvent(ctx context.Context, organizerID string, event *models.Event) (err error) {
+	ctx, span := tracing.Start(ctx, "EventService.CreateEvent", attribute.String("organizer.id", organizerID))
+	defer func() { tracing.End(span, err) }()
+
 	orgUUID, err := uuid.Parse(organizerID)
 	if err != nil {
 		return errors.New("invalid organizer ID")
@@ -34,49 +106,415 @@ func (s *eventService) CreateEvent(ctx context.Context, organizerID string, even
 	event.OrganizerID = orgUUID
 	event.SeatsRemaining = event.Capacity
 	event.Status = models.EventStatusDraft
-	return s.eventRepo.Create(ctx, event)
+	event.AllocationStrategy = "" // admin-only, see SetAllocationStrategy
+	event.SeatMapID = nil         // see SeatingService.AssignSeatMap
+	event.SeriesID = nil          // see SeriesService.CreateSeries
+	event.Venue = nil
+	if event.AdmissionMode == "" {
+		event.AdmissionMode = models.AdmissionDirect
+	}
+	if !event.AdmissionMode.Valid() {
+		return errors.New("admission_mode must be DIRECT or QUEUE")
+	}
+	if err := validatePrice(event); err != nil {
+		return err
+	}
+	if !event.RefundPolicy.Valid() {
+		return errInvalidRefundPolicy
+	}
+	if err := validateSchedule(event); err != nil {
+		return err
+	}
+	if event.VenueID == nil {
+		if event.Timezone == "" {
+			event.Timezone = "UTC"
+		}
+		return s.eventRepo.Create(ctx, event)
+	}
+
+	if strings.TrimSpace(event.Location) != "" {
+		return ErrVenueLocation
+	}
+
+	span.SetAttributes(attribute.String("venue.id", event.VenueID.String()))
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := scheduleAtVenue(ctx, tx, s.venueRepo, s.eventRepo, event); err != nil {
+			return err
+		}
+		return s.eventRepo.WithTx(tx).Create(ctx, event)
+	})
+}
+
+// scheduleAtVenue checks that the event fits its venue and does not overlap
+// another event there, sets the location to the venue's name and fills in
+// the timezone from the venue.
+// It locks the venue row, which serialises scheduling at the venue so two
+// events cannot both pass the check.
+func scheduleAtVenue(ctx context.Context, tx *gorm.DB, venueRepo repositories.VenueRepository, eventRepo repositories.EventRepository, event *models.Event) error {
+	venue, err := venueRepo.WithTx(tx).LockByID(ctx, event.VenueID.String())
+	if err != nil || venue.OrganizerID != event.OrganizerID {
+		return ErrVenueNotFound
+	}
+	if event.Capacity > venue.MaxCapacity {
+		return fmt.Errorf("%w (%d)", ErrOverVenueCapacity, venue.MaxCapacity)
+	}
+	event.Location = venue.Name
+	if event.Timezone == "" {
+		event.Timezone = venue.Timezone
+	}
+
+	clashes, err := eventRepo.WithTx(tx).FindAtVenue(ctx, venue.ID.String(), event.EventDate, event.EndsAt)
+	if err != nil {
+		return err
+	}
+	for _, clash := range clashes {
+		if clash.ID == event.ID {
+			continue
+		}
+		return fmt.Errorf("%w: %q runs from %s to %s", ErrVenueDoubleBooked, clash.Title,
+			clash.EventDate.In(clash.Zone()).Format(time.RFC3339), clash.EndsAt.In(clash.Zone()).Format(time.RFC3339))
+	}
+	return nil
 }
 
-func (s *eventService) PublishEvent(ctx context.Context, organizerID, eventID string) error {
-	event, err := s.eventRepo.FindByID(ctx, eventID)
+func (s *eventService) UpdateEvent(ctx context.Context, organizerID, eventID string, update EventUpdate) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.UpdateEvent", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	var event models.Event
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if event, err = lockEvent(ctx, tx, eventID, "update"); err != nil {
+			return ErrEventNotFound
+		}
+		if event.OrganizerID.String() != organizerID {
+			return errors.New("unauthorized to update this event")
+		}
+		if err := applyEventDetails(ctx, tx, s.waitRepo, s.auditRepo, &event, update); err != nil {
+			return err
+		}
+
+		rescheduled := update.EventDate != nil || update.EndsAt != nil
+		if update.EventDate != nil {
+			event.EventDate = *update.EventDate
+		}
+		if update.EndsAt != nil {
+			event.EndsAt = *update.EndsAt
+		}
+		if rescheduled {
+			if err := validateSchedule(&event); err != nil {
+				return err
+			}
+			if !event.EventDate.After(time.Now()) {
+				return errors.New("event_date must be in the future")
+			}
+		}
+		if event.VenueID != nil && (rescheduled || update.Capacity != nil) {
+			if err := scheduleAtVenue(ctx, tx, s.venueRepo, s.eventRepo, &event); err != nil {
+				return err
+			}
+		}
+		// scheduleAtVenue sets the location and may fill in the timezone.
+		return tx.Model(&models.Event{}).Where("id = ?", event.ID).Updates(map[string]any{
+			"event_date": event.EventDate,
+			"ends_at":    event.EndsAt,
+			"location":   event.Location,
+			"timezone":   event.Timezone,
+		}).Error
+	})
 	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Event updated", "event_id", eventID, "organizer_id", organizerID)
+	return &event, nil
+}
+
+// applyEventDetails applies the title, description, location and capacity
+// of update to the locked event and saves them. Seats added to a full event
+// go to the waitlist first.
+func applyEventDetails(ctx context.Context, tx *gorm.DB, waitRepo repositories.WaitlistRepository, auditRepo repositories.AuditLogRepository, event *models.Event, update EventUpdate) error {
+	if event.Status == models.EventStatusCancelled {
+		return errors.New("cancelled events cannot be changed")
+	}
+	if !event.EventDate.After(time.Now()) {
+		return ErrEventPassed
+	}
+	if update.Title != nil {
+		if strings.TrimSpace(*update.Title) == "" {
+			return errors.New("title must not be empty")
+		}
+		event.Title = strings.TrimSpace(*update.Title)
+	}
+	if update.Description != nil {
+		event.Description = *update.Description
+	}
+	if update.Location != nil {
+		if event.VenueID != nil {
+			return ErrVenueLocation
+		}
+		event.Location = *update.Location
+	}
+	if err := tx.Model(&models.Event{}).Where("id = ?", event.ID).Updates(map[string]any{
+		"title":       event.Title,
+		"description": event.Description,
+		"location":    event.Location,
+	}).Error; err != nil {
 		return err
 	}
 
-	if event.OrganizerID.String() != organizerID {
-		return errors.New("unauthorized to publish this event")
+	if update.Capacity == nil || *update.Capacity == event.Capacity {
+		return nil
+	}
+	if event.ReservedSeating() {
+		return errors.New("the capacity of a reserved seating event is its seat count")
+	}
+	booked := event.Capacity - event.SeatsRemaining
+	if *update.Capacity < 1 || *update.Capacity < booked {
+		return fmt.Errorf("capacity must be at least 1 and not below the %d seats already booked", booked)
+	}
+	delta := *update.Capacity - event.Capacity
+	if err := tx.Model(&models.Event{}).Where("id = ?", event.ID).Update("capacity", *update.Capacity).Error; err != nil {
+		return err
+	}
+	event.Capacity = *update.Capacity
+	if err := adjustSeats(tx, event.ID.String(), delta); err != nil {
+		return err
 	}
+	event.SeatsRemaining += delta
+	event.Version++
+	if delta > 0 {
+		_, err := promoteWaitlist(ctx, tx, waitRepo, auditRepo, event)
+		return err
+	}
+	return nil
+}
 
-	if event.Status != models.EventStatusDraft {
-		return errors.New("event is not in draft status")
+// validateSchedule checks the event's time range and zone. An empty zone is
+// filled in later from the venue, or UTC.
+func validateSchedule(event *models.Event) error {
+	if event.EventDate.IsZero() {
+		return errors.New("event_date is required")
+	}
+	if event.EndsAt.IsZero() {
+		return errors.New("ends_at is required")
 	}
+	if !event.EndsAt.After(event.EventDate) {
+		return errors.New("ends_at must be after event_date")
+	}
+	if event.Timezone != "" {
+		if _, err := time.LoadLocation(event.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", event.Timezone)
+		}
+	}
+	return nil
+}
 
-	event.Status = models.EventStatusPublished
-	return s.eventRepo.Update(ctx, event)
+func (s *eventService) PublishEvent(ctx context.Context, organizerID, eventID string) (err error) {
+	ctx, span := tracing.Start(ctx, "EventService.PublishEvent", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	_, err = s.updateColumns(ctx, organizerID, eventID, "publish", func(event *models.Event) (map[string]any, error) {
+		if event.Status != models.EventStatusDraft {
+			return nil, errors.New("event is not in draft status")
+		}
+		return map[string]any{"status": models.EventStatusPublished, "published_at": time.Now()}, nil
+	})
+	return err
 }
 
-func (s *eventService) CancelEvent(ctx context.Context, organizerID, eventID string) error {
-	event, err := s.eventRepo.FindByID(ctx, eventID)
+// updateColumns locks the event and lets change check the locked copy and
+// return the columns to write. Only those columns are written, so an edit
+// never puts back a stale capacity, status or seat count. An empty
+// organizerID skips the ownership check, for admin changes.
+func (s *eventService) updateColumns(ctx context.Context, organizerID, eventID, operation string, change func(event *models.Event) (map[string]any, error)) (*models.Event, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(ctx, tx, eventID, operation)
+		if err != nil {
+			return ErrEventNotFound
+		}
+		if organizerID != "" && event.OrganizerID.String() != organizerID {
+			return errors.New("unauthorized to update this event")
+		}
+		columns, err := change(&event)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Event{}).Where("id = ?", event.ID).Updates(columns).Error
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return s.eventRepo.FindByID(ctx, eventID)
+}
+
+// CancelEvent sets the status under the event row lock, so a booking either
+// commits first and is settled below or sees the event cancelled, and
+// settles the event's orders in the same transaction.
+func (s *eventService) CancelEvent(ctx context.Context, organizerID, eventID string) (err error) {
+	ctx, span := tracing.Start(ctx, "EventService.CancelEvent", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	var refundIDs []string
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(ctx, tx, eventID, "event_cancel")
+		if err != nil {
+			return ErrEventNotFound
+		}
+		if event.OrganizerID.String() != organizerID {
+			return errors.New("unauthorized to cancel this event")
+		}
+		if event.Status == models.EventStatusCancelled {
+			return nil
+		}
 
-	if event.OrganizerID.String() != organizerID {
-		return errors.New("unauthorized to cancel this event")
+		if err := tx.Model(&models.Event{}).Where("id = ?", event.ID).Update("status", models.EventStatusCancelled).Error; err != nil {
+			return err
+		}
+		event.Status = models.EventStatusCancelled
+		refundIDs, err = s.refunds.SettleCancelledEvent(ctx, tx, &event)
+		return err
+	})
+	if err != nil {
+		return err
 	}
 
-	event.Status = models.EventStatusCancelled
-	return s.eventRepo.Update(ctx, event)
+	s.refunds.IssueRefunds(ctx, refundIDs)
+	span.SetAttributes(attribute.Int("refunds.queued", len(refundIDs)))
+	return nil
 }
 
-func (s *eventService) GetEvent(ctx context.Context, eventID string) (*models.Event, error) {
+func (s *eventService) GetEvent(ctx context.Context, eventID string) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.GetEvent", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
 	return s.eventRepo.FindByID(ctx, eventID)
 }
 
-func (s *eventService) ListPublishedEvents(ctx context.Context) ([]models.Event, error) {
-	return s.eventRepo.FindAll(ctx, models.EventStatusPublished)
+func (s *eventService) ListPublishedEvents(ctx context.Context, query EventQuery) (_ []models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.ListPublishedEvents")
+	defer func() { tracing.End(span, err) }()
+
+	if query.Period != "" && !query.Period.Valid() {
+		return nil, fmt.Errorf("%w: when must be today or this_weekend", ErrInvalidEventQuery)
+	}
+	filter := repositories.EventFilter{Status: models.EventStatusPublished, Period: query.Period, Now: time.Now()}
+	if query.Latitude == nil && query.Longitude == nil {
+		if query.RadiusKm != 0 {
+			return nil, fmt.Errorf("%w: radius needs lat and lng", ErrInvalidEventQuery)
+		}
+		return s.eventRepo.FindAll(ctx, filter)
+	}
+	if query.Latitude == nil || query.Longitude == nil {
+		return nil, fmt.Errorf("%w: lat and lng must be given together", ErrInvalidEventQuery)
+	}
+	lat, lng := *query.Latitude, *query.Longitude
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, fmt.Errorf("%w: lat must be between -90 and 90 and lng between -180 and 180", ErrInvalidEventQuery)
+	}
+	if query.RadiusKm == 0 {
+		query.RadiusKm = defaultSearchRadiusKm
+	}
+	if query.RadiusKm < 0 || query.RadiusKm > maxSearchRadiusKm {
+		return nil, fmt.Errorf("%w: radius must be between 0 and %d km", ErrInvalidEventQuery, maxSearchRadiusKm)
+	}
+
+	span.SetAttributes(attribute.Float64("search.radius_km", query.RadiusKm))
+	return s.eventRepo.FindNear(ctx, filter, lat, lng, query.RadiusKm)
 }
 
-func (s *eventService) ListOrganizerEvents(ctx context.Context, organizerID string) ([]models.Event, error) {
+func (s *eventService) ListOrganizerEvents(ctx context.Context, organizerID string) (_ []models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.ListOrganizerEvents", attribute.String("organizer.id", organizerID))
+	defer func() { tracing.End(span, err) }()
+
 	return s.eventRepo.FindByOrganizer(ctx, organizerID)
 }
+
+// SetAllocationStrategy overrides the seat allocation strategy for one event;
+// an empty strategy reverts it to the deployment default.
+func (s *eventService) SetAllocationStrategy(ctx context.Context, eventID string, strategy models.AllocationStrategy) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.SetAllocationStrategy",
+		attribute.String("event.id", eventID),
+		attribute.String("booking.strategy", string(strategy)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if strategy != "" && !strategy.Valid() {
+		return nil, errors.New("strategy must be pessimistic, optimistic or atomic")
+	}
+
+	return s.updateColumns(ctx, "", eventID, "allocation_strategy", func(*models.Event) (map[string]any, error) {
+		return map[string]any{"allocation_strategy": strategy}, nil
+	})
+}
+
+// SetAdmissionMode switches an event between direct booking and the booking
+// queue. Requests already queued are still processed after switching back.
+func (s *eventService) SetAdmissionMode(ctx context.Context, organizerID, eventID string, mode models.AdmissionMode) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.SetAdmissionMode",
+		attribute.String("event.id", eventID),
+		attribute.String("event.admission_mode", string(mode)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if !mode.Valid() {
+		return nil, errors.New("admission_mode must be DIRECT or QUEUE")
+	}
+
+	return s.updateColumns(ctx, organizerID, eventID, "admission_mode", func(event *models.Event) (map[string]any, error) {
+		if mode == models.AdmissionQueue && event.IsPaid() {
+			return nil, ErrQueueNotForPaid
+		}
+		if mode == models.AdmissionQueue && event.ReservedSeating() {
+			return nil, ErrSeatingQueued
+		}
+		return map[string]any{"admission_mode": mode}, nil
+	})
+}
+
+func (s *eventService) SetRefundPolicy(ctx context.Context, organizerID, eventID string, policy models.RefundPolicy) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.SetRefundPolicy", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	if !policy.Valid() {
+		return nil, errInvalidRefundPolicy
+	}
+
+	return s.updateColumns(ctx, organizerID, eventID, "refund_policy", func(*models.Event) (map[string]any, error) {
+		return map[string]any{
+			"refund_full_refund_days": policy.FullRefundDays,
+			"refund_partial_percent":  policy.PartialPercent,
+			"refund_no_refund_hours":  policy.NoRefundHours,
+		}, nil
+	})
+}
+
+func (s *eventService) SetTransferPolicy(ctx context.Context, organizerID, eventID string, policy models.TransferPolicy) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "EventService.SetTransferPolicy", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	return s.updateColumns(ctx, organizerID, eventID, "transfer_policy", func(event *models.Event) (map[string]any, error) {
+		if policy.Cutoff != nil && policy.Cutoff.After(event.EventDate) {
+			return nil, errors.New("transfer cutoff must not be after the event starts")
+		}
+		return map[string]any{"transfer_disabled": policy.Disabled, "transfer_cutoff": policy.Cutoff}, nil
+	})
+}
+
+// validatePrice normalizes the event's currency code and rejects prices that
+// cannot be charged.
+func validatePrice(event *models.Event) error {
+	event.Price.Currency = strings.ToUpper(strings.TrimSpace(event.Price.Currency))
+	if event.Price.Amount < 0 {
+		return errors.New("price must not be negative")
+	}
+	if !event.IsPaid() {
+		return nil
+	}
+	if !models.ValidCurrency(event.Price.Currency) {
+		return errors.New("price currency must be a three-letter ISO 4217 code")
+	}
+	if event.AdmissionMode == models.AdmissionQueue {
+		return ErrQueueNotForPaid
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrGroupBookingNotFound = errors.New("group booking not found")
+	ErrGroupTooLarge        = errors.New("group booking asks for more seats than allowed")
+	ErrNotEnoughSeats       = errors.New("not enough seats left for the whole group")
+	ErrGroupQueued          = errors.New("group bookings are not available while the event uses queued admission")
+	ErrGroupCancelled       = errors.New("group booking is already cancelled")
+)
+
+// GroupBookingRequest is a purchaser's request for several seats. Attendees
+// optionally names the seats in order; unnamed seats are the purchaser's to
+// hand out. OnShortfall defaults to REJECT.
+type GroupBookingRequest struct {
+	Seats       int                    `json:"seats"`
+	Attendees   []models.GroupAttendee `json:"attendees"`
+	OnShortfall models.GroupShortfall  `json:"on_shortfall"`
+}
+
+type GroupBookingService interface {
+	// BookGroup reserves the seats in one transaction under the event row
+	// lock. When fewer seats remain, the request's OnShortfall decides
+	// between failing with ErrNotEnoughSeats, booking what is left, and
+	// waitlisting the whole group.
+	BookGroup(ctx context.Context, userID, eventID string, req GroupBookingRequest) (*models.GroupBooking, error)
+	GetGroupBooking(ctx context.Context, userID, groupID string) (*models.GroupBooking, error)
+	ListUserGroupBookings(ctx context.Context, userID string) ([]models.GroupBooking, error)
+	// CancelGroupBooking cancels every seat still held by the group, or its
+	// waitlist place, and hands the freed seats to the waitlist.
+	CancelGroupBooking(ctx context.Context, userID, groupID string) (*models.GroupBooking, error)
+}
+
+type groupBookingService struct {
+	db        *gorm.DB
+	groupRepo repositories.GroupBookingRepository
+	waitRepo  repositories.WaitlistRepository
+	auditRepo repositories.AuditLogRepository
+	maxSize   int
+}
+
+func NewGroupBookingService(db *gorm.DB, groupRepo repositories.GroupBookingRepository, waitRepo repositories.WaitlistRepository, auditRepo repositories.AuditLogRepository, maxSize int) GroupBookingService {
+	return &groupBookingService{
+		db:        db,
+		groupRepo: groupRepo,
+		waitRepo:  waitRepo,
+		auditRepo: auditRepo,
+		maxSize:   maxSize,
+	}
+}
+
+func (s *groupBookingService) validate(req *GroupBookingRequest) error {
+	if req.Seats < 1 {
+		return errors.New("seats must be at least 1")
+	}
+	if req.Seats > s.maxSize {
+		return fmt.Errorf("%w: at most %d", ErrGroupTooLarge, s.maxSize)
+	}
+	if len(req.Attendees) > req.Seats {
+		return errors.New("more attendees than seats")
+	}
+	normalizeAttendees(req.Attendees)
+	if req.OnShortfall == "" {
+		req.OnShortfall = models.ShortfallReject
+	}
+	if !req.OnShortfall.Valid() {
+		return errors.New("on_shortfall must be REJECT, PARTIAL or WAITLIST")
+	}
+	return nil
+}
+
+func normalizeAttendees(attendees []models.GroupAttendee) {
+	for i := range attendees {
+		attendees[i].Name = strings.TrimSpace(attendees[i].Name)
+		attendees[i].Email = strings.ToLower(strings.TrimSpace(attendees[i].Email))
+	}
+}
+
+func (s *groupBookingService) BookGroup(ctx context.Context, userID, eventID string, req GroupBookingRequest) (_ *models.GroupBooking, err error) {
+	ctx, span := tracing.Start(ctx, "GroupBookingService.BookGroup",
+		attribute.String("event.id", eventID),
+		attribute.String("user.id", userID),
+		attribute.Int("group.seats", req.Seats),
+		attribute.String("group.on_shortfall", string(req.OnShortfall)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.validate(&req); err != nil {
+		return nil, err
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var group *models.GroupBooking
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Groups always take the row lock: the allocators reserve one seat
+		// at a time, and all-or-nothing needs the count to hold still.
+		event, err := lockEvent(ctx, tx, eventID, "group_booking")
+		if err != nil {
+			return ErrEventNotFound
+		}
+		if err := validateBookable(event); err != nil {
+			return err
+		}
+		if event.IsPaid() {
+			return ErrPaymentRequired
+		}
+		if event.AdmissionMode == models.AdmissionQueue {
+			return ErrGroupQueued
+		}
+		if err := useAdmission(ctx, tx, eventID, userID); err != nil {
+			return err
+		}
+
+		group = &models.GroupBooking{
+			EventID:   event.ID,
+			UserID:    userUUID,
+			Seats:     req.Seats,
+			Shortfall: req.OnShortfall,
+			Attendees: req.Attendees,
+		}
+		seats := min(req.Seats, event.SeatsRemaining)
+		switch {
+		case seats == req.Seats:
+			group.Status = models.GroupBookingConfirmed
+		case req.OnShortfall == models.ShortfallPartial && seats > 0:
+			group.Status = models.GroupBookingPartial
+		case req.OnShortfall == models.ShortfallWaitlist:
+			group.Status = models.GroupBookingWaitlisted
+			seats = 0
+		default:
+			return ErrNotEnoughSeats
+		}
+		span.SetAttributes(attribute.Int("event.seats_remaining", event.SeatsRemaining))
+
+		if err := s.groupRepo.WithTx(tx).Create(ctx, group); err != nil {
+			return err
+		}
+		if seats == 0 {
+			// The waitlist allows one place per user and event.
+			if _, err := s.waitRepo.WithTx(tx).FindByEventAndUser(ctx, eventID, userID); err == nil {
+				return ErrAlreadyWaitlisted
+			}
+			return appendWaitlist(ctx, tx, s.auditRepo, &models.Waitlist{
+				UserID:         userUUID,
+				EventID:        event.ID,
+				Seats:          req.Seats,
+				GroupBookingID: &group.ID,
+			})
+		}
+		if err := takeSeats(tx, &event, seats); err != nil {
+			return err
+		}
+		return confirmGroupSeats(ctx, tx, s.auditRepo, group, seats, models.AuditActionRegistrationConfirmed)
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotEnoughSeats) {
+			metrics.GroupBookingsTotal.WithLabelValues("rejected").Inc()
+		}
+		return nil, err
+	}
+
+	metrics.GroupBookingsTotal.WithLabelValues(strings.ToLower(string(group.Status))).Inc()
+	if group.Status == models.GroupBookingWaitlisted {
+		metrics.WaitlistJoinsTotal.Inc()
+	} else {
+		metrics.BookingsTotal.Add(float64(len(group.Registrations)))
+	}
+	span.SetAttributes(attribute.String("group.id", group.ID.String()), attribute.String("group.status", string(group.Status)))
+	slog.InfoContext(ctx, "Group booking placed", "group_id", group.ID, "event_id", eventID, "user_id", userID,
+		"seats", req.Seats, "confirmed", len(group.Registrations), "status", group.Status)
+	return group, nil
+}
+
+// confirmGroupSeats creates n confirmed registrations for seats the caller
+// already took, named in the order the purchaser gave.
+func confirmGroupSeats(ctx context.Context, tx *gorm.DB, auditRepo repositories.AuditLogRepository, group *models.GroupBooking, n int, action string) error {
+	regs := make([]models.Registration, n)
+	for i := range regs {
+		attendee := group.Attendee(i)
+		regs[i] = models.Registration{
+			UserID:         group.UserID,
+			EventID:        group.EventID,
+			Status:         models.RegistrationStatusConfirmed,
+			GroupBookingID: &group.ID,
+			AttendeeName:   attendee.Name,
+			AttendeeEmail:  attendee.Email,
+		}
+	}
+	if err := tx.Omit("User", "Event").Create(&regs).Error; err != nil {
+		return err
+	}
+	for _, reg := range regs {
+		if err := recordActivity(ctx, tx, auditRepo, group.UserID, action, models.AuditEntityRegistration, reg.ID, group.EventID); err != nil {
+			return err
+		}
+	}
+	group.Registrations = regs
+	return nil
+}
+
+// promoteGroup confirms every seat of a waitlisted group. The caller holds
+// the event row lock and has taken the seats.
+func promoteGroup(ctx context.Context, tx *gorm.DB, auditRepo repositories.AuditLogRepository, groupID uuid.UUID) error {
+	var group models.GroupBooking
+	if err := tx.Where("id = ?", groupID).First(&group).Error; err != nil {
+		return err
+	}
+	group.Status = models.GroupBookingConfirmed
+	if err := tx.Omit("Registrations").Save(&group).Error; err != nil {
+		return err
+	}
+	return confirmGroupSeats(ctx, tx, auditRepo, &group, group.Seats, models.AuditActionWaitlistPromoted)
+}
+
+func (s *groupBookingService) GetGroupBooking(ctx context.Context, userID, groupID string) (*models.GroupBooking, error) {
+	group, err := s.groupRepo.FindByID(ctx, groupID)
+	if err != nil || group.UserID.String() != userID {
+		return nil, ErrGroupBookingNotFound
+	}
+	return group, nil
+}
+
+func (s *groupBookingService) ListUserGroupBookings(ctx context.Context, userID string) ([]models.GroupBooking, error) {
+	return s.groupRepo.FindByUser(ctx, userID)
+}
+
+func (s *groupBookingService) CancelGroupBooking(ctx context.Context, userID, groupID string) (_ *models.GroupBooking, err error) {
+	ctx, span := tracing.Start(ctx, "GroupBookingService.CancelGroupBooking",
+		attribute.String("group.id", groupID),
+		attribute.String("user.id", userID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	group, err := s.groupRepo.FindByID(ctx, groupID)
+	if err != nil || group.UserID.String() != userID {
+		return nil, ErrGroupBookingNotFound
+	}
+
+	cancelled, promoted := 0, 0
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Seats of the group change only under the event row lock, so the
+		// group is re-read once it is held.
+		event, err := lockEvent(ctx, tx, group.EventID.String(), "cancel")
+		if err != nil {
+			return err
+		}
+		locked, err := s.groupRepo.WithTx(tx).LockByID(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if locked.Status == models.GroupBookingCancelled {
+			return ErrGroupCancelled
+		}
+
+		if locked.Status == models.GroupBookingWaitlisted {
+			if err := s.waitRepo.WithTx(tx).DeleteByGroupBooking(ctx, groupID); err != nil {
+				return err
+			}
+		}
+
+		var regs []models.Registration
+		if err := tx.Where("group_booking_id = ? AND status = ?", groupID, models.RegistrationStatusConfirmed).Find(&regs).Error; err != nil {
+			return err
+		}
+		ids := make([]uuid.UUID, len(regs))
+		for i, reg := range regs {
+			ids[i] = reg.ID
+		}
+		if err := cancelPendingTransfers(tx, ids...); err != nil {
+			return err
+		}
+		if err := releaseEventSeats(tx, ids...); err != nil {
+			return err
+		}
+		for _, reg := range regs {
+			if err := tx.Model(&reg).Update("status", models.RegistrationStatusCancelled).Error; err != nil {
+				return err
+			}
+			if err := recordActivity(ctx, tx, s.auditRepo, locked.UserID, models.AuditActionRegistrationCancelled, models.AuditEntityRegistration, reg.ID, event.ID); err != nil {
+				return err
+			}
+		}
+		cancelled = len(regs)
+
+		locked.Status = models.GroupBookingCancelled
+		if err := s.groupRepo.WithTx(tx).Update(ctx, locked); err != nil {
+			return err
+		}
+
+		if cancelled > 0 {
+			if err := releaseSeats(tx, &event, cancelled); err != nil {
+				return err
+			}
+			if promoted, err = promoteWaitlist(ctx, tx, s.waitRepo, s.auditRepo, &event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("group.seats_cancelled", cancelled), attribute.Int("waitlist.promoted", promoted))
+	metrics.CancellationsTotal.Add(float64(cancelled))
+	metrics.PromotionsTotal.Add(float64(promoted))
+	slog.InfoContext(ctx, "Group booking cancelled", "group_id", groupID, "user_id", userID, "seats_cancelled", cancelled, "promoted_from_waitlist", promoted)
+	return s.groupRepo.FindByID(ctx, groupID)
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"sync"
+
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+)
+
+const (
+	// Files with more rows than this are processed in the background.
+	asyncImportThreshold = 100
+	maxImportRows        = 10000
+	importBatchSize      = 50
+
+	// Imported accounts get an unusable hash; bcrypt never matches it, so
+	// the guest cannot log in until a real password is set.
+	importedPasswordHash = "!imported"
+)
+
+type ImportRow struct {
+	RowNumber int
+	Name      string
+	Email     string
+}
+
+type ImportService interface {
+	StartImport(ctx context.Context, organizerID, eventID string, data io.Reader, dryRun bool) (*models.ImportJob, error)
+	GetImportJob(ctx context.Context, organizerID, jobID string) (*models.ImportJob, error)
+	// Close waits for background imports to finish or for ctx to expire.
+	Close(ctx context.Context) error
+}
+
+type importService struct {
+	importRepo repositories.ImportRepository
+	userRepo   repositories.UserRepository
+	eventRepo  repositories.EventRepository
+	regRepo    repositories.RegistrationRepository
+	waitRepo   repositories.WaitlistRepository
+	regService RegistrationService
+
+	running sync.WaitGroup
+}
+
+func NewImportService(importRepo repositories.ImportRepository, userRepo repositories.UserRepository, eventRepo repositories.EventRepository, regRepo repositories.RegistrationRepository, waitRepo repositories.WaitlistRepository, regService RegistrationService) ImportService {
+	return &importService{
+		importRepo: importRepo,
+		userRepo:   userRepo,
+		eventRepo:  eventRepo,
+		regRepo:    regRepo,
+		waitRepo:   waitRepo,
+		regService: regService,
+	}
+}
+
+// StartImport parses the CSV and books every row through BookEvent. Small
+// files are processed inline; larger ones return a RUNNING job to poll.
+func (s *importService) StartImport(ctx context.Context, organizerID, eventID string, data io.Reader, dryRun bool) (*models.ImportJob, error) {
+	event, err := s.eventRepo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, errors.New("event not found")
+	}
+	if event.OrganizerID.String() != organizerID {
+		return nil, errors.New("unauthorized to import attendees for this event")
+	}
+	if event.Status != models.EventStatusPublished {
+		return nil, errors.New("event is not published")
+	}
+
+	rows, err := parseImportCSV(data)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.ImportJob{
+		EventID:     event.ID,
+		OrganizerID: event.OrganizerID,
+		DryRun:      dryRun,
+		Status:      models.ImportJobStatusRunning,
+		TotalRows:   len(rows),
+	}
+	if err := s.importRepo.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if len(rows) > asyncImportThreshold {
+		s.running.Add(1)
+		go func() {
+			defer s.running.Done()
+			s.process(context.Background(), job, event, rows)
+		}()
+		return job, nil
+	}
+
+	s.process(ctx, job, event, rows)
+	return s.importRepo.FindByID(ctx, job.ID.String())
+}
+
+func (s *importService) GetImportJob(ctx context.Context, organizerID, jobID string) (*models.ImportJob, error) {
+	job, err := s.importRepo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, errors.New("import job not found")
+	}
+	if job.OrganizerID.String() != organizerID {
+		return nil, errors.New("unauthorized access to import job")
+	}
+	return job, nil
+}
+
+func (s *importService) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *importService) process(ctx context.Context, job *models.ImportJob, event *models.Event, rows []ImportRow) {
+	// Dry runs predict the outcome from a snapshot of the seat count.
+	seatsLeft := event.SeatsRemaining
+	seen := make(map[string]bool)
+	batch := make([]models.ImportRowResult, 0, importBatchSize)
+
+	flush := func() error {
+		if err := s.importRepo.AddResults(ctx, batch); err != nil {
+			return err
+		}
+		batch = batch[:0]
+		return s.importRepo.Update(ctx, job)
+	}
+
+	for _, row := range rows {
+		result := s.processRow(ctx, job, event, row, seen, &seatsLeft)
+
+		job.ProcessedRows++
+		switch result.Outcome {
+		case models.ImportRowOutcomeBooked:
+			job.BookedCount++
+		case models.ImportRowOutcomeWaitlisted:
+			job.WaitlistedCount++
+		case models.ImportRowOutcomeSkipped:
+			job.SkippedCount++
+		default:
+			job.FailedCount++
+		}
+
+		batch = append(batch, result)
+		if len(batch) >= importBatchSize {
+			if err := flush(); err != nil {
+				s.fail(ctx, job, err)
+				return
+			}
+		}
+	}
+
+	job.Status = models.ImportJobStatusCompleted
+	if err := flush(); err != nil {
+		s.fail(ctx, job, err)
+	}
+}
+
+func (s *importService) processRow(ctx context.Context, job *models.ImportJob, event *models.Event, row ImportRow, seen map[string]bool, seatsLeft *int) models.ImportRowResult {
+	result := models.ImportRowResult{
+		JobID:     job.ID,
+		RowNumber: row.RowNumber,
+		Name:      row.Name,
+		Email:     row.Email,
+	}
+
+	if row.Name == "" {
+		result.Outcome = models.ImportRowOutcomeFailed
+		result.Message = "name is required"
+		return result
+	}
+	addr, err := mail.ParseAddress(row.Email)
+	if err != nil {
+		result.Outcome = models.ImportRowOutcomeFailed
+		result.Message = "invalid email"
+		return result
+	}
+	email := strings.ToLower(addr.Address)
+	result.Email = email
+
+	if seen[email] {
+		result.Outcome = models.ImportRowOutcomeSkipped
+		result.Message = "duplicate email in file"
+		return result
+	}
+	seen[email] = true
+
+	user, _ := s.userRepo.FindByEmail(ctx, email)
+	if user != nil {
+		if reg, _ := s.regRepo.FindByEventAndUser(ctx, event.ID.String(), user.ID.String()); reg != nil && reg.Status == models.RegistrationStatusConfirmed {
+			result.Outcome = models.ImportRowOutcomeSkipped
+			result.Message = "already registered for this event"
+			return result
+		}
+		if w, _ := s.waitRepo.FindByEventAndUser(ctx, event.ID.String(), user.ID.String()); w != nil {
+			result.Outcome = models.ImportRowOutcomeSkipped
+			result.Message = "already on waitlist for this event"
+			return result
+		}
+	}
+	result.UserCreated = user == nil
+
+	if job.DryRun {
+		if *seatsLeft > 0 {
+			*seatsLeft--
+			result.Outcome = models.ImportRowOutcomeBooked
+		} else {
+			result.Outcome = models.ImportRowOutcomeWaitlisted
+		}
+		return result
+	}
+
+	if user == nil {
+		user = &models.User{
+			Name:         row.Name,
+			Email:        email,
+			PasswordHash: importedPasswordHash,
+			Role:         models.RoleAudience,
+		}
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			result.Outcome = models.ImportRowOutcomeFailed
+			result.Message = "failed to create user: " + err.Error()
+			return result
+		}
+	}
+
+	_, waitlist, err := s.regService.BookEventWithoutAdmission(ctx, user.ID.String(), event.ID.String())
+	switch {
+	case err != nil:
+		result.Outcome = models.ImportRowOutcomeFailed
+		result.Message = err.Error()
+	case waitlist != nil:
+		result.Outcome = models.ImportRowOutcomeWaitlisted
+	default:
+		result.Outcome = models.ImportRowOutcomeBooked
+	}
+	return result
+}
+
+func (s *importService) fail(ctx context.Context, job *models.ImportJob, err error) {
+	slog.ErrorContext(ctx, "Import job failed", "job_id", job.ID, "error", err)
+	job.Status = models.ImportJobStatusFailed
+	job.Error = err.Error()
+	if updateErr := s.importRepo.Update(ctx, job); updateErr != nil {
+		slog.ErrorContext(ctx, "Failed to record import job failure", "job_id", job.ID, "error", updateErr)
+	}
+}
+
+// parseImportCSV expects a header row containing "name" and "email" columns
+// in any order; extra columns are ignored.
+func parseImportCSV(data io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("csv file is empty or unreadable")
+	}
+
+	nameCol, emailCol := -1, -1
+	for i, col := range header {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\uFEFF"))) {
+		case "name":
+			nameCol = i
+		case "email":
+			emailCol = i
+		}
+	}
+	if nameCol < 0 || emailCol < 0 {
+		return nil, errors.New("csv header must contain name and email columns")
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("malformed csv: " + err.Error())
+		}
+		if len(rows) >= maxImportRows {
+			return nil, errors.New("csv file exceeds the maximum number of rows")
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := ImportRow{RowNumber: line}
+		if nameCol < len(record) {
+			row.Name = strings.TrimSpace(record[nameCol])
+		}
+		if emailCol < len(record) {
+			row.Email = strings.TrimSpace(record[emailCol])
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("csv file contains no attendee rows")
+	}
+	return rows, nil
+}
@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"event_registration/internal/payments"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEventNotPaid   = errors.New("event is free: register for it directly")
+	ErrSoldOut        = errors.New("event is sold out")
+	ErrOrderPending   = errors.New("an order for this event is already awaiting payment")
+	ErrOrderNotFound  = errors.New("order not found")
+	ErrPaymentFailure = errors.New("payment provider unavailable, please retry")
+)
+
+// OrderRequest picks the ticket tier and promo code for an order. Both are
+// optional: the default is general admission at the event's price.
+type OrderRequest struct {
+	TicketTypeID string `json:"ticket_type_id"`
+	PromoCode    string `json:"promo_code"`
+}
+
+// expiryBatchSize bounds how many lapsed holds one expiry pass releases.
+const expiryBatchSize = 100
+
+type OrderService interface {
+	// PlaceOrder holds a seat for a paid event and starts the payment. The
+	// registration is confirmed by HandleWebhook once the payment succeeds,
+	// or at once when a promo code brings the total to zero. Placing again
+	// while the user's order is pending returns that order.
+	PlaceOrder(ctx context.Context, userID, eventID string, req OrderRequest) (*models.Order, error)
+	GetOrder(ctx context.Context, userID, orderID string) (*models.Order, error)
+	ListUserOrders(ctx context.Context, userID string) ([]models.Order, error)
+	// HandleWebhook applies a payment outcome reported by the provider.
+	// Duplicate deliveries are harmless.
+	HandleWebhook(ctx context.Context, payload []byte, signature string) (*models.Order, error)
+	// ExpireHolds fails pending orders whose hold lapsed and frees their seats.
+	ExpireHolds(ctx context.Context) (int, error)
+	RunExpiryJob(ctx context.Context, interval time.Duration)
+}
+
+type orderService struct {
+	db             *gorm.DB
+	orderRepo      repositories.OrderRepository
+	eventRepo      repositories.EventRepository
+	auditRepo      repositories.AuditLogRepository
+	refundRepo     repositories.RefundRepository
+	promoRepo      repositories.PromoRepository
+	ticketTypeRepo repositories.TicketTypeRepository
+	allocators     *SeatAllocators
+	provider       payments.PaymentProvider
+	refunds        RefundService
+	holdTTL        time.Duration
+}
+
+func NewOrderService(db *gorm.DB, orderRepo repositories.OrderRepository, eventRepo repositories.EventRepository, auditRepo repositories.AuditLogRepository, refundRepo repositories.RefundRepository, promoRepo repositories.PromoRepository, ticketTypeRepo repositories.TicketTypeRepository, allocators *SeatAllocators, provider payments.PaymentProvider, refunds RefundService, holdTTL time.Duration) OrderService {
+	return &orderService{
+		db:             db,
+		orderRepo:      orderRepo,
+		eventRepo:      eventRepo,
+		auditRepo:      auditRepo,
+		refundRepo:     refundRepo,
+		promoRepo:      promoRepo,
+		ticketTypeRepo: ticketTypeRepo,
+		allocators:     allocators,
+		provider:       provider,
+		refunds:        refunds,
+		holdTTL:        holdTTL,
+	}
+}
+
+func (s *orderService) PlaceOrder(ctx context.Context, userID, eventID string, req OrderRequest) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.PlaceOrder",
+		attribute.String("event.id", eventID),
+		attribute.String("user.id", userID),
+		attribute.String("order.ticket_type_id", req.TicketTypeID),
+		attribute.Bool("order.promo_code", req.PromoCode != ""),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if existing, err := s.orderRepo.FindPendingByEventAndUser(ctx, eventID, userID); err == nil {
+		return existing, nil
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+	var ticketTypeID *uuid.UUID
+	if req.TicketTypeID != "" {
+		id, err := uuid.Parse(req.TicketTypeID)
+		if err != nil {
+			return nil, ErrTicketTypeNotFound
+		}
+		ticketTypeID = &id
+	}
+
+	var order *models.Order
+	allocator := s.allocators.For(s.eventRepo.FindAllocationStrategy(ctx, eventID))
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, reserved, err := allocator.Reserve(ctx, tx, eventID)
+		if err != nil {
+			return err
+		}
+		if !event.IsPaid() {
+			return ErrEventNotPaid
+		}
+		if err := ensureNotBooked(tx, eventID, userID); err != nil {
+			return err
+		}
+		if _, err := s.orderRepo.WithTx(tx).FindPendingByEventAndUser(ctx, eventID, userID); err == nil {
+			return ErrOrderPending
+		}
+		if err := useAdmission(ctx, tx, eventID, userID); err != nil {
+			return err
+		}
+		if !reserved {
+			return ErrSoldOut
+		}
+
+		// The code is claimed under its row lock after the seat, so every
+		// order path takes the event row before the promo code row.
+		var promo *models.PromoCode
+		if req.PromoCode != "" {
+			if promo, err = claimPromo(ctx, tx, s.promoRepo, eventID, req.PromoCode, userID, ticketTypeID); err != nil {
+				return err
+			}
+		}
+		ticketType, err := resolveTicketType(ctx, tx, s.ticketTypeRepo, event.ID, ticketTypeID, promo)
+		if err != nil {
+			return err
+		}
+
+		var discount models.Money
+		order, discount, err = newTicketOrder(event, ticketType, promo, userUUID, s.provider.Name(), time.Now().Add(s.holdTTL))
+		if err != nil {
+			return err
+		}
+		if err := s.orderRepo.WithTx(tx).Create(ctx, order); err != nil {
+			return err
+		}
+		if promo != nil {
+			if err := redeemPromo(ctx, tx, s.promoRepo, promo, order, discount); err != nil {
+				return err
+			}
+		}
+		if err := recordActivity(ctx, tx, s.auditRepo, userUUID, models.AuditActionOrderPlaced, models.AuditEntityOrder, order.ID, event.ID); err != nil {
+			return err
+		}
+		// Nothing to charge: confirm the seat without a payment.
+		if order.Total.IsZero() {
+			return s.completeOrder(ctx, tx, order)
+		}
+		return nil
+	})
+	if err != nil {
+		metrics.BookingFailuresTotal.WithLabelValues(bookingFailureReason(err)).Inc()
+		return nil, err
+	}
+	metrics.OrdersTotal.WithLabelValues("placed").Inc()
+	span.SetAttributes(attribute.String("order.id", order.ID.String()))
+	if order.Status == models.OrderStatusPaid {
+		metrics.OrdersTotal.WithLabelValues("paid").Inc()
+		slog.InfoContext(ctx, "Order confirmed without payment", "order_id", order.ID, "event_id", eventID, "user_id", userID)
+		return order, nil
+	}
+
+	// The provider is called outside the transaction so a slow provider
+	// does not hold the event row lock.
+	payment, err := s.provider.CreatePayment(ctx, payments.PaymentRequest{
+		OrderID:     order.ID,
+		Amount:      order.Total,
+		Description: order.Items[0].Description,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Payment creation failed", "order_id", order.ID, "provider", s.provider.Name(), "error", err)
+		if releaseErr := s.failPending(context.WithoutCancel(ctx), order.ID.String(), "payment could not be started", "failed"); releaseErr != nil {
+			slog.ErrorContext(ctx, "Failed to release seat hold", "order_id", order.ID, "error", releaseErr)
+		}
+		return nil, ErrPaymentFailure
+	}
+	if err := s.orderRepo.SetPayment(ctx, order.ID.String(), payment.Ref, payment.CheckoutURL); err != nil {
+		return nil, err
+	}
+	order.PaymentRef = payment.Ref
+	order.CheckoutURL = payment.CheckoutURL
+
+	slog.InfoContext(ctx, "Order placed", "order_id", order.ID, "event_id", eventID, "user_id", userID, "total", order.Total.String())
+	return order, nil
+}
+
+// newTicketOrder builds a pending order for one ticket of the tier (nil for
+// general admission), less the promo code's discount, which it returns.
+func newTicketOrder(event models.Event, ticketType *models.TicketType, promo *models.PromoCode, userID uuid.UUID, provider string, expiresAt time.Time) (*models.Order, models.Money, error) {
+	description, price := "Ticket: "+event.Title, event.Price
+	var ticketTypeID *uuid.UUID
+	if ticketType != nil {
+		description += " (" + ticketType.Name + ")"
+		price = ticketType.Price
+		ticketTypeID = &ticketType.ID
+	}
+	items := []models.OrderItem{{
+		Kind:        models.OrderItemTicket,
+		Description: description,
+		UnitPrice:   price,
+		Quantity:    1,
+		Total:       price.Mul(1),
+	}}
+
+	var discount models.Money
+	var promoCodeID *uuid.UUID
+	if promo != nil {
+		promoCodeID = &promo.ID
+		if discount = promo.Discount(price); !discount.IsZero() {
+			items = append(items, models.OrderItem{
+				Kind:        models.OrderItemDiscount,
+				Description: "Promo code " + promo.Code,
+				UnitPrice:   discount.Mul(-1),
+				Quantity:    1,
+				Total:       discount.Mul(-1),
+			})
+		}
+	}
+
+	var total models.Money
+	for _, item := range items {
+		var err error
+		if total, err = total.Add(item.Total); err != nil {
+			return nil, models.Money{}, err
+		}
+	}
+
+	return &models.Order{
+		UserID:          userID,
+		EventID:         event.ID,
+		Status:          models.OrderStatusPending,
+		Total:           total,
+		TicketTypeID:    ticketTypeID,
+		PromoCodeID:     promoCodeID,
+		PaymentProvider: provider,
+		ExpiresAt:       expiresAt,
+		Items:           items,
+	}, discount, nil
+}
+
+func (s *orderService) GetOrder(ctx context.Context, userID, orderID string) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.GetOrder", attribute.String("order.id", orderID))
+	defer func() { tracing.End(span, err) }()
+
+	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if err != nil || order.UserID.String() != userID {
+		return nil, ErrOrderNotFound
+	}
+	return order, nil
+}
+
+func (s *orderService) ListUserOrders(ctx context.Context, userID string) (_ []models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.ListUserOrders", attribute.String("user.id", userID))
+	defer func() { tracing.End(span, err) }()
+
+	return s.orderRepo.FindByUser(ctx, userID)
+}
+
+func (s *orderService) HandleWebhook(ctx context.Context, payload []byte, signature string) (_ *models.Order, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.HandleWebhook", attribute.String("payment.provider", s.provider.Name()))
+	defer func() { tracing.End(span, err) }()
+
+	hook, err := s.provider.ParseWebhook(payload, signature)
+	if err != nil {
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("payment.ref", hook.Ref), attribute.Bool("payment.succeeded", hook.Succeeded))
+
+	var order *models.Order
+	outcome, refundID := "", ""
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err = s.orderRepo.WithTx(tx).LockByPaymentRef(ctx, s.provider.Name(), hook.Ref)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+
+		switch {
+		case order.Status == models.OrderStatusPending && hook.Succeeded:
+			outcome = "paid"
+			return s.completeOrder(ctx, tx, order)
+		case order.Status == models.OrderStatusPending:
+			outcome = "failed"
+			return s.releaseHold(ctx, tx, order, hook.Reason)
+		case order.Status == models.OrderStatusFailed && hook.Succeeded:
+			outcome, refundID, err = s.settleLatePayment(ctx, tx, order)
+			return err
+		}
+		// A repeated or out-of-order delivery for a settled order.
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if outcome != "" {
+		metrics.OrdersTotal.WithLabelValues(outcome).Inc()
+		slog.InfoContext(ctx, "Payment webhook applied", "order_id", order.ID, "payment_ref", hook.Ref, "outcome", outcome)
+	}
+	if refundID != "" {
+		slog.WarnContext(ctx, "Payment arrived after the seat hold was released; refunding", "order_id", order.ID, "event_id", order.EventID)
+		if _, err := s.refunds.Issue(ctx, refundID); err != nil {
+			slog.ErrorContext(ctx, "Failed to issue refund", "refund_id", refundID, "error", err)
+		}
+	}
+	return order, nil
+}
+
+// completeOrder turns the held seat into a confirmed registration. The seat
+// was taken when the order was placed, so the counter does not change.
+func (s *orderService) completeOrder(ctx context.Context, tx *gorm.DB, order *models.Order) error {
+	reg, err := confirmSeat(ctx, tx, s.auditRepo, order.EventID, order.UserID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	order.Status = models.OrderStatusPaid
+	order.PaidAt = &now
+	order.RegistrationID = &reg.ID
+	order.FailureReason = ""
+	if err := s.orderRepo.WithTx(tx).Update(ctx, order); err != nil {
+		return err
+	}
+	return recordActivity(ctx, tx, s.auditRepo, order.UserID, models.AuditActionOrderPaid, models.AuditEntityOrder, order.ID, order.EventID)
+}
+
+// releaseHold fails a pending order and gives its seat back. The caller holds
+// the order row lock.
+func (s *orderService) releaseHold(ctx context.Context, tx *gorm.DB, order *models.Order, reason string) error {
+	event, err := lockEvent(ctx, tx, order.EventID.String(), "order_release")
+	if err != nil {
+		return err
+	}
+	return releaseOrderHold(ctx, tx, s.orderRepo, s.promoRepo, s.auditRepo, order, &event, reason)
+}
+
+// releaseOrderHold fails a pending order and returns its seat to event and
+// its promo code use. The caller holds the order and event row locks.
+func releaseOrderHold(ctx context.Context, tx *gorm.DB, orderRepo repositories.OrderRepository, promoRepo repositories.PromoRepository, auditRepo repositories.AuditLogRepository, order *models.Order, event *models.Event, reason string) error {
+	if err := releaseSeat(tx, event); err != nil {
+		return err
+	}
+	if err := releasePromo(ctx, tx, promoRepo, order); err != nil {
+		return err
+	}
+
+	order.Status = models.OrderStatusFailed
+	order.FailureReason = reason
+	if err := orderRepo.WithTx(tx).Update(ctx, order); err != nil {
+		return err
+	}
+	return recordActivity(ctx, tx, auditRepo, order.UserID, models.AuditActionOrderFailed, models.AuditEntityOrder, order.ID, order.EventID)
+}
+
+// settleLatePayment handles a successful payment for an order whose hold
+// already lapsed: it takes a seat again if one is free and the order's promo
+// code can still be redeemed, and otherwise leaves the order failed and
+// queues a full refund, returned as refundID.
+func (s *orderService) settleLatePayment(ctx context.Context, tx *gorm.DB, order *models.Order) (outcome, refundID string, err error) {
+	event, err := lockEvent(ctx, tx, order.EventID.String(), "order_late_payment")
+	if err != nil {
+		return "", "", err
+	}
+	reason := "payment received after the seat hold was released; refunded"
+	if validateBookable(event) == nil && event.SeatsRemaining > 0 &&
+		ensureNotBooked(tx, order.EventID.String(), order.UserID.String()) == nil {
+		err := reclaimPromo(ctx, tx, s.promoRepo, order)
+		switch {
+		case err == nil:
+			if err := takeSeat(tx, &event); err != nil {
+				return "", "", err
+			}
+			return "paid", "", s.completeOrder(ctx, tx, order)
+		case errors.Is(err, ErrPromoInvalid), errors.Is(err, ErrPromoExhausted),
+			errors.Is(err, ErrPromoUserLimit), errors.Is(err, ErrPromoNotApplicable):
+			reason = "payment received after the seat hold was released and the promo code can no longer be used; refunded"
+		default:
+			return "", "", err
+		}
+	}
+
+	now := time.Now()
+	order.PaidAt = &now
+	order.FailureReason = reason
+	if err := s.orderRepo.WithTx(tx).Update(ctx, order); err != nil {
+		return "", "", err
+	}
+	refund, err := queueRefund(ctx, tx, s.refundRepo, s.auditRepo, order, order.Total, models.RefundReasonLatePayment, order.UserID, order.FailureReason)
+	if err != nil {
+		return "", "", err
+	}
+	return "late_payment", refund.ID.String(), nil
+}
+
+// reclaimPromo redeems the order's promo code again for a late payment. The
+// expired hold gave the use back, so the code is claimed under its row lock
+// like a new order and fails the same way once it is used up.
+func reclaimPromo(ctx context.Context, tx *gorm.DB, promoRepo repositories.PromoRepository, order *models.Order) error {
+	if order.PromoCodeID == nil {
+		return nil
+	}
+	code, err := promoRepo.WithTx(tx).FindByID(ctx, order.PromoCodeID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPromoInvalid
+	}
+	if err != nil {
+		return err
+	}
+	promo, err := claimPromo(ctx, tx, promoRepo, order.EventID.String(), code.Code, order.UserID.String(), order.TicketTypeID)
+	if err != nil {
+		return err
+	}
+
+	var items []models.OrderItem
+	if err := tx.Where("order_id = ? AND kind = ?", order.ID, models.OrderItemDiscount).Find(&items).Error; err != nil {
+		return err
+	}
+	var discount models.Money
+	for _, item := range items {
+		if discount, err = discount.Add(item.Total.Mul(-1)); err != nil {
+			return err
+		}
+	}
+	return redeemPromo(ctx, tx, promoRepo, promo, order, discount)
+}
+
+// failPending releases the hold of an order that is still pending; outcome
+// labels the transition in metrics.
+func (s *orderService) failPending(ctx context.Context, orderID, reason, outcome string) error {
+	released := false
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.WithTx(tx).LockByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != models.OrderStatusPending {
+			return nil
+		}
+		released = true
+		return s.releaseHold(ctx, tx, order, reason)
+	})
+	if err == nil && released {
+		metrics.OrdersTotal.WithLabelValues(outcome).Inc()
+	}
+	return err
+}
+
+func (s *orderService) ExpireHolds(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "OrderService.ExpireHolds")
+	defer func() { tracing.End(span, err) }()
+
+	ids, err := s.orderRepo.FindExpiredPending(ctx, time.Now(), expiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := s.failPending(ctx, id, "payment hold expired", "expired"); err != nil {
+			return 0, fmt.Errorf("expire order %s: %w", id, err)
+		}
+	}
+	span.SetAttributes(attribute.Int("orders.expired", len(ids)))
+	return len(ids), nil
+}
+
+// RunExpiryJob releases lapsed seat holds every interval until ctx is done.
+func (s *orderService) RunExpiryJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := s.ExpireHolds(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.ErrorContext(ctx, "Order hold expiry failed", "error", err)
+			continue
+		}
+		if expired > 0 {
+			slog.InfoContext(ctx, "Released expired order holds", "count", expired)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPromoInvalid       = errors.New("promo code is not valid for this event")
+	ErrPromoExhausted     = errors.New("promo code has reached its usage limit")
+	ErrPromoUserLimit     = errors.New("you have already used this promo code the maximum number of times")
+	ErrPromoNotApplicable = errors.New("promo code does not apply to this ticket type")
+	ErrTicketTypeNotFound = errors.New("ticket type not found")
+	ErrPromoCodeTaken     = errors.New("a promo code with this code already exists for the event")
+)
+
+// PromoCodeRequest is what an organizer submits to create a promo code.
+// AmountOff is in minor units of the event's currency.
+type PromoCodeRequest struct {
+	Code           string              `json:"code"`
+	DiscountType   models.DiscountType `json:"discount_type"`
+	PercentOff     int                 `json:"percent_off"`
+	AmountOff      int64               `json:"amount_off"`
+	MaxUses        int                 `json:"max_uses"`
+	MaxUsesPerUser int                 `json:"max_uses_per_user"`
+	ValidFrom      *time.Time          `json:"valid_from"`
+	ValidUntil     *time.Time          `json:"valid_until"`
+	TicketTypeIDs  []string            `json:"ticket_type_ids"`
+}
+
+type PromoService interface {
+	CreateTicketType(ctx context.Context, organizerID, eventID string, ticketType *models.TicketType) error
+	// ListTicketTypes returns the tiers a buyer can see: every public tier,
+	// plus the hidden ones code unlocks.
+	ListTicketTypes(ctx context.Context, eventID, code string) ([]models.TicketType, error)
+	CreatePromoCode(ctx context.Context, organizerID, eventID string, req PromoCodeRequest) (*models.PromoCode, error)
+	ListPromoCodes(ctx context.Context, organizerID, eventID string) ([]models.PromoCode, error)
+	// DeactivatePromoCode stops further redemptions; orders already placed
+	// keep their discount.
+	DeactivatePromoCode(ctx context.Context, organizerID, codeID string) (*models.PromoCode, error)
+}
+
+type promoService struct {
+	promoRepo      repositories.PromoRepository
+	ticketTypeRepo repositories.TicketTypeRepository
+	eventRepo      repositories.EventRepository
+}
+
+func NewPromoService(promoRepo repositories.PromoRepository, ticketTypeRepo repositories.TicketTypeRepository, eventRepo repositories.EventRepository) PromoService {
+	return &promoService{
+		promoRepo:      promoRepo,
+		ticketTypeRepo: ticketTypeRepo,
+		eventRepo:      eventRepo,
+	}
+}
+
+// organizerEvent loads an event the organizer owns.
+func (s *promoService) organizerEvent(ctx context.Context, organizerID, eventID string) (*models.Event, error) {
+	event, err := s.eventRepo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+	if event.OrganizerID.String() != organizerID {
+		return nil, errors.New("unauthorized to update this event")
+	}
+	return event, nil
+}
+
+func (s *promoService) CreateTicketType(ctx context.Context, organizerID, eventID string, ticketType *models.TicketType) (err error) {
+	ctx, span := tracing.Start(ctx, "PromoService.CreateTicketType", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	event, err := s.organizerEvent(ctx, organizerID, eventID)
+	if err != nil {
+		return err
+	}
+	if !event.IsPaid() {
+		return errors.New("ticket types are only available for paid events")
+	}
+	ticketType.Name = strings.TrimSpace(ticketType.Name)
+	if ticketType.Name == "" {
+		return errors.New("name is required")
+	}
+	if ticketType.Price.Amount < 0 {
+		return errors.New("price must not be negative")
+	}
+	ticketType.Price.Currency = strings.ToUpper(strings.TrimSpace(ticketType.Price.Currency))
+	if ticketType.Price.Currency == "" {
+		ticketType.Price.Currency = event.Price.Currency
+	}
+	if ticketType.Price.Currency != event.Price.Currency {
+		return fmt.Errorf("ticket types must be priced in %s like the event", event.Price.Currency)
+	}
+
+	ticketType.ID = uuid.Nil
+	ticketType.EventID = event.ID
+	return s.ticketTypeRepo.Create(ctx, ticketType)
+}
+
+func (s *promoService) ListTicketTypes(ctx context.Context, eventID, code string) (_ []models.TicketType, err error) {
+	ctx, span := tracing.Start(ctx, "PromoService.ListTicketTypes", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	all, err := s.ticketTypeRepo.FindByEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	var promo *models.PromoCode
+	if code = models.NormalizePromoCode(code); code != "" {
+		if promo, err = s.promoRepo.FindByCode(ctx, eventID, code); err != nil || !promo.OpenAt(time.Now()) {
+			return nil, ErrPromoInvalid
+		}
+	}
+
+	visible := make([]models.TicketType, 0, len(all))
+	for _, t := range all {
+		if !t.Hidden || (promo != nil && promo.Unlocks(t.ID)) {
+			visible = append(visible, t)
+		}
+	}
+	return visible, nil
+}
+
+func (s *promoService) CreatePromoCode(ctx context.Context, organizerID, eventID string, req PromoCodeRequest) (_ *models.PromoCode, err error) {
+	ctx, span := tracing.Start(ctx, "PromoService.CreatePromoCode",
+		attribute.String("event.id", eventID),
+		attribute.String("promo.discount_type", string(req.DiscountType)),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	event, err := s.organizerEvent(ctx, organizerID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	if !event.IsPaid() {
+		return nil, errors.New("promo codes are only available for paid events")
+	}
+
+	promo := &models.PromoCode{
+		EventID:        event.ID,
+		Code:           models.NormalizePromoCode(req.Code),
+		DiscountType:   req.DiscountType,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+		Active:         true,
+	}
+	switch {
+	case promo.Code == "" || len(promo.Code) > 64:
+		return nil, errors.New("code must be between 1 and 64 characters")
+	case !req.DiscountType.Valid():
+		return nil, errors.New("discount_type must be PERCENT, FIXED or ACCESS")
+	case req.MaxUses < 0 || req.MaxUsesPerUser < 0:
+		return nil, errors.New("usage limits must not be negative")
+	case req.ValidFrom != nil && req.ValidUntil != nil && !req.ValidUntil.After(*req.ValidFrom):
+		return nil, errors.New("valid_until must be after valid_from")
+	}
+	switch req.DiscountType {
+	case models.DiscountPercent:
+		if req.PercentOff < 1 || req.PercentOff > 100 {
+			return nil, errors.New("percent_off must be between 1 and 100")
+		}
+		promo.PercentOff = req.PercentOff
+	case models.DiscountFixed:
+		if req.AmountOff <= 0 {
+			return nil, errors.New("amount_off must be positive")
+		}
+		promo.AmountOff = models.Money{Amount: req.AmountOff, Currency: event.Price.Currency}
+	case models.DiscountAccess:
+		if len(req.TicketTypeIDs) == 0 {
+			return nil, errors.New("an access code must name the ticket types it unlocks")
+		}
+	}
+
+	if len(req.TicketTypeIDs) > 0 {
+		eventTypes, err := s.ticketTypeRepo.FindByEvent(ctx, eventID)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]models.TicketType, len(eventTypes))
+		for _, t := range eventTypes {
+			byID[t.ID.String()] = t
+		}
+		for _, id := range req.TicketTypeIDs {
+			t, ok := byID[id]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrTicketTypeNotFound, id)
+			}
+			promo.TicketTypes = append(promo.TicketTypes, t)
+		}
+	}
+
+	if _, err := s.promoRepo.FindByCode(ctx, eventID, promo.Code); err == nil {
+		return nil, ErrPromoCodeTaken
+	}
+	if err := s.promoRepo.Create(ctx, promo); err != nil {
+		return nil, err
+	}
+	return promo, nil
+}
+
+func (s *promoService) ListPromoCodes(ctx context.Context, organizerID, eventID string) (_ []models.PromoCode, err error) {
+	ctx, span := tracing.Start(ctx, "PromoService.ListPromoCodes", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.organizerEvent(ctx, organizerID, eventID); err != nil {
+		return nil, err
+	}
+	return s.promoRepo.FindByEvent(ctx, eventID)
+}
+
+func (s *promoService) DeactivatePromoCode(ctx context.Context, organizerID, codeID string) (_ *models.PromoCode, err error) {
+	ctx, span := tracing.Start(ctx, "PromoService.DeactivatePromoCode", attribute.String("promo.id", codeID))
+	defer func() { tracing.End(span, err) }()
+
+	promo, err := s.promoRepo.FindByID(ctx, codeID)
+	if err != nil {
+		return nil, ErrPromoInvalid
+	}
+	if _, err := s.organizerEvent(ctx, organizerID, promo.EventID.String()); err != nil {
+		return nil, err
+	}
+
+	if err := s.promoRepo.Deactivate(ctx, codeID); err != nil {
+		return nil, err
+	}
+	return s.promoRepo.FindByID(ctx, codeID)
+}
+
+// claimPromo locks the event's promo code and checks that userID may redeem
+// it now for the tier (nil for general admission). The lock is held until
+// the caller's transaction ends, so the usage checks and redeemPromo are one
+// atomic step and a limited code cannot be oversold.
+func claimPromo(ctx context.Context, tx *gorm.DB, promoRepo repositories.PromoRepository, eventID, code, userID string, ticketTypeID *uuid.UUID) (*models.PromoCode, error) {
+	promo, err := promoRepo.WithTx(tx).LockByCode(ctx, eventID, models.NormalizePromoCode(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPromoInvalid
+		}
+		return nil, err
+	}
+	if !promo.OpenAt(time.Now()) {
+		return nil, ErrPromoInvalid
+	}
+	if !promo.AppliesTo(ticketTypeID) {
+		return nil, ErrPromoNotApplicable
+	}
+	if promo.MaxUses > 0 && promo.UsedCount >= promo.MaxUses {
+		return nil, ErrPromoExhausted
+	}
+	if promo.MaxUsesPerUser > 0 {
+		used, err := promoRepo.WithTx(tx).CountUserRedemptions(ctx, promo.ID.String(), userID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(promo.MaxUsesPerUser) {
+			return nil, ErrPromoUserLimit
+		}
+	}
+	return promo, nil
+}
+
+// redeemPromo records the use of a code claimed with claimPromo.
+func redeemPromo(ctx context.Context, tx *gorm.DB, promoRepo repositories.PromoRepository, promo *models.PromoCode, order *models.Order, discount models.Money) error {
+	if err := promoRepo.WithTx(tx).CreateRedemption(ctx, &models.PromoRedemption{
+		PromoCodeID: promo.ID,
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		Discount:    discount,
+	}); err != nil {
+		return err
+	}
+	return promoRepo.WithTx(tx).AddUses(ctx, promo.ID.String(), 1)
+}
+
+// releasePromo gives back the use an order made of a promo code, if any.
+func releasePromo(ctx context.Context, tx *gorm.DB, promoRepo repositories.PromoRepository, order *models.Order) error {
+	if order.PromoCodeID == nil {
+		return nil
+	}
+	redemption, err := promoRepo.WithTx(tx).FindRedemptionByOrder(ctx, order.ID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := promoRepo.WithTx(tx).DeleteRedemption(ctx, redemption); err != nil {
+		return err
+	}
+	return promoRepo.WithTx(tx).AddUses(ctx, redemption.PromoCodeID.String(), -1)
+}
+
+// resolveTicketType returns the tier an order is for; a nil ticketTypeID is
+// general admission at the event's price. Hidden tiers are only found with a
+// promo code that unlocks them.
+func resolveTicketType(ctx context.Context, tx *gorm.DB, ticketTypeRepo repositories.TicketTypeRepository, eventID uuid.UUID, ticketTypeID *uuid.UUID, promo *models.PromoCode) (*models.TicketType, error) {
+	if ticketTypeID == nil {
+		return nil, nil
+	}
+	ticketType, err := ticketTypeRepo.WithTx(tx).FindByID(ctx, ticketTypeID.String())
+	if err != nil || ticketType.EventID != eventID {
+		return nil, ErrTicketTypeNotFound
+	}
+	if ticketType.Hidden && (promo == nil || !promo.Unlocks(ticketType.ID)) {
+		return nil, ErrTicketTypeNotFound
+	}
+	return ticketType, nil
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// ReconciliationReport lists the seat inconsistencies found by a check and,
+// for a repair run, what was changed.
+type ReconciliationReport struct {
+	CheckedAt         time.Time                 `json:"checked_at"`
+	SeatDrift         []models.SeatDrift        `json:"seat_drift"`
+	OrphanedWaitlists []models.OrphanedWaitlist `json:"orphaned_waitlists"`
+	Repairs           []EventRepair             `json:"repairs,omitempty"`
+}
+
+func (r *ReconciliationReport) Consistent() bool {
+	return len(r.SeatDrift) == 0 && len(r.OrphanedWaitlists) == 0
+}
+
+// EventRepair describes the fix applied to one event. Overbooked events have
+// more confirmed registrations and held seats than capacity; the counter is
+// clamped to zero but the extra registrations need a human decision.
+type EventRepair struct {
+	EventID     uuid.UUID `json:"event_id"`
+	SeatsBefore int       `json:"seats_before"`
+	SeatsAfter  int       `json:"seats_after"`
+	Promoted    int       `json:"promoted"`
+	Overbooked  bool      `json:"overbooked"`
+}
+
+type ReconciliationService interface {
+	Check(ctx context.Context) (*ReconciliationReport, error)
+	// Repair checks, then fixes every affected event under its row lock.
+	// actorID attributes seat-count fixes in the audit log; it may be empty
+	// for the scheduled job.
+	Repair(ctx context.Context, actorID string) (*ReconciliationReport, error)
+	// RepairEvent fixes one event whether or not a check flagged it. It
+	// returns nil when the event was already consistent.
+	RepairEvent(ctx context.Context, eventID, actorID string) (*EventRepair, error)
+	RunJob(ctx context.Context, interval time.Duration, autoRepair bool)
+}
+
+type reconciliationService struct {
+	db            *gorm.DB
+	reconcileRepo repositories.ReconciliationRepository
+	regRepo       repositories.RegistrationRepository
+	waitRepo      repositories.WaitlistRepository
+	orderRepo     repositories.OrderRepository
+	auditRepo     repositories.AuditLogRepository
+}
+
+func NewReconciliationService(db *gorm.DB, reconcileRepo repositories.ReconciliationRepository, regRepo repositories.RegistrationRepository, waitRepo repositories.WaitlistRepository, orderRepo repositories.OrderRepository, auditRepo repositories.AuditLogRepository) ReconciliationService {
+	return &reconciliationService{
+		db:            db,
+		reconcileRepo: reconcileRepo,
+		regRepo:       regRepo,
+		waitRepo:      waitRepo,
+		orderRepo:     orderRepo,
+		auditRepo:     auditRepo,
+	}
+}
+
+func (s *reconciliationService) Check(ctx context.Context) (_ *ReconciliationReport, err error) {
+	ctx, span := tracing.Start(ctx, "ReconciliationService.Check")
+	defer func() { tracing.End(span, err) }()
+
+	report := &ReconciliationReport{CheckedAt: time.Now()}
+	if report.SeatDrift, err = s.reconcileRepo.FindSeatDrift(ctx); err != nil {
+		return nil, err
+	}
+	if report.OrphanedWaitlists, err = s.reconcileRepo.FindOrphanedWaitlists(ctx); err != nil {
+		return nil, err
+	}
+
+	metrics.ReconciliationIssues.WithLabelValues("seat_drift").Set(float64(len(report.SeatDrift)))
+	metrics.ReconciliationIssues.WithLabelValues("orphaned_waitlist").Set(float64(len(report.OrphanedWaitlists)))
+	span.SetAttributes(
+		attribute.Int("reconcile.seat_drift", len(report.SeatDrift)),
+		attribute.Int("reconcile.orphaned_waitlists", len(report.OrphanedWaitlists)),
+	)
+	return report, nil
+}
+
+func (s *reconciliationService) Repair(ctx context.Context, actorID string) (_ *ReconciliationReport, err error) {
+	ctx, span := tracing.Start(ctx, "ReconciliationService.Repair")
+	defer func() { tracing.End(span, err) }()
+
+	var actor uuid.UUID
+	if actorID != "" {
+		if actor, err = uuid.Parse(actorID); err != nil {
+			return nil, errors.New("invalid actor ID")
+		}
+	}
+
+	report, err := s.Check(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// One event may show up in both lists; repair it once.
+	seen := make(map[uuid.UUID]bool)
+	var eventIDs []uuid.UUID
+	for _, d := range report.SeatDrift {
+		if !seen[d.EventID] {
+			seen[d.EventID] = true
+			eventIDs = append(eventIDs, d.EventID)
+		}
+	}
+	for _, o := range report.OrphanedWaitlists {
+		if !seen[o.EventID] {
+			seen[o.EventID] = true
+			eventIDs = append(eventIDs, o.EventID)
+		}
+	}
+
+	for _, eventID := range eventIDs {
+		repair, err := s.repairEvent(ctx, eventID, actor)
+		if err != nil {
+			return report, err
+		}
+		if repair != nil {
+			report.Repairs = append(report.Repairs, *repair)
+		}
+	}
+	return report, nil
+}
+
+func (s *reconciliationService) RepairEvent(ctx context.Context, eventID, actorID string) (_ *EventRepair, err error) {
+	ctx, span := tracing.Start(ctx, "ReconciliationService.RepairEvent", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+	var actor uuid.UUID
+	if actorID != "" {
+		if actor, err = uuid.Parse(actorID); err != nil {
+			return nil, errors.New("invalid actor ID")
+		}
+	}
+	return s.repairEvent(ctx, id, actor)
+}
+
+// repairEvent recomputes the event's seat state under the row lock, so the
+// fix cannot race with concurrent bookings or cancellations. It returns nil
+// when the event turned out to be consistent by the time the lock was held.
+func (s *reconciliationService) repairEvent(ctx context.Context, eventID, actor uuid.UUID) (*EventRepair, error) {
+	var repair *EventRepair
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		event, err := lockEvent(ctx, tx, eventID.String(), "reconcile")
+		if err != nil {
+			return err
+		}
+
+		confirmed, err := s.regRepo.WithTx(tx).CountByEventAndStatus(ctx, eventID.String(), models.RegistrationStatusConfirmed)
+		if err != nil {
+			return err
+		}
+		// Seats held by pending orders are taken until the order settles.
+		held, err := s.orderRepo.WithTx(tx).CountPendingByEvent(ctx, eventID.String())
+		if err != nil {
+			return err
+		}
+		r := EventRepair{EventID: eventID, SeatsBefore: event.SeatsRemaining}
+		free := event.Capacity - int(confirmed) - int(held)
+		if free < 0 {
+			r.Overbooked = true
+			free = 0
+		}
+
+		// The recomputed count is written before promoting, which takes
+		// seats relative to it.
+		if free != event.SeatsRemaining || r.Overbooked {
+			if err := tx.Model(&event).Updates(map[string]any{
+				"seats_remaining": free,
+				"version":         gorm.Expr("version + 1"),
+			}).Error; err != nil {
+				return err
+			}
+			event.SeatsRemaining = free
+		}
+
+		// Only promote into events people can still attend.
+		if event.Status == models.EventStatusPublished && event.EventDate.After(time.Now()) {
+			if r.Promoted, err = promoteWaitlist(ctx, tx, s.waitRepo, s.auditRepo, &event); err != nil {
+				return err
+			}
+		}
+
+		r.SeatsAfter = event.SeatsRemaining
+		if r.SeatsAfter == r.SeatsBefore && r.Promoted == 0 && !r.Overbooked {
+			return nil
+		}
+		if r.SeatsAfter != r.SeatsBefore && actor != uuid.Nil {
+			if err := s.auditRepo.WithTx(tx).Create(ctx, &models.AuditLog{
+				ActorID:    actor,
+				Action:     models.AuditActionSeatCountRepaired,
+				EntityType: models.AuditEntityEvent,
+				EntityID:   event.ID,
+				EventID:    &event.ID,
+			}); err != nil {
+				return err
+			}
+		}
+		repair = &r
+		return nil
+	})
+	if err != nil || repair == nil {
+		return nil, err
+	}
+
+	if repair.SeatsAfter != repair.SeatsBefore {
+		metrics.ReconciliationRepairsTotal.WithLabelValues("seat_drift").Inc()
+	}
+	if repair.Promoted > 0 {
+		metrics.ReconciliationRepairsTotal.WithLabelValues("orphaned_waitlist").Add(float64(repair.Promoted))
+		metrics.PromotionsTotal.Add(float64(repair.Promoted))
+	}
+	slog.WarnContext(ctx, "Repaired event seat state",
+		"event_id", eventID,
+		"seats_before", repair.SeatsBefore,
+		"seats_after", repair.SeatsAfter,
+		"promoted", repair.Promoted,
+		"overbooked", repair.Overbooked,
+	)
+	return repair, nil
+}
+
+// RunJob checks (and optionally repairs) every interval until ctx is done.
+func (s *reconciliationService) RunJob(ctx context.Context, interval time.Duration, autoRepair bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var report *ReconciliationReport
+		var err error
+		if autoRepair {
+			report, err = s.Repair(ctx, "")
+		} else {
+			report, err = s.Check(ctx)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.ErrorContext(ctx, "Seat reconciliation failed", "error", err)
+			continue
+		}
+		if !report.Consistent() {
+			slog.WarnContext(ctx, "Seat reconciliation found inconsistencies",
+				"seat_drift", len(report.SeatDrift),
+				"orphaned_waitlists", len(report.OrphanedWaitlists),
+				"repaired", len(report.Repairs),
+			)
+		}
+	}
+}
@@ -0,0 +1,490 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"event_registration/internal/payments"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRefundNotFound     = errors.New("refund not found")
+	ErrNotRefundable      = errors.New("order has no captured payment to refund")
+	ErrRefundExceedsPaid  = errors.New("refund exceeds the amount left to refund")
+	ErrRefundNotRetryable = errors.New("only failed refunds can be retried")
+)
+
+const (
+	// maxRefundAttempts is how often the provider is asked before a refund
+	// is marked FAILED and left to an admin.
+	maxRefundAttempts = 5
+	// refundBatchSize bounds how many orders and refunds one pass handles.
+	refundBatchSize = 100
+	refundListLimit = 500
+)
+
+type RefundService interface {
+	// Issue sends a pending refund to the payment provider. A provider error
+	// is recorded on the refund, which stays pending for the retry job until
+	// maxRefundAttempts is reached; only storage errors are returned.
+	Issue(ctx context.Context, refundID string) (*models.Refund, error)
+	// SettleCancelledEvent fails the pending orders of an event the caller
+	// has just cancelled in tx, under the event row lock, and cancels its
+	// paid bookings with a full refund queued. Orders locked by a payment in
+	// flight are skipped and settled by the refund job once it commits. The
+	// queued refunds are returned for IssueRefunds after tx commits.
+	SettleCancelledEvent(ctx context.Context, tx *gorm.DB, event *models.Event) ([]string, error)
+	// IssueRefunds issues freshly queued refunds. Failures are only logged:
+	// the refunds stay pending and the refund job picks them up.
+	IssueRefunds(ctx context.Context, refundIDs []string)
+	// ProcessPending settles orders left on cancelled events and issues the
+	// pending refunds, so work interrupted after a commit is picked up.
+	ProcessPending(ctx context.Context) (int, error)
+	RunJob(ctx context.Context, interval time.Duration)
+	ListRefunds(ctx context.Context, filter repositories.RefundFilter) ([]models.Refund, error)
+	// Override refunds amount (in minor units of the order's currency) of a
+	// paid order regardless of the event's refund policy.
+	Override(ctx context.Context, adminID, orderID string, amount int64, note string) (*models.Refund, error)
+	// Retry queues a failed refund again and issues it.
+	Retry(ctx context.Context, adminID, refundID string) (*models.Refund, error)
+}
+
+type refundService struct {
+	db         *gorm.DB
+	refundRepo repositories.RefundRepository
+	orderRepo  repositories.OrderRepository
+	promoRepo  repositories.PromoRepository
+	auditRepo  repositories.AuditLogRepository
+	provider   payments.PaymentProvider
+}
+
+func NewRefundService(db *gorm.DB, refundRepo repositories.RefundRepository, orderRepo repositories.OrderRepository, promoRepo repositories.PromoRepository, auditRepo repositories.AuditLogRepository, provider payments.PaymentProvider) RefundService {
+	return &refundService{
+		db:         db,
+		refundRepo: refundRepo,
+		orderRepo:  orderRepo,
+		promoRepo:  promoRepo,
+		auditRepo:  auditRepo,
+		provider:   provider,
+	}
+}
+
+// refundable returns how much of the order can still be refunded: what was
+// paid less every refund that has not failed.
+func refundable(ctx context.Context, tx *gorm.DB, refundRepo repositories.RefundRepository, order *models.Order) (models.Money, error) {
+	if order.PaidAt == nil {
+		return models.Money{Currency: order.Total.Currency}, nil
+	}
+	committed, err := refundRepo.WithTx(tx).CommittedAmount(ctx, order.ID.String())
+	if err != nil {
+		return models.Money{}, err
+	}
+	return models.Money{Amount: max(order.Total.Amount-committed, 0), Currency: order.Total.Currency}, nil
+}
+
+// queueRefund writes a pending refund for a paid order; it is sent to the
+// provider by Issue after commit. The caller holds the order row lock, so
+// concurrent refunds cannot add up to more than was paid.
+func queueRefund(ctx context.Context, tx *gorm.DB, refundRepo repositories.RefundRepository, auditRepo repositories.AuditLogRepository, order *models.Order, amount models.Money, reason models.RefundReason, actorID uuid.UUID, note string) (*models.Refund, error) {
+	if order.PaidAt == nil {
+		return nil, ErrNotRefundable
+	}
+	if amount.Amount <= 0 || amount.Currency != order.Total.Currency {
+		return nil, fmt.Errorf("refund must be a positive amount in %s", order.Total.Currency)
+	}
+	left, err := refundable(ctx, tx, refundRepo, order)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Amount > left.Amount {
+		return nil, ErrRefundExceedsPaid
+	}
+
+	refund := &models.Refund{
+		OrderID:     order.ID,
+		EventID:     order.EventID,
+		UserID:      order.UserID,
+		Amount:      amount,
+		Reason:      reason,
+		Status:      models.RefundStatusPending,
+		Note:        note,
+		RequestedBy: &actorID,
+	}
+	if err := refundRepo.WithTx(tx).Create(ctx, refund); err != nil {
+		return nil, err
+	}
+	if err := recordActivity(ctx, tx, auditRepo, actorID, models.AuditActionRefundRequested, models.AuditEntityRefund, refund.ID, order.EventID); err != nil {
+		return nil, err
+	}
+	metrics.RefundsTotal.WithLabelValues("requested").Inc()
+	return refund, nil
+}
+
+func (s *refundService) Issue(ctx context.Context, refundID string) (_ *models.Refund, err error) {
+	ctx, span := tracing.Start(ctx, "RefundService.Issue", attribute.String("refund.id", refundID))
+	defer func() { tracing.End(span, err) }()
+
+	refund, err := s.refundRepo.FindByID(ctx, refundID)
+	if err != nil {
+		return nil, ErrRefundNotFound
+	}
+	if refund.Status != models.RefundStatusPending {
+		return refund, nil
+	}
+	order, err := s.orderRepo.FindByID(ctx, refund.OrderID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// The provider is called outside the transaction; the refund ID is its
+	// idempotency key, so a concurrent Issue of the same refund is harmless.
+	result, providerErr := s.provider.Refund(ctx, payments.RefundRequest{
+		RefundID:   refund.ID,
+		PaymentRef: order.PaymentRef,
+		Amount:     refund.Amount,
+	})
+
+	outcome := ""
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.WithTx(tx).LockByID(ctx, refund.OrderID.String())
+		if err != nil {
+			return err
+		}
+		refund, err = s.refundRepo.WithTx(tx).LockByID(ctx, refundID)
+		if err != nil {
+			return err
+		}
+		if refund.Status != models.RefundStatusPending {
+			return nil
+		}
+
+		refund.Attempts++
+		actorID := order.UserID
+		if refund.RequestedBy != nil {
+			actorID = *refund.RequestedBy
+		}
+		now := time.Now()
+		switch {
+		case providerErr == nil:
+			outcome = "issued"
+			refund.Status = models.RefundStatusSucceeded
+			refund.ProviderRef = result.Ref
+			refund.FailureReason = ""
+			refund.ProcessedAt = &now
+			if order.Refunded, err = order.Refunded.Add(refund.Amount); err != nil {
+				return err
+			}
+			if order.Refunded.Amount >= order.Total.Amount {
+				order.Status = models.OrderStatusRefunded
+			}
+			if err := s.orderRepo.WithTx(tx).Update(ctx, order); err != nil {
+				return err
+			}
+			if err := recordActivity(ctx, tx, s.auditRepo, actorID, models.AuditActionRefundIssued, models.AuditEntityRefund, refund.ID, refund.EventID); err != nil {
+				return err
+			}
+		case refund.Attempts >= maxRefundAttempts:
+			outcome = "failed"
+			refund.Status = models.RefundStatusFailed
+			refund.FailureReason = providerErr.Error()
+			refund.ProcessedAt = &now
+			if err := recordActivity(ctx, tx, s.auditRepo, actorID, models.AuditActionRefundFailed, models.AuditEntityRefund, refund.ID, refund.EventID); err != nil {
+				return err
+			}
+		default:
+			outcome = "retry"
+			refund.FailureReason = providerErr.Error()
+		}
+		return s.refundRepo.WithTx(tx).Update(ctx, refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("refund.outcome", outcome))
+	switch outcome {
+	case "":
+		return refund, nil
+	case "issued":
+		slog.InfoContext(ctx, "Refund issued", "refund_id", refund.ID, "order_id", refund.OrderID, "amount", refund.Amount.String())
+	default:
+		slog.WarnContext(ctx, "Refund attempt failed", "refund_id", refund.ID, "order_id", refund.OrderID, "attempts", refund.Attempts, "error", providerErr)
+	}
+	metrics.RefundsTotal.WithLabelValues(outcome).Inc()
+	return refund, nil
+}
+
+func (s *refundService) IssueRefunds(ctx context.Context, refundIDs []string) {
+	for _, id := range refundIDs {
+		if _, err := s.Issue(ctx, id); err != nil {
+			slog.ErrorContext(ctx, "Failed to issue refund", "refund_id", id, "error", err)
+		}
+	}
+}
+
+func (s *refundService) SettleCancelledEvent(ctx context.Context, tx *gorm.DB, event *models.Event) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "RefundService.SettleCancelledEvent", attribute.String("event.id", event.ID.String()))
+	defer func() { tracing.End(span, err) }()
+
+	orders, err := s.orderRepo.WithTx(tx).LockUnsettled(ctx, event.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	var refundIDs []string
+	for i := range orders {
+		refundID, err := s.settleOrder(ctx, tx, &orders[i], event)
+		if err != nil {
+			return nil, fmt.Errorf("settle order %s: %w", orders[i].ID, err)
+		}
+		if refundID != "" {
+			refundIDs = append(refundIDs, refundID)
+		}
+	}
+	span.SetAttributes(attribute.Int("orders.settled", len(orders)))
+	return refundIDs, nil
+}
+
+// settleOrders settles the given orders of cancelled events one transaction
+// each and issues the refunds that creates.
+func (s *refundService) settleOrders(ctx context.Context, orderIDs []string) (int, error) {
+	var refundIDs []string
+	defer func() { s.IssueRefunds(ctx, refundIDs) }()
+
+	for i, id := range orderIDs {
+		refundID, err := s.settleCancelledOrder(ctx, id)
+		if err != nil {
+			return i, fmt.Errorf("settle order %s: %w", id, err)
+		}
+		if refundID != "" {
+			refundIDs = append(refundIDs, refundID)
+		}
+	}
+	return len(orderIDs), nil
+}
+
+// settleCancelledOrder releases the seat of one order on a cancelled event:
+// a pending order fails, and a paid order has its booking cancelled and what
+// is left of its payment refunded. It returns the queued refund, if any.
+func (s *refundService) settleCancelledOrder(ctx context.Context, orderID string) (string, error) {
+	refundID := ""
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.WithTx(tx).LockByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		event, err := lockEvent(ctx, tx, order.EventID.String(), "event_cancel_settle")
+		if err != nil {
+			return err
+		}
+		if event.Status != models.EventStatusCancelled {
+			return nil
+		}
+		refundID, err = s.settleOrder(ctx, tx, order, &event)
+		return err
+	})
+	return refundID, err
+}
+
+// settleOrder releases the seat of an order on a cancelled event. The caller
+// holds the order and event row locks.
+func (s *refundService) settleOrder(ctx context.Context, tx *gorm.DB, order *models.Order, event *models.Event) (string, error) {
+	switch {
+	case order.Status == models.OrderStatusPending:
+		if err := releaseOrderHold(ctx, tx, s.orderRepo, s.promoRepo, s.auditRepo, order, event, "event cancelled"); err != nil {
+			return "", err
+		}
+		metrics.OrdersTotal.WithLabelValues("failed").Inc()
+		return "", nil
+	case order.Status != models.OrderStatusPaid || order.CancelledAt != nil:
+		return "", nil
+	}
+
+	if order.RegistrationID != nil {
+		var reg models.Registration
+		if err := tx.Where("id = ?", *order.RegistrationID).First(&reg).Error; err != nil {
+			return "", err
+		}
+		if reg.Status == models.RegistrationStatusConfirmed {
+			if err := tx.Model(&reg).Update("status", models.RegistrationStatusCancelled).Error; err != nil {
+				return "", err
+			}
+			if err := releaseSeat(tx, event); err != nil {
+				return "", err
+			}
+			if err := recordActivity(ctx, tx, s.auditRepo, event.OrganizerID, models.AuditActionRegistrationCancelled, models.AuditEntityRegistration, reg.ID, event.ID); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	now := time.Now()
+	order.CancelledAt = &now
+	if err := s.orderRepo.WithTx(tx).Update(ctx, order); err != nil {
+		return "", err
+	}
+
+	amount, err := refundable(ctx, tx, s.refundRepo, order)
+	if err != nil || amount.Amount == 0 {
+		return "", err
+	}
+	refund, err := queueRefund(ctx, tx, s.refundRepo, s.auditRepo, order, amount, models.RefundReasonEventCancelled, event.OrganizerID, "event cancelled")
+	if err != nil {
+		return "", err
+	}
+	return refund.ID.String(), nil
+}
+
+func (s *refundService) ProcessPending(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "RefundService.ProcessPending")
+	defer func() { tracing.End(span, err) }()
+
+	orderIDs, err := s.orderRepo.FindUnsettledCancelled(ctx, "", refundBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := s.settleOrders(ctx, orderIDs); err != nil {
+		return 0, err
+	}
+
+	refundIDs, err := s.refundRepo.FindPendingIDs(ctx, refundBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range refundIDs {
+		if _, err := s.Issue(ctx, id); err != nil {
+			return 0, fmt.Errorf("issue refund %s: %w", id, err)
+		}
+	}
+	span.SetAttributes(attribute.Int("refunds.processed", len(refundIDs)))
+	return len(refundIDs), nil
+}
+
+// RunJob retries pending refunds every interval until ctx is done.
+func (s *refundService) RunJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		processed, err := s.ProcessPending(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			slog.ErrorContext(ctx, "Refund processing failed", "error", err)
+			continue
+		}
+		if processed > 0 {
+			slog.InfoContext(ctx, "Processed pending refunds", "count", processed)
+		}
+	}
+}
+
+func (s *refundService) ListRefunds(ctx context.Context, filter repositories.RefundFilter) (_ []models.Refund, err error) {
+	ctx, span := tracing.Start(ctx, "RefundService.ListRefunds")
+	defer func() { tracing.End(span, err) }()
+
+	if filter.Status != "" && filter.Status != models.RefundStatusPending &&
+		filter.Status != models.RefundStatusSucceeded && filter.Status != models.RefundStatusFailed {
+		return nil, errors.New("status must be PENDING, SUCCEEDED or FAILED")
+	}
+	return s.refundRepo.List(ctx, filter, refundListLimit)
+}
+
+func (s *refundService) Override(ctx context.Context, adminID, orderID string, amount int64, note string) (_ *models.Refund, err error) {
+	ctx, span := tracing.Start(ctx, "RefundService.Override",
+		attribute.String("order.id", orderID),
+		attribute.Int64("refund.amount", amount),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, errors.New("invalid admin ID")
+	}
+	if note == "" {
+		return nil, errors.New("a note explaining the override is required")
+	}
+
+	var refund *models.Refund
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.WithTx(tx).LockByID(ctx, orderID)
+		if err != nil {
+			return ErrOrderNotFound
+		}
+		refund, err = queueRefund(ctx, tx, s.refundRepo, s.auditRepo, order,
+			models.Money{Amount: amount, Currency: order.Total.Currency},
+			models.RefundReasonAdminOverride, adminUUID, note)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Refund override requested", "refund_id", refund.ID, "order_id", orderID, "admin_id", adminID, "amount", refund.Amount.String())
+	return s.Issue(ctx, refund.ID.String())
+}
+
+func (s *refundService) Retry(ctx context.Context, adminID, refundID string) (_ *models.Refund, err error) {
+	ctx, span := tracing.Start(ctx, "RefundService.Retry", attribute.String("refund.id", refundID))
+	defer func() { tracing.End(span, err) }()
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, errors.New("invalid admin ID")
+	}
+	existing, err := s.refundRepo.FindByID(ctx, refundID)
+	if err != nil {
+		return nil, ErrRefundNotFound
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := s.orderRepo.WithTx(tx).LockByID(ctx, existing.OrderID.String())
+		if err != nil {
+			return err
+		}
+		refund, err := s.refundRepo.WithTx(tx).LockByID(ctx, refundID)
+		if err != nil {
+			return err
+		}
+		if refund.Status != models.RefundStatusFailed {
+			return ErrRefundNotRetryable
+		}
+		// A failed refund no longer counts against the order, so re-check
+		// that others have not used up the amount since.
+		left, err := refundable(ctx, tx, s.refundRepo, order)
+		if err != nil {
+			return err
+		}
+		if refund.Amount.Amount > left.Amount {
+			return ErrRefundExceedsPaid
+		}
+
+		refund.Status = models.RefundStatusPending
+		refund.Attempts = 0
+		refund.FailureReason = ""
+		refund.ProcessedAt = nil
+		if err := s.refundRepo.WithTx(tx).Update(ctx, refund); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx, s.auditRepo, adminUUID, models.AuditActionRefundRequested, models.AuditEntityRefund, refund.ID, refund.EventID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Issue(ctx, refundID)
+}
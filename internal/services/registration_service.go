@@ -3,119 +3,361 @@ package services
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"time"
 
+	"event_registration/internal/metrics"
 	"event_registration/internal/models"
 	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// Booking outcomes surfaced to callers. Keeping them as values lets metrics
+// and other callers classify failures without matching on strings.
+var (
+	ErrEventNotFound     = errors.New("event not found")
+	ErrEventNotPublished = errors.New("event is not published")
+	ErrEventPassed       = errors.New("event has already passed")
+	ErrAlreadyRegistered = errors.New("already registered for this event")
+	ErrAlreadyWaitlisted = errors.New("already on waitlist for this event")
+	ErrPaymentRequired   = errors.New("event requires payment: place an order instead")
+)
+
 type RegistrationService interface {
+	// BookEvent books one seat, or a waitlist spot, for an attendee. On an
+	// event with an enabled waiting room it spends the user's admission.
 	BookEvent(ctx context.Context, userID, eventID string) (*models.Registration, *models.Waitlist, error)
+	// BookEventWithoutAdmission books like BookEvent but skips the waiting
+	// room, for imports and simulations that act on the organizer's behalf.
+	BookEventWithoutAdmission(ctx context.Context, userID, eventID string) (*models.Registration, *models.Waitlist, error)
 	CancelRegistration(ctx context.Context, userID, registrationID string) error
-	GetOrganizerAnalytics(ctx context.Context, organizerID, eventID string) (map[string]interface{}, error)
+	CheckIn(ctx context.Context, organizerID, registrationID string) (*models.Registration, error)
+	CheckInTicket(ctx context.Context, organizerID, eventID, token string) (*models.Registration, error)
+	GetOrganizerAnalytics(ctx context.Context, organizerID, eventID string, query AnalyticsQuery) (*EventAnalytics, error)
+	ListEventRegistrations(ctx context.Context, eventID string) ([]models.Registration, error)
 }
 
 type registrationService struct {
-	db        *gorm.DB
-	regRepo   repositories.RegistrationRepository
-	waitRepo  repositories.WaitlistRepository
-	eventRepo repositories.EventRepository
+	db         *gorm.DB
+	regRepo    repositories.RegistrationRepository
+	waitRepo   repositories.WaitlistRepository
+	eventRepo  repositories.EventRepository
+	auditRepo  repositories.AuditLogRepository
+	orderRepo  repositories.OrderRepository
+	refundRepo repositories.RefundRepository
+	allocators *SeatAllocators
+	refunds    RefundService
 }
 
-func NewRegistrationService(db *gorm.DB, regRepo repositories.RegistrationRepository, waitRepo repositories.WaitlistRepository, eventRepo repositories.EventRepository) RegistrationService {
+func NewRegistrationService(db *gorm.DB, regRepo repositories.RegistrationRepository, waitRepo repositories.WaitlistRepository, eventRepo repositories.EventRepository, auditRepo repositories.AuditLogRepository, orderRepo repositories.OrderRepository, refundRepo repositories.RefundRepository, allocators *SeatAllocators, refunds RefundService) RegistrationService {
 	return &registrationService{
-		db:        db,
-		regRepo:   regRepo,
-		waitRepo:  waitRepo,
-		eventRepo: eventRepo,
+		db:         db,
+		regRepo:    regRepo,
+		waitRepo:   waitRepo,
+		eventRepo:  eventRepo,
+		auditRepo:  auditRepo,
+		orderRepo:  orderRepo,
+		refundRepo: refundRepo,
+		allocators: allocators,
+		refunds:    refunds,
 	}
 }
 
-// BookEvent contains the core concurrency-safe logic
 func (s *registrationService) BookEvent(ctx context.Context, userID, eventID string) (*models.Registration, *models.Waitlist, error) {
+	return s.bookEvent(ctx, userID, eventID, true)
+}
+
+func (s *registrationService) BookEventWithoutAdmission(ctx context.Context, userID, eventID string) (*models.Registration, *models.Waitlist, error) {
+	return s.bookEvent(ctx, userID, eventID, false)
+}
+
+// bookEvent contains the core concurrency-safe logic
+func (s *registrationService) bookEvent(ctx context.Context, userID, eventID string, spendAdmission bool) (*models.Registration, *models.Waitlist, error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.BookEvent",
+		attribute.String("event.id", eventID),
+		attribute.String("user.id", userID),
+	)
+
 	var finalReg *models.Registration
 	var finalWaitlist *models.Waitlist
 
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 1. Lock the event row using SELECT ... FOR UPDATE
-		var event models.Event
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", eventID).First(&event).Error; err != nil {
-			return errors.New("event not found")
-		}
+	allocator := s.allocators.For(s.eventRepo.FindAllocationStrategy(ctx, eventID))
+	span.SetAttributes(attribute.String("booking.strategy", string(allocator.Strategy())))
 
-		// 2. Validate Event criteria
-		if event.Status != models.EventStatusPublished {
-			return errors.New("event is not published")
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Validate the event and try to take a seat with the configured strategy
+		allocStart := time.Now()
+		event, reserved, err := allocator.Reserve(ctx, tx, eventID)
+		metrics.SeatAllocationDuration.WithLabelValues(string(allocator.Strategy())).Observe(time.Since(allocStart).Seconds())
+		if err != nil {
+			return err
 		}
-		if event.EventDate.Before(time.Now()) {
-			return errors.New("event has already passed")
+		// Paid events confirm through OrderService; rolling back returns the seat.
+		if event.IsPaid() {
+			return ErrPaymentRequired
 		}
 
-		// 3. User Parsing
+		// 2. User Parsing
 		userUUID, err := uuid.Parse(userID)
 		if err != nil {
 			return err
 		}
 
 		// Check if user already registered or waitlisted
-		var existingReg models.Registration
-		if err := tx.Where("event_id = ? AND user_id = ?", eventID, userID).First(&existingReg).Error; err == nil {
-			if existingReg.Status == models.RegistrationStatusConfirmed {
-				return errors.New("already registered for this event")
-			}
-		}
-
-		var existingWaitlist models.Waitlist
-		if err := tx.Where("event_id = ? AND user_id = ?", eventID, userID).First(&existingWaitlist).Error; err == nil {
-			return errors.New("already on waitlist for this event")
+		if err := ensureNotBooked(tx, eventID, userID); err != nil {
+			return err
 		}
-
-		// 4. Concurrency Logic / Distribution
-		if event.SeatsRemaining > 0 {
-			// Seat available
-			newReg := &models.Registration{
-				UserID:  userUUID,
-				EventID: event.ID,
-				Status:  models.RegistrationStatusConfirmed,
-			}
-			if err := tx.Create(newReg).Error; err != nil {
+		if spendAdmission {
+			if err := useAdmission(ctx, tx, eventID, userID); err != nil {
 				return err
 			}
+		}
 
-			// Decrement seats
-			event.SeatsRemaining -= 1
-			if err := tx.Save(&event).Error; err != nil {
-				return err
+		// 3. Joining the waitlist is serialized on the row lock under every
+		// strategy so positions stay unique. A seat freed since the allocator
+		// looked is taken here instead.
+		if !reserved && allocator.Strategy() != models.AllocationPessimistic {
+			if event, err = lockEvent(ctx, tx, eventID, "waitlist"); err != nil {
+				return ErrEventNotFound
 			}
-			finalReg = newReg
-		} else {
-			// Waitlist
-			var count int64
-			tx.Model(&models.Waitlist{}).Where("event_id = ?", event.ID).Count(&count)
-
-			newWaitlist := &models.Waitlist{
-				UserID:   userUUID,
-				EventID:  event.ID,
-				Position: int(count) + 1,
-			}
-			if err := tx.Create(newWaitlist).Error; err != nil {
+			if err := validateBookable(event); err != nil {
 				return err
 			}
-			finalWaitlist = newWaitlist
+			if event.SeatsRemaining > 0 {
+				if err := takeSeat(tx, &event); err != nil {
+					return err
+				}
+				reserved = true
+			}
 		}
 
-		return nil // Commit transaction
+		if reserved {
+			finalReg, err = confirmSeat(ctx, tx, s.auditRepo, event.ID, userUUID)
+		} else {
+			finalWaitlist, err = joinWaitlist(ctx, tx, s.auditRepo, event.ID, userUUID)
+		}
+		return err // Commit transaction unless it failed
 	})
 
+	switch {
+	case err != nil:
+		reason := bookingFailureReason(err)
+		metrics.BookingFailuresTotal.WithLabelValues(reason).Inc()
+		span.SetAttributes(attribute.String("booking.outcome", "failed"), attribute.String("booking.failure_reason", reason))
+	case finalWaitlist != nil:
+		metrics.WaitlistJoinsTotal.Inc()
+		span.SetAttributes(attribute.String("booking.outcome", "waitlisted"), attribute.Int("waitlist.position", finalWaitlist.Position))
+		slog.InfoContext(ctx, "Added to waitlist", "event_id", eventID, "user_id", userID, "position", finalWaitlist.Position)
+	default:
+		metrics.BookingsTotal.Inc()
+		span.SetAttributes(attribute.String("booking.outcome", "confirmed"), attribute.String("registration.id", finalReg.ID.String()))
+		slog.InfoContext(ctx, "Booking confirmed", "event_id", eventID, "user_id", userID, "registration_id", finalReg.ID)
+	}
+	tracing.End(span, err)
+
 	return finalReg, finalWaitlist, err
 }
 
-// CancelRegistration cancels the reg, increments seat or polls from waitlist
-func (s *registrationService) CancelRegistration(ctx context.Context, userID, registrationID string) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+// ensureNotBooked rejects a user who already holds a confirmed seat or a
+// waitlist spot for the event. Seats the user booked for a group do not
+// count as their own.
+func ensureNotBooked(tx *gorm.DB, eventID, userID string) error {
+	var confirmed int64
+	if err := tx.Model(&models.Registration{}).
+		Where("event_id = ? AND user_id = ? AND group_booking_id IS NULL AND status = ?", eventID, userID, models.RegistrationStatusConfirmed).
+		Count(&confirmed).Error; err != nil {
+		return err
+	}
+	if confirmed > 0 {
+		return ErrAlreadyRegistered
+	}
+
+	var existingWaitlist models.Waitlist
+	if err := tx.Where("event_id = ? AND user_id = ?", eventID, userID).First(&existingWaitlist).Error; err == nil {
+		return ErrAlreadyWaitlisted
+	}
+	return nil
+}
+
+// confirmSeat records the registration for a seat the caller already took.
+func confirmSeat(ctx context.Context, tx *gorm.DB, auditRepo repositories.AuditLogRepository, eventID, userID uuid.UUID) (*models.Registration, error) {
+	reg, err := confirmRegistration(tx, eventID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordActivity(ctx, tx, auditRepo, userID, models.AuditActionRegistrationConfirmed, models.AuditEntityRegistration, reg.ID, eventID); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// confirmRegistration creates the user's confirmed registration, or revives
+// a row left by an earlier cancellation.
+func confirmRegistration(tx *gorm.DB, eventID, userID uuid.UUID) (*models.Registration, error) {
+	var reg models.Registration
+	err := tx.Where("event_id = ? AND user_id = ? AND group_booking_id IS NULL AND status = ?", eventID, userID, models.RegistrationStatusCancelled).
+		First(&reg).Error
+	switch {
+	case err == nil:
+		err = tx.Model(&reg).Updates(map[string]any{
+			"status":        models.RegistrationStatusConfirmed,
+			"checked_in_at": nil,
+		}).Error
+		reg.Status = models.RegistrationStatusConfirmed
+		reg.CheckedInAt = nil
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		reg = models.Registration{UserID: userID, EventID: eventID, Status: models.RegistrationStatusConfirmed}
+		err = tx.Create(&reg).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reg, nil
+}
+
+// joinWaitlist appends the user to the waitlist. The caller must hold the
+// event row lock so positions stay unique.
+func joinWaitlist(ctx context.Context, tx *gorm.DB, auditRepo repositories.AuditLogRepository, eventID, userID uuid.UUID) (*models.Waitlist, error) {
+	entry := &models.Waitlist{UserID: userID, EventID: eventID, Seats: 1}
+	return entry, appendWaitlist(ctx, tx, auditRepo, entry)
+}
+
+// appendWaitlist gives entry the next position. The caller must hold the
+// event row lock.
+func appendWaitlist(ctx context.Context, tx *gorm.DB, auditRepo repositories.AuditLogRepository, entry *models.Waitlist) error {
+	var count int64
+	tx.Model(&models.Waitlist{}).Where("event_id = ?", entry.EventID).Count(&count)
+	trace.SpanFromContext(ctx).AddEvent("event full, joining waitlist", trace.WithAttributes(
+		attribute.Int64("waitlist.length", count),
+		attribute.Int("waitlist.seats", entry.Seats),
+	))
+
+	entry.Position = int(count) + 1
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+	return recordActivity(ctx, tx, auditRepo, entry.UserID, models.AuditActionWaitlistJoined, models.AuditEntityWaitlist, entry.ID, entry.EventID)
+}
+
+// promoteWaitlist confirms waitlisted bookings in position order while the
+// head of the line fits in the event's free seats; a group waits until all
+// its seats are free, and no waitlist entry behind it is served first. Free
+// seats are not held for the group, so new bookings can still take them. It
+// takes the seats from event and returns how many entries were promoted. The
+// caller holds the event row lock.
+func promoteWaitlist(ctx context.Context, tx *gorm.DB, waitRepo repositories.WaitlistRepository, auditRepo repositories.AuditLogRepository, event *models.Event) (int, error) {
+	promoted := 0
+	for event.SeatsRemaining > 0 {
+		next, err := waitRepo.WithTx(tx).GetNextInLine(ctx, event.ID.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return promoted, err
+		}
+		if next.Seats > event.SeatsRemaining {
+			break
+		}
+
+		if err := takeSeats(tx, event, next.Seats); err != nil {
+			return promoted, err
+		}
+		if next.GroupBookingID != nil {
+			err = promoteGroup(ctx, tx, auditRepo, *next.GroupBookingID)
+		} else {
+			var reg *models.Registration
+			if reg, err = confirmRegistration(tx, event.ID, next.UserID); err == nil {
+				err = recordActivity(ctx, tx, auditRepo, next.UserID, models.AuditActionWaitlistPromoted, models.AuditEntityRegistration, reg.ID, event.ID)
+			}
+		}
+		if err != nil {
+			return promoted, err
+		}
+		if err := waitRepo.WithTx(tx).Delete(ctx, next.ID.String()); err != nil {
+			return promoted, err
+		}
+
+		promoted++
+		trace.SpanFromContext(ctx).AddEvent("waitlist user promoted", trace.WithAttributes(
+			attribute.String("user.id", next.UserID.String()),
+			attribute.Int("waitlist.position", next.Position),
+			attribute.Int("waitlist.seats", next.Seats),
+		))
+	}
+	return promoted, nil
+}
+
+// lockEvent takes the event row lock (SELECT ... FOR UPDATE), recording how
+// long the caller waited for it as a metric and as a span event.
+func lockEvent(ctx context.Context, tx *gorm.DB, eventID, operation string) (models.Event, error) {
+	var event models.Event
+	lockStart := time.Now()
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", eventID).First(&event).Error
+	metrics.ObserveLockWait(operation, lockStart)
+
+	trace.SpanFromContext(ctx).AddEvent("event row locked", trace.WithAttributes(
+		attribute.Int64("lock.wait_us", time.Since(lockStart).Microseconds()),
+		attribute.Int("event.seats_remaining", event.SeatsRemaining),
+	))
+	return event, err
+}
+
+func bookingFailureReason(err error) string {
+	switch {
+	case errors.Is(err, ErrEventNotFound):
+		return "event_not_found"
+	case errors.Is(err, ErrEventNotPublished):
+		return "event_not_published"
+	case errors.Is(err, ErrEventPassed):
+		return "event_passed"
+	case errors.Is(err, ErrAlreadyRegistered):
+		return "already_registered"
+	case errors.Is(err, ErrAlreadyWaitlisted):
+		return "already_waitlisted"
+	case errors.Is(err, ErrSeatContention):
+		return "contention"
+	case errors.Is(err, ErrPaymentRequired):
+		return "payment_required"
+	case errors.Is(err, ErrSoldOut):
+		return "sold_out"
+	case errors.Is(err, ErrReservedSeating):
+		return "reserved_seating"
+	case errors.Is(err, ErrSeatTaken), errors.Is(err, ErrNoAdjacentSeats):
+		return "seat_taken"
+	case errors.Is(err, ErrOrderPending):
+		return "order_pending"
+	case errors.Is(err, ErrPromoInvalid), errors.Is(err, ErrPromoExhausted),
+		errors.Is(err, ErrPromoUserLimit), errors.Is(err, ErrPromoNotApplicable):
+		return "promo_rejected"
+	case errors.Is(err, ErrTicketTypeNotFound):
+		return "ticket_type_not_found"
+	case errors.Is(err, ErrAdmissionUsed):
+		return "admission_used"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "internal"
+	}
+}
+
+// CancelRegistration cancels the reg, increments seat or polls from waitlist.
+// A paid booking is refunded as the event's refund policy allows.
+func (s *registrationService) CancelRegistration(ctx context.Context, userID, registrationID string) (err error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.CancelRegistration",
+		attribute.String("registration.id", registrationID),
+		attribute.String("user.id", userID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	promoted := 0
+	var refund *models.Refund
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var reg models.Registration
 		if err := tx.Where("id = ?", registrationID).First(&reg).Error; err != nil {
 			return errors.New("registration not found")
@@ -129,50 +371,157 @@ func (s *registrationService) CancelRegistration(ctx context.Context, userID, re
 			return errors.New("already cancelled")
 		}
 
+		// The order of a paid booking is locked before the event, the same
+		// order the payment webhook takes them in.
+		order, err := s.orderRepo.WithTx(tx).LockActiveByRegistration(ctx, registrationID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
 		// Lock event
-		var event models.Event
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", reg.EventID).First(&event).Error; err != nil {
+		event, err := lockEvent(ctx, tx, reg.EventID.String(), "cancel")
+		if err != nil {
+			return err
+		}
+
+		// Re-read under the locks: the seat may have been transferred or
+		// cancelled since it was first read.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", registrationID).First(&reg).Error; err != nil {
+			return err
+		}
+		if reg.UserID.String() != userID {
+			return errors.New("unauthorized to cancel this registration")
+		}
+		if reg.Status == models.RegistrationStatusCancelled {
+			return errors.New("already cancelled")
+		}
+		if err := cancelPendingTransfers(tx, reg.ID); err != nil {
+			return err
+		}
+		if err := releaseEventSeats(tx, reg.ID); err != nil {
 			return err
 		}
 
+		if order != nil {
+			if refund, err = s.refundCancelledOrder(ctx, tx, order, event, reg.UserID); err != nil {
+				return err
+			}
+		}
+
 		// Cancel registration
 		reg.Status = models.RegistrationStatusCancelled
 		if err := tx.Save(&reg).Error; err != nil {
 			return err
 		}
+		if err := s.recordActivity(ctx, tx, reg.UserID, models.AuditActionRegistrationCancelled, models.AuditEntityRegistration, reg.ID, event.ID); err != nil {
+			return err
+		}
 
-		// Check waitlist
-		var nextUser models.Waitlist
-		err := tx.Where("event_id = ?", event.ID).Order("position asc").First(&nextUser).Error
-		if err == nil { // Waitlist user found
-			// Promote user to Registration
-			newReg := &models.Registration{
-				UserID:  nextUser.UserID,
-				EventID: event.ID,
-				Status:  models.RegistrationStatusConfirmed,
-			}
-			if err := tx.Create(newReg).Error; err != nil {
-				return err
-			}
+		// The seat goes to the head of the waitlist if it fits there
+		if err := releaseSeat(tx, &event); err != nil {
+			return err
+		}
+		promoted, err = promoteWaitlist(ctx, tx, s.waitRepo, s.auditRepo, &event)
+		return err
+	})
 
-			// Remove from waitlist
-			if err := tx.Delete(&nextUser).Error; err != nil {
-				return err
-			}
-			// Seats remaining does not change because it's transferred
-		} else {
-			// No waitlist, increment seats
-			event.SeatsRemaining += 1
-			if err := tx.Save(&event).Error; err != nil {
-				return err
+	span.SetAttributes(attribute.Bool("waitlist.promoted", promoted > 0))
+	if err == nil {
+		metrics.CancellationsTotal.Inc()
+		slog.InfoContext(ctx, "Registration cancelled", "registration_id", registrationID, "user_id", userID, "promoted_from_waitlist", promoted)
+		metrics.PromotionsTotal.Add(float64(promoted))
+		if refund != nil {
+			// A failed attempt stays pending for the refund job.
+			if _, issueErr := s.refunds.Issue(ctx, refund.ID.String()); issueErr != nil {
+				slog.ErrorContext(ctx, "Failed to issue refund", "refund_id", refund.ID, "error", issueErr)
 			}
 		}
+	}
+	return err
+}
+
+// refundCancelledOrder marks the paid order of a cancelled booking and queues
+// the refund its event's policy allows, capped at what is left to refund. It
+// returns nil when nothing is due.
+func (s *registrationService) refundCancelledOrder(ctx context.Context, tx *gorm.DB, order *models.Order, event models.Event, actorID uuid.UUID) (*models.Refund, error) {
+	now := time.Now()
+	order.CancelledAt = &now
+	if err := s.orderRepo.WithTx(tx).Update(ctx, order); err != nil {
+		return nil, err
+	}
+
+	left, err := refundable(ctx, tx, s.refundRepo, order)
+	if err != nil {
+		return nil, err
+	}
+	amount := event.RefundPolicy.Quote(order.Total, event.EventDate, now)
+	amount.Amount = min(amount.Amount, left.Amount)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int64("refund.amount", amount.Amount))
+	if amount.Amount == 0 {
+		return nil, nil
+	}
+	return queueRefund(ctx, tx, s.refundRepo, s.auditRepo, order, amount, models.RefundReasonAttendeeCancelled, actorID, "")
+}
+
+// CheckIn marks a confirmed registration as attended at the door.
+func (s *registrationService) CheckIn(ctx context.Context, organizerID, registrationID string) (checkedIn *models.Registration, err error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.CheckIn", attribute.String("registration.id", registrationID))
+	defer func() { tracing.End(span, err) }()
+
+	return s.checkIn(ctx, organizerID, "id = ?", registrationID)
+}
+
+// CheckInTicket checks in the registration holding the ticket token. A token
+// replaced by a transfer no longer matches any registration.
+func (s *registrationService) CheckInTicket(ctx context.Context, organizerID, eventID, token string) (checkedIn *models.Registration, err error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.CheckInTicket", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
+	return s.checkIn(ctx, organizerID, "ticket_token = ? AND event_id = ?", token, eventID)
+}
+
+func (s *registrationService) checkIn(ctx context.Context, organizerID, query string, args ...any) (checkedIn *models.Registration, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var reg models.Registration
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Event").Where(query, args...).First(&reg).Error; err != nil {
+			return errors.New("registration not found")
+		}
+
+		if reg.Event.OrganizerID.String() != organizerID {
+			return errors.New("unauthorized to check in this registration")
+		}
+		if reg.Status != models.RegistrationStatusConfirmed {
+			return errors.New("registration is not confirmed")
+		}
+		if reg.CheckedInAt != nil {
+			return errors.New("already checked in")
+		}
 
+		now := time.Now()
+		if err := tx.Model(&reg).Update("checked_in_at", now).Error; err != nil {
+			return err
+		}
+		reg.CheckedInAt = &now
+
+		orgUUID, err := uuid.Parse(organizerID)
+		if err != nil {
+			return err
+		}
+		if err := s.recordActivity(ctx, tx, orgUUID, models.AuditActionRegistrationCheckedIn, models.AuditEntityRegistration, reg.ID, reg.EventID); err != nil {
+			return err
+		}
+
+		checkedIn = &reg
 		return nil
 	})
+
+	return checkedIn, err
 }
 
-func (s *registrationService) GetOrganizerAnalytics(ctx context.Context, organizerID, eventID string) (map[string]interface{}, error) {
+func (s *registrationService) GetOrganizerAnalytics(ctx context.Context, organizerID, eventID string, query AnalyticsQuery) (_ *EventAnalytics, err error) {
+	ctx, span := tracing.Start(ctx, "RegistrationService.GetOrganizerAnalytics", attribute.String("event.id", eventID))
+	defer func() { tracing.End(span, err) }()
+
 	event, err := s.eventRepo.FindByID(ctx, eventID)
 	if err != nil {
 		return nil, err
@@ -182,20 +531,52 @@ func (s *registrationService) GetOrganizerAnalytics(ctx context.Context, organiz
 		return nil, errors.New("unauthorized access to analytics")
 	}
 
-	confirmedCount, _ := s.regRepo.CountByEventAndStatus(ctx, eventID, models.RegistrationStatusConfirmed)
-	var waitlistCount int64
-	s.db.WithContext(ctx).Model(&models.Waitlist{}).Where("event_id = ?", eventID).Count(&waitlistCount)
+	query, err = query.normalize(event)
+	if err != nil {
+		return nil, err
+	}
+
+	registrations, err := s.regRepo.FindByEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	waitlistCount, err := s.waitRepo.CountByEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := s.auditRepo.FindByEvent(ctx, eventID,
+		models.AuditActionRegistrationConfirmed,
+		models.AuditActionRegistrationCancelled,
+		models.AuditActionWaitlistJoined,
+		models.AuditActionWaitlistPromoted,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildEventAnalytics(event, query, registrations, waitlistCount, activity), nil
+}
 
-	totalRegistrations := confirmedCount + waitlistCount
-	var seatsFilledPercentage float64
-	if event.Capacity > 0 {
-		seatsFilledPercentage = float64(event.Capacity-event.SeatsRemaining) / float64(event.Capacity) * 100
+// ListEventRegistrations returns every registration for the event with its
+// attendee loaded. It performs no ownership check; callers must authorize.
+func (s *registrationService) ListEventRegistrations(ctx context.Context, eventID string) ([]models.Registration, error) {
+	if _, err := s.eventRepo.FindByID(ctx, eventID); err != nil {
+		return nil, ErrEventNotFound
 	}
+	return s.regRepo.FindByEvent(ctx, eventID)
+}
 
-	return map[string]interface{}{
-		"total_registrations":     totalRegistrations,
-		"confirmed_count":         confirmedCount,
-		"waitlist_count":          waitlistCount,
-		"seats_filled_percentage": seatsFilledPercentage,
-	}, nil
+func (s *registrationService) recordActivity(ctx context.Context, tx *gorm.DB, actorID uuid.UUID, action, entityType string, entityID, eventID uuid.UUID) error {
+	return recordActivity(ctx, tx, s.auditRepo, actorID, action, entityType, entityID, eventID)
+}
+
+// recordActivity appends an event-scoped audit entry inside the caller's transaction.
+func recordActivity(ctx context.Context, tx *gorm.DB, auditRepo repositories.AuditLogRepository, actorID uuid.UUID, action, entityType string, entityID, eventID uuid.UUID) error {
+	return auditRepo.WithTx(tx).Create(ctx, &models.AuditLog{
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		EventID:    &eventID,
+	})
 }
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrSeatContention is returned when optimistic allocation keeps losing the
+// version race and runs out of retries.
+var ErrSeatContention = errors.New("event is under heavy contention, please retry")
+
+// SeatAllocator takes one seat for a booking inside the caller's transaction.
+// Implementations differ only in how they stay correct under concurrency.
+type SeatAllocator interface {
+	Strategy() models.AllocationStrategy
+	// Reserve checks that the event is bookable and decrements its
+	// seats_remaining. reserved is false, with a nil error, when the event is
+	// full. Errors are ErrEventNotFound, ErrEventNotPublished, ErrEventPassed,
+	// ErrSeatContention or a database error.
+	Reserve(ctx context.Context, tx *gorm.DB, eventID string) (event models.Event, reserved bool, err error)
+}
+
+// SeatAllocators resolves the allocator for an event: its own override if
+// set, otherwise the deployment default.
+type SeatAllocators struct {
+	defaultStrategy models.AllocationStrategy
+	byStrategy      map[models.AllocationStrategy]SeatAllocator
+}
+
+func NewSeatAllocators(defaultStrategy models.AllocationStrategy, optimisticRetries int) (*SeatAllocators, error) {
+	if !defaultStrategy.Valid() {
+		return nil, fmt.Errorf("unknown allocation strategy %q", defaultStrategy)
+	}
+	return &SeatAllocators{
+		defaultStrategy: defaultStrategy,
+		byStrategy: map[models.AllocationStrategy]SeatAllocator{
+			models.AllocationPessimistic: pessimisticAllocator{},
+			models.AllocationOptimistic:  optimisticAllocator{maxRetries: optimisticRetries},
+			models.AllocationAtomic:      atomicAllocator{},
+		},
+	}, nil
+}
+
+func (a *SeatAllocators) For(strategy models.AllocationStrategy) SeatAllocator {
+	if allocator, ok := a.byStrategy[strategy]; ok {
+		return allocator
+	}
+	return a.byStrategy[a.defaultStrategy]
+}
+
+// validateBookable checks that the event takes bookings without a chosen
+// seat. Reserved seating is booked through SeatingService.
+func validateBookable(event models.Event) error {
+	if err := validateOnSale(event); err != nil {
+		return err
+	}
+	if event.ReservedSeating() {
+		return ErrReservedSeating
+	}
+	return nil
+}
+
+func validateOnSale(event models.Event) error {
+	if event.Status != models.EventStatusPublished {
+		return ErrEventNotPublished
+	}
+	if event.EventDate.Before(time.Now()) {
+		return ErrEventPassed
+	}
+	return nil
+}
+
+// takeSeats and releaseSeats move seats_remaining for bookings and
+// cancellations; both bump version so optimistic allocators notice the write.
+func takeSeat(tx *gorm.DB, event *models.Event) error {
+	return takeSeats(tx, event, 1)
+}
+
+func releaseSeat(tx *gorm.DB, event *models.Event) error {
+	return releaseSeats(tx, event, 1)
+}
+
+func takeSeats(tx *gorm.DB, event *models.Event, n int) error {
+	if err := adjustSeats(tx, event.ID.String(), -n); err != nil {
+		return err
+	}
+	event.SeatsRemaining -= n
+	event.Version++
+	return nil
+}
+
+func releaseSeats(tx *gorm.DB, event *models.Event, n int) error {
+	if err := adjustSeats(tx, event.ID.String(), n); err != nil {
+		return err
+	}
+	event.SeatsRemaining += n
+	event.Version++
+	return nil
+}
+
+func adjustSeats(tx *gorm.DB, eventID string, delta int) error {
+	return tx.Model(&models.Event{}).Where("id = ?", eventID).Updates(map[string]any{
+		"seats_remaining": gorm.Expr("seats_remaining + ?", delta),
+		"version":         gorm.Expr("version + 1"),
+	}).Error
+}
+
+// pessimisticAllocator serializes every booking for the event on the row
+// lock. Simple and fair, but bookings queue behind each other.
+type pessimisticAllocator struct{}
+
+func (pessimisticAllocator) Strategy() models.AllocationStrategy { return models.AllocationPessimistic }
+
+func (pessimisticAllocator) Reserve(ctx context.Context, tx *gorm.DB, eventID string) (models.Event, bool, error) {
+	event, err := lockEvent(ctx, tx, eventID, "book")
+	if err != nil {
+		return event, false, ErrEventNotFound
+	}
+	if err := validateBookable(event); err != nil {
+		return event, false, err
+	}
+	if event.SeatsRemaining <= 0 {
+		return event, false, nil
+	}
+	return event, true, takeSeat(tx, &event)
+}
+
+// optimisticAllocator reads without locking and writes only if the version
+// is unchanged, retrying with jittered backoff when another writer won.
+type optimisticAllocator struct {
+	maxRetries int
+}
+
+func (optimisticAllocator) Strategy() models.AllocationStrategy { return models.AllocationOptimistic }
+
+func (a optimisticAllocator) Reserve(ctx context.Context, tx *gorm.DB, eventID string) (models.Event, bool, error) {
+	for attempt := 0; ; attempt++ {
+		var event models.Event
+		if err := tx.Where("id = ?", eventID).First(&event).Error; err != nil {
+			return event, false, ErrEventNotFound
+		}
+		if err := validateBookable(event); err != nil {
+			return event, false, err
+		}
+		if event.SeatsRemaining <= 0 {
+			return event, false, nil
+		}
+
+		result := tx.Model(&models.Event{}).
+			Where("id = ? AND version = ?", eventID, event.Version).
+			Updates(map[string]any{
+				"seats_remaining": event.SeatsRemaining - 1,
+				"version":         event.Version + 1,
+			})
+		if result.Error != nil {
+			return event, false, result.Error
+		}
+		if result.RowsAffected == 1 {
+			event.SeatsRemaining--
+			event.Version++
+			return event, true, nil
+		}
+
+		metrics.OptimisticConflictsTotal.Inc()
+		if attempt >= a.maxRetries {
+			return event, false, ErrSeatContention
+		}
+		backoff := time.Duration(rand.Int63n(int64(time.Millisecond)*int64(attempt+1))) + time.Millisecond
+		select {
+		case <-ctx.Done():
+			return event, false, ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// atomicAllocator lets Postgres check and decrement in one statement. The
+// row is still locked by the UPDATE, but only for the statement's duration
+// before the insert, with no read-then-write round trip.
+type atomicAllocator struct{}
+
+func (atomicAllocator) Strategy() models.AllocationStrategy { return models.AllocationAtomic }
+
+func (atomicAllocator) Reserve(ctx context.Context, tx *gorm.DB, eventID string) (models.Event, bool, error) {
+	var event models.Event
+	result := tx.Raw(`
+		UPDATE events
+		SET seats_remaining = seats_remaining - 1, version = version + 1, updated_at = now()
+		WHERE id = ? AND seats_remaining > 0 AND status = ? AND event_date > now()
+		RETURNING *`, eventID, models.EventStatusPublished).
+		Scan(&event)
+	if result.Error != nil {
+		return event, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return event, true, nil
+	}
+
+	// Nothing updated: work out whether the event is full or unbookable.
+	if err := tx.Where("id = ?", eventID).First(&event).Error; err != nil {
+		return event, false, ErrEventNotFound
+	}
+	if err := validateBookable(event); err != nil {
+		return event, false, err
+	}
+	return event, false, nil
+}
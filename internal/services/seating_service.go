@@ -0,0 +1,579 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// Upper bound on the seats of one seat map.
+const maxSeatMapSeats = 20000
+
+var (
+	ErrReservedSeating    = errors.New("event has reserved seating: book specific seats instead")
+	ErrNotReservedSeating = errors.New("event does not have reserved seating")
+	ErrSeatMapNotFound    = errors.New("seat map not found")
+	ErrSeatNotFound       = errors.New("seat not found for this event")
+	ErrSeatTaken          = errors.New("one or more of the chosen seats are already taken")
+	ErrNoAdjacentSeats    = errors.New("no block of adjacent seats is free for that many people")
+	ErrSeatingNotForPaid  = errors.New("reserved seating is not available for paid events")
+	ErrSeatingQueued      = errors.New("reserved seating is not available with queued admission")
+)
+
+// SeatMapRequest lays out a venue section by section, best section first.
+type SeatMapRequest struct {
+	Name     string               `json:"name"`
+	Sections []SeatSectionRequest `json:"sections"`
+}
+
+// SeatSectionRequest lists its rows from the front.
+type SeatSectionRequest struct {
+	Name string           `json:"name"`
+	Rows []SeatRowRequest `json:"rows"`
+}
+
+// SeatRowRequest describes seats numbered 1 to Seats. Numbers in Skip have
+// no seat, which splits the row at an aisle.
+type SeatRowRequest struct {
+	Label      string `json:"label"`
+	Seats      int    `json:"seats"`
+	Skip       []int  `json:"skip"`
+	Wheelchair []int  `json:"wheelchair"`
+	Companion  []int  `json:"companion"`
+}
+
+// SeatAvailability lists every seat of the event in seating order.
+type SeatAvailability struct {
+	EventID   uuid.UUID              `json:"event_id"`
+	Total     int                    `json:"total"`
+	Available int                    `json:"available"`
+	Seats     []models.EventSeatView `json:"seats"`
+}
+
+// SeatBookingRequest names the seats to book, or asks for Quantity seats
+// together and lets best-available choose them. Accessible asks for a block
+// with a wheelchair space.
+type SeatBookingRequest struct {
+	SeatIDs    []uuid.UUID            `json:"seat_ids"`
+	Quantity   int                    `json:"quantity"`
+	Section    string                 `json:"section"`
+	Accessible bool                   `json:"accessible"`
+	Attendees  []models.GroupAttendee `json:"attendees"`
+}
+
+// SeatBooking pairs each booked seat with its registration: Seats[i] is held
+// by Registrations[i]. Several seats are booked as one group booking.
+type SeatBooking struct {
+	GroupBookingID *uuid.UUID             `json:"group_booking_id,omitempty"`
+	Registrations  []models.Registration  `json:"registrations"`
+	Seats          []models.EventSeatView `json:"seats"`
+}
+
+type SeatingService interface {
+	CreateSeatMap(ctx context.Context, organizerID string, req SeatMapRequest) (*models.SeatMap, error)
+	GetSeatMap(ctx context.Context, organizerID, seatMapID string) (*models.SeatMap, error)
+	ListSeatMaps(ctx context.Context, organizerID string) ([]models.SeatMap, error)
+	// AssignSeatMap gives a draft event reserved seating. Its capacity
+	// becomes the number of seats in the map.
+	AssignSeatMap(ctx context.Context, organizerID, eventID, seatMapID string) (*models.Event, error)
+	GetSeatAvailability(ctx context.Context, eventID string) (*SeatAvailability, error)
+	// BookSeats locks only the seats being booked, so bookings for
+	// different seats of the same event run side by side.
+	BookSeats(ctx context.Context, userID, eventID string, req SeatBookingRequest) (*SeatBooking, error)
+}
+
+type seatingService struct {
+	db            *gorm.DB
+	seatMapRepo   repositories.SeatMapRepository
+	eventSeatRepo repositories.EventSeatRepository
+	eventRepo     repositories.EventRepository
+	groupRepo     repositories.GroupBookingRepository
+	auditRepo     repositories.AuditLogRepository
+	maxSeats      int
+	retries       int
+}
+
+func NewSeatingService(db *gorm.DB, seatMapRepo repositories.SeatMapRepository, eventSeatRepo repositories.EventSeatRepository, eventRepo repositories.EventRepository, groupRepo repositories.GroupBookingRepository, auditRepo repositories.AuditLogRepository, maxSeats, retries int) SeatingService {
+	return &seatingService{
+		db:            db,
+		seatMapRepo:   seatMapRepo,
+		eventSeatRepo: eventSeatRepo,
+		eventRepo:     eventRepo,
+		groupRepo:     groupRepo,
+		auditRepo:     auditRepo,
+		maxSeats:      maxSeats,
+		retries:       retries,
+	}
+}
+
+func (s *seatingService) CreateSeatMap(ctx context.Context, organizerID string, req SeatMapRequest) (_ *models.SeatMap, err error) {
+	ctx, span := tracing.Start(ctx, "SeatingService.CreateSeatMap", attribute.String("organizer.id", organizerID))
+	defer func() { tracing.End(span, err) }()
+
+	orgUUID, err := uuid.Parse(organizerID)
+	if err != nil {
+		return nil, errors.New("invalid organizer ID")
+	}
+	seatMap, err := buildSeatMap(orgUUID, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.seatMapRepo.Create(ctx, seatMap); err != nil {
+		return nil, err
+	}
+	return seatMap, nil
+}
+
+// buildSeatMap validates the layout and turns it into models.
+func buildSeatMap(organizerID uuid.UUID, req SeatMapRequest) (*models.SeatMap, error) {
+	seatMap := &models.SeatMap{OrganizerID: organizerID, Name: strings.TrimSpace(req.Name)}
+	if seatMap.Name == "" {
+		return nil, errors.New("seat map name is required")
+	}
+	if len(req.Sections) == 0 {
+		return nil, errors.New("seat map needs at least one section")
+	}
+
+	total := 0
+	sectionNames := map[string]bool{}
+	for i, sec := range req.Sections {
+		section := models.SeatSection{Name: strings.TrimSpace(sec.Name), Position: i}
+		if section.Name == "" {
+			return nil, errors.New("every section needs a name")
+		}
+		if sectionNames[strings.ToLower(section.Name)] {
+			return nil, fmt.Errorf("section %q is listed twice", section.Name)
+		}
+		sectionNames[strings.ToLower(section.Name)] = true
+		if len(sec.Rows) == 0 {
+			return nil, fmt.Errorf("section %q needs at least one row", section.Name)
+		}
+
+		labels := map[string]bool{}
+		for j, r := range sec.Rows {
+			row := models.SeatRow{Label: strings.TrimSpace(r.Label), Position: j}
+			if row.Label == "" {
+				return nil, fmt.Errorf("every row of section %q needs a label", section.Name)
+			}
+			if labels[row.Label] {
+				return nil, fmt.Errorf("row %q of section %q is listed twice", row.Label, section.Name)
+			}
+			labels[row.Label] = true
+
+			seats, err := buildRowSeats(r)
+			if err != nil {
+				return nil, fmt.Errorf("row %q of section %q: %w", row.Label, section.Name, err)
+			}
+			if total += len(seats); total > maxSeatMapSeats {
+				return nil, fmt.Errorf("seat map has more than %d seats", maxSeatMapSeats)
+			}
+			row.Seats = seats
+			section.Rows = append(section.Rows, row)
+		}
+		seatMap.Sections = append(seatMap.Sections, section)
+	}
+	return seatMap, nil
+}
+
+func buildRowSeats(r SeatRowRequest) ([]models.Seat, error) {
+	if r.Seats < 1 {
+		return nil, errors.New("seats must be at least 1")
+	}
+	skip, err := seatNumbers(r.Skip, r.Seats, nil)
+	if err != nil {
+		return nil, err
+	}
+	wheelchair, err := seatNumbers(r.Wheelchair, r.Seats, skip)
+	if err != nil {
+		return nil, err
+	}
+	companion, err := seatNumbers(r.Companion, r.Seats, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	var seats []models.Seat
+	for number := 1; number <= r.Seats; number++ {
+		if skip[number] {
+			continue
+		}
+		seats = append(seats, models.Seat{
+			Number:     number,
+			Wheelchair: wheelchair[number],
+			Companion:  companion[number],
+		})
+	}
+	if len(seats) == 0 {
+		return nil, errors.New("every seat is skipped")
+	}
+	return seats, nil
+}
+
+// seatNumbers checks that every number names a seat of a row of max seats
+// that is not skipped.
+func seatNumbers(numbers []int, max int, skip map[int]bool) (map[int]bool, error) {
+	set := make(map[int]bool, len(numbers))
+	for _, number := range numbers {
+		if number < 1 || number > max || skip[number] {
+			return nil, fmt.Errorf("there is no seat %d", number)
+		}
+		set[number] = true
+	}
+	return set, nil
+}
+
+func (s *seatingService) GetSeatMap(ctx context.Context, organizerID, seatMapID string) (*models.SeatMap, error) {
+	seatMap, err := s.seatMapRepo.FindByID(ctx, seatMapID)
+	if err != nil || seatMap.OrganizerID.String() != organizerID {
+		return nil, ErrSeatMapNotFound
+	}
+	return seatMap, nil
+}
+
+func (s *seatingService) ListSeatMaps(ctx context.Context, organizerID string) ([]models.SeatMap, error) {
+	return s.seatMapRepo.FindByOrganizer(ctx, organizerID)
+}
+
+func (s *seatingService) AssignSeatMap(ctx context.Context, organizerID, eventID, seatMapID string) (_ *models.Event, err error) {
+	ctx, span := tracing.Start(ctx, "SeatingService.AssignSeatMap",
+		attribute.String("event.id", eventID),
+		attribute.String("seat_map.id", seatMapID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	seatMap, err := s.GetSeatMap(ctx, organizerID, seatMapID)
+	if err != nil {
+		return nil, err
+	}
+
+	var event models.Event
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if event, err = lockEvent(ctx, tx, eventID, "seat_map"); err != nil {
+			return ErrEventNotFound
+		}
+		if event.OrganizerID.String() != organizerID {
+			return errors.New("unauthorized to update this event")
+		}
+		// A draft has no bookings, so the inventory can be replaced freely.
+		if event.Status != models.EventStatusDraft {
+			return errors.New("seat maps can only be set while the event is a draft")
+		}
+		if event.IsPaid() {
+			return ErrSeatingNotForPaid
+		}
+		if event.AdmissionMode == models.AdmissionQueue {
+			return ErrSeatingQueued
+		}
+
+		count, err := s.eventSeatRepo.WithTx(tx).CreateForEvent(ctx, eventID, seatMapID)
+		if err != nil {
+			return err
+		}
+		if event.VenueID != nil {
+			var venue models.Venue
+			if err := tx.Where("id = ?", event.VenueID).First(&venue).Error; err != nil {
+				return err
+			}
+			if int(count) > venue.MaxCapacity {
+				return fmt.Errorf("%w: the seat map has %d seats, the venue holds %d", ErrOverVenueCapacity, count, venue.MaxCapacity)
+			}
+		}
+		event.SeatMapID = &seatMap.ID
+		event.Capacity = int(count)
+		event.SeatsRemaining = int(count)
+		return tx.Model(&event).Updates(map[string]any{
+			"seat_map_id":     event.SeatMapID,
+			"capacity":        event.Capacity,
+			"seats_remaining": event.SeatsRemaining,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("event.capacity", event.Capacity))
+	slog.InfoContext(ctx, "Seat map assigned", "event_id", eventID, "seat_map_id", seatMapID, "seats", event.Capacity)
+	return &event, nil
+}
+
+func (s *seatingService) GetSeatAvailability(ctx context.Context, eventID string) (*SeatAvailability, error) {
+	event, err := s.eventRepo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+	if !event.ReservedSeating() {
+		return nil, ErrNotReservedSeating
+	}
+	seats, err := s.eventSeatRepo.ListByEvent(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	availability := &SeatAvailability{EventID: event.ID, Total: len(seats), Seats: seats}
+	for _, seat := range seats {
+		if seat.Status == models.EventSeatAvailable {
+			availability.Available++
+		}
+	}
+	return availability, nil
+}
+
+func (s *seatingService) validateBooking(req *SeatBookingRequest) (int, error) {
+	n := req.Quantity
+	if len(req.SeatIDs) > 0 {
+		if req.Quantity != 0 && req.Quantity != len(req.SeatIDs) {
+			return 0, errors.New("give either seat_ids or quantity")
+		}
+		n = len(req.SeatIDs)
+		seen := make(map[uuid.UUID]bool, n)
+		for _, id := range req.SeatIDs {
+			if seen[id] {
+				return 0, errors.New("a seat is listed twice")
+			}
+			seen[id] = true
+		}
+	}
+	if n < 1 {
+		return 0, errors.New("choose seat_ids or a quantity of at least 1")
+	}
+	if n > s.maxSeats {
+		return 0, fmt.Errorf("%w: at most %d", ErrGroupTooLarge, s.maxSeats)
+	}
+	if len(req.Attendees) > n {
+		return 0, errors.New("more attendees than seats")
+	}
+	normalizeAttendees(req.Attendees)
+	return n, nil
+}
+
+func (s *seatingService) BookSeats(ctx context.Context, userID, eventID string, req SeatBookingRequest) (booking *SeatBooking, err error) {
+	ctx, span := tracing.Start(ctx, "SeatingService.BookSeats",
+		attribute.String("event.id", eventID),
+		attribute.String("user.id", userID),
+		attribute.Bool("seats.best_available", len(req.SeatIDs) == 0),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	n, err := s.validateBooking(&req)
+	if err != nil {
+		return nil, err
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	for attempt := 0; ; attempt++ {
+		booking, err = s.bookSeats(ctx, userUUID, eventID, n, req)
+		// Best-available chooses before it locks; when another booking took
+		// one of its seats first, it chooses again.
+		if errors.Is(err, ErrSeatTaken) && len(req.SeatIDs) == 0 {
+			if attempt < s.retries {
+				continue
+			}
+			err = ErrSeatContention
+		}
+		break
+	}
+	if err != nil {
+		metrics.BookingFailuresTotal.WithLabelValues(bookingFailureReason(err)).Inc()
+		return nil, err
+	}
+
+	metrics.BookingsTotal.Add(float64(n))
+	span.SetAttributes(attribute.Int("seats.booked", n))
+	slog.InfoContext(ctx, "Seats booked", "event_id", eventID, "user_id", userID, "seats", n)
+	return booking, nil
+}
+
+func (s *seatingService) bookSeats(ctx context.Context, userID uuid.UUID, eventID string, n int, req SeatBookingRequest) (booking *SeatBooking, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The event row is read, not locked: the seat rows are the locks.
+		var event models.Event
+		if err := tx.Where("id = ?", eventID).First(&event).Error; err != nil {
+			return ErrEventNotFound
+		}
+		if err := validateOnSale(event); err != nil {
+			return err
+		}
+		if !event.ReservedSeating() {
+			return ErrNotReservedSeating
+		}
+		if err := useAdmission(ctx, tx, eventID, userID.String()); err != nil {
+			return err
+		}
+
+		seatRepo := s.eventSeatRepo.WithTx(tx)
+		seats, err := seatRepo.ListByEvent(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		var chosen []models.EventSeatView
+		if len(req.SeatIDs) > 0 {
+			if chosen, err = pickSeats(seats, req.SeatIDs); err != nil {
+				return err
+			}
+		} else if chosen = bestAvailable(seats, n, req.Section, req.Accessible); chosen == nil {
+			return ErrNoAdjacentSeats
+		}
+
+		ids := make([]uuid.UUID, len(chosen))
+		for i, seat := range chosen {
+			ids[i] = seat.SeatID
+		}
+		locked, err := seatRepo.LockSeats(ctx, eventID, ids)
+		if err != nil {
+			return err
+		}
+		for _, seat := range locked {
+			if seat.Status != models.EventSeatAvailable {
+				return ErrSeatTaken
+			}
+		}
+
+		booking = &SeatBooking{Seats: chosen}
+		if n == 1 {
+			if err := ensureNotBooked(tx, eventID, userID.String()); err != nil {
+				return err
+			}
+			reg, err := confirmSeat(ctx, tx, s.auditRepo, event.ID, userID)
+			if err != nil {
+				return err
+			}
+			booking.Registrations = []models.Registration{*reg}
+		} else {
+			group := &models.GroupBooking{
+				EventID:   event.ID,
+				UserID:    userID,
+				Seats:     n,
+				Shortfall: models.ShortfallReject,
+				Status:    models.GroupBookingConfirmed,
+				Attendees: req.Attendees,
+			}
+			if err := s.groupRepo.WithTx(tx).Create(ctx, group); err != nil {
+				return err
+			}
+			if err := confirmGroupSeats(ctx, tx, s.auditRepo, group, n, models.AuditActionRegistrationConfirmed); err != nil {
+				return err
+			}
+			booking.GroupBookingID = &group.ID
+			booking.Registrations = group.Registrations
+		}
+
+		for i := range chosen {
+			if err := seatRepo.Book(ctx, eventID, chosen[i].SeatID, booking.Registrations[i].ID); err != nil {
+				return err
+			}
+			chosen[i].Status = models.EventSeatBooked
+		}
+		// The counter is written last, so the event row is held only while
+		// the transaction commits.
+		return takeSeats(tx, &event, n)
+	})
+	return booking, err
+}
+
+// pickSeats returns the requested seats in the order they were asked for.
+func pickSeats(seats []models.EventSeatView, ids []uuid.UUID) ([]models.EventSeatView, error) {
+	byID := make(map[uuid.UUID]models.EventSeatView, len(seats))
+	for _, seat := range seats {
+		byID[seat.SeatID] = seat
+	}
+	chosen := make([]models.EventSeatView, 0, len(ids))
+	for _, id := range ids {
+		seat, ok := byID[id]
+		if !ok {
+			return nil, ErrSeatNotFound
+		}
+		if seat.Status != models.EventSeatAvailable {
+			return nil, ErrSeatTaken
+		}
+		chosen = append(chosen, seat)
+	}
+	return chosen, nil
+}
+
+// bestAvailable picks n adjacent free seats in the first row, in seating
+// order, that has them, as close to the middle of the row as possible. It
+// returns nil when no row has such a block.
+func bestAvailable(seats []models.EventSeatView, n int, section string, accessible bool) []models.EventSeatView {
+	for start := 0; start < len(seats); {
+		end := start
+		for end < len(seats) && seats[end].RowID == seats[start].RowID {
+			end++
+		}
+		row := seats[start:end]
+		start = end
+
+		if section != "" && !strings.EqualFold(row[0].Section, section) {
+			continue
+		}
+		if block := bestInRow(row, n, accessible); block != nil {
+			return append([]models.EventSeatView(nil), block...)
+		}
+	}
+	return nil
+}
+
+func bestInRow(row []models.EventSeatView, n int, accessible bool) []models.EventSeatView {
+	middle := float64(row[0].Number+row[len(row)-1].Number) / 2
+	var best []models.EventSeatView
+	bestDistance := math.Inf(1)
+	for i := 0; i+n <= len(row); i++ {
+		block := row[i : i+n]
+		if !seatsTogether(block, accessible) {
+			continue
+		}
+		center := float64(block[0].Number+block[n-1].Number) / 2
+		if distance := math.Abs(center - middle); distance < bestDistance {
+			best, bestDistance = block, distance
+		}
+	}
+	return best
+}
+
+// seatsTogether reports whether the block is free and unbroken by an aisle.
+// Wheelchair spaces are kept for accessible requests, which need one.
+func seatsTogether(block []models.EventSeatView, accessible bool) bool {
+	wheelchair := false
+	for i, seat := range block {
+		if seat.Status != models.EventSeatAvailable {
+			return false
+		}
+		if i > 0 && seat.Number != block[i-1].Number+1 {
+			return false
+		}
+		if seat.Wheelchair {
+			if !accessible {
+				return false
+			}
+			wheelchair = true
+		}
+	}
+	return wheelchair || !accessible
+}
+
+// releaseEventSeats frees the reserved seats of registrations that are
+// being cancelled.
+func releaseEventSeats(tx *gorm.DB, registrationIDs ...uuid.UUID) error {
+	if len(registrationIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.EventSeat{}).
+		Where("registration_id IN ?", registrationIDs).
+		Updates(map[string]any{
+			"status":          models.EventSeatAvailable,
+			"registration_id": nil,
+		}).Error
+}
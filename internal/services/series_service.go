@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+// Upper bound on the sessions one series generates.
+const maxSeriesSessions = 200
+
+var (
+	ErrSeriesNotFound     = errors.New("event series not found")
+	ErrSeriesNoSessions   = errors.New("series has no upcoming sessions open for booking")
+	ErrSeriesQueued       = errors.New("a session of this series uses queued admission: book sessions one by one")
+	ErrSeriesWaitingRoom  = errors.New("a session of this series has a waiting room: book sessions one by one")
+	errSeriesNeedsOneStop = errors.New("recurrence needs exactly one of until and count")
+)
+
+// SeriesUpdate changes every upcoming session of a series. Nil fields are
+// left as they are.
+type SeriesUpdate struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Location    *string `json:"location"`
+	Capacity    *int    `json:"capacity"`
+}
+
+type SeriesService interface {
+	// CreateSeries stores the series and generates its sessions as draft
+	// events, all in one transaction.
+	CreateSeries(ctx context.Context, organizerID string, series *models.EventSeries) (*models.EventSeries, error)
+	GetSeries(ctx context.Context, organizerID, seriesID string) (*models.EventSeries, error)
+	ListSeries(ctx context.Context, organizerID string) ([]models.EventSeries, error)
+	// PublishSeries publishes every upcoming draft session.
+	PublishSeries(ctx context.Context, organizerID, seriesID string) (*models.EventSeries, error)
+	// UpdateSeries applies the update to the series and to every session
+	// that has not started, including sessions edited on their own.
+	UpdateSeries(ctx context.Context, organizerID, seriesID string, update SeriesUpdate) (*models.EventSeries, error)
+	// GetPublicSeries returns the series with its published sessions.
+	GetPublicSeries(ctx context.Context, seriesID string) (*models.EventSeries, error)
+	// SubscribeSeries books the user a seat on every upcoming published
+	// session, or on none of them.
+	SubscribeSeries(ctx context.Context, userID, seriesID string) ([]models.Registration, error)
+}
+
+type seriesService struct {
+	db         *gorm.DB
+	seriesRepo repositories.SeriesRepository
+	eventRepo  repositories.EventRepository
+	venueRepo  repositories.VenueRepository
+	waitRepo   repositories.WaitlistRepository
+	auditRepo  repositories.AuditLogRepository
+}
+
+func NewSeriesService(db *gorm.DB, seriesRepo repositories.SeriesRepository, eventRepo repositories.EventRepository, venueRepo repositories.VenueRepository, waitRepo repositories.WaitlistRepository, auditRepo repositories.AuditLogRepository) SeriesService {
+	return &seriesService{
+		db:         db,
+		seriesRepo: seriesRepo,
+		eventRepo:  eventRepo,
+		venueRepo:  venueRepo,
+		waitRepo:   waitRepo,
+		auditRepo:  auditRepo,
+	}
+}
+
+func (s *seriesService) CreateSeries(ctx context.Context, organizerID string, series *models.EventSeries) (_ *models.EventSeries, err error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.CreateSeries", attribute.String("organizer.id", organizerID))
+	defer func() { tracing.End(span, err) }()
+
+	orgUUID, err := uuid.Parse(organizerID)
+	if err != nil {
+		return nil, errors.New("invalid organizer ID")
+	}
+	series.ID = uuid.Nil
+	series.OrganizerID = orgUUID
+	series.Events = nil
+	if err := validateSeries(series); err != nil {
+		return nil, err
+	}
+	if series.VenueID != nil && strings.TrimSpace(series.Location) != "" {
+		return nil, ErrVenueLocation
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if series.VenueID != nil {
+			venue, err := s.venueRepo.WithTx(tx).FindByID(ctx, series.VenueID.String())
+			if err != nil || venue.OrganizerID != orgUUID {
+				return ErrVenueNotFound
+			}
+			series.Location = venue.Name
+			if series.Timezone == "" {
+				series.Timezone = venue.Timezone
+			}
+		}
+		if series.Timezone == "" {
+			series.Timezone = "UTC"
+		}
+		starts, err := sessionStarts(series)
+		if err != nil {
+			return err
+		}
+		if err := s.seriesRepo.WithTx(tx).Create(ctx, series); err != nil {
+			return err
+		}
+
+		duration := series.EndsAt.Sub(series.StartsAt)
+		for _, start := range starts {
+			event := models.Event{
+				Title:          series.Title,
+				Description:    series.Description,
+				Location:       series.Location,
+				EventDate:      start,
+				EndsAt:         start.Add(duration),
+				Timezone:       series.Timezone,
+				Capacity:       series.Capacity,
+				SeatsRemaining: series.Capacity,
+				OrganizerID:    orgUUID,
+				Status:         models.EventStatusDraft,
+				AdmissionMode:  models.AdmissionDirect,
+				VenueID:        series.VenueID,
+				SeriesID:       &series.ID,
+			}
+			if event.VenueID != nil {
+				if err := scheduleAtVenue(ctx, tx, s.venueRepo, s.eventRepo, &event); err != nil {
+					return fmt.Errorf("session on %s: %w", start.Format(time.RFC3339), err)
+				}
+			}
+			if err := s.eventRepo.WithTx(tx).Create(ctx, &event); err != nil {
+				return err
+			}
+			series.Events = append(series.Events, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("series.id", series.ID.String()), attribute.Int("series.sessions", len(series.Events)))
+	slog.InfoContext(ctx, "Event series created", "series_id", series.ID, "organizer_id", organizerID, "sessions", len(series.Events))
+	return series, nil
+}
+
+func validateSeries(series *models.EventSeries) error {
+	series.Title = strings.TrimSpace(series.Title)
+	if series.Title == "" {
+		return errors.New("title is required")
+	}
+	if series.Capacity < 1 {
+		return errors.New("capacity must be at least 1")
+	}
+	if series.StartsAt.IsZero() || series.EndsAt.IsZero() {
+		return errors.New("starts_at and ends_at are required")
+	}
+	if !series.EndsAt.After(series.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	if series.Timezone != "" {
+		if _, err := time.LoadLocation(series.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", series.Timezone)
+		}
+	}
+
+	rule := &series.Recurrence
+	rule.Frequency = models.RecurrenceFrequency(strings.ToUpper(string(rule.Frequency)))
+	if !rule.Frequency.Valid() {
+		return errors.New("frequency must be DAILY, WEEKLY or MONTHLY")
+	}
+	if rule.Interval == 0 {
+		rule.Interval = 1
+	}
+	if rule.Interval < 1 {
+		return errors.New("interval must be at least 1")
+	}
+	if len(rule.ByDay) > 0 && rule.Frequency != models.FrequencyWeekly {
+		return errors.New("by_day is only supported for WEEKLY series")
+	}
+	for i, code := range rule.ByDay {
+		rule.ByDay[i] = strings.ToUpper(code)
+		if _, ok := models.Weekdays[rule.ByDay[i]]; !ok {
+			return fmt.Errorf("unknown day %q in by_day, use MO, TU, WE, TH, FR, SA or SU", code)
+		}
+	}
+	if (rule.Until == nil) == (rule.Count == 0) {
+		return errSeriesNeedsOneStop
+	}
+	if rule.Count < 0 || rule.Count > maxSeriesSessions {
+		return fmt.Errorf("count must be between 1 and %d", maxSeriesSessions)
+	}
+	if rule.Until != nil && rule.Until.Before(series.StartsAt) {
+		return errors.New("until must not be before starts_at")
+	}
+	return nil
+}
+
+// sessionStarts expands the series' rule in its time zone. The first
+// session must be the one starts_at describes, and sessions may not
+// overlap each other.
+func sessionStarts(series *models.EventSeries) ([]time.Time, error) {
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", series.Timezone)
+	}
+	first := series.StartsAt.In(loc)
+	rule := series.Recurrence
+	if len(rule.ByDay) > 0 && !slices.ContainsFunc(rule.ByDay, func(code string) bool { return models.Weekdays[code] == first.Weekday() }) {
+		return nil, fmt.Errorf("starts_at falls on a %s, which by_day does not include", first.Weekday())
+	}
+
+	starts := rule.Occurrences(first, maxSeriesSessions+1)
+	if len(starts) > maxSeriesSessions {
+		return nil, fmt.Errorf("series would have more than %d sessions", maxSeriesSessions)
+	}
+	duration := series.EndsAt.Sub(series.StartsAt)
+	for i := 1; i < len(starts); i++ {
+		if starts[i].Before(starts[i-1].Add(duration)) {
+			return nil, errors.New("sessions would overlap: each must end before the next starts")
+		}
+	}
+	return starts, nil
+}
+
+func (s *seriesService) GetSeries(ctx context.Context, organizerID, seriesID string) (*models.EventSeries, error) {
+	series, err := s.seriesRepo.FindByID(ctx, seriesID, "")
+	if err != nil || series.OrganizerID.String() != organizerID {
+		return nil, ErrSeriesNotFound
+	}
+	return series, nil
+}
+
+func (s *seriesService) ListSeries(ctx context.Context, organizerID string) ([]models.EventSeries, error) {
+	return s.seriesRepo.FindByOrganizer(ctx, organizerID)
+}
+
+func (s *seriesService) GetPublicSeries(ctx context.Context, seriesID string) (*models.EventSeries, error) {
+	series, err := s.seriesRepo.FindByID(ctx, seriesID, models.EventStatusPublished)
+	if err != nil || len(series.Events) == 0 {
+		return nil, ErrSeriesNotFound
+	}
+	return series, nil
+}
+
+func (s *seriesService) PublishSeries(ctx context.Context, organizerID, seriesID string) (_ *models.EventSeries, err error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.PublishSeries", attribute.String("series.id", seriesID))
+	defer func() { tracing.End(span, err) }()
+
+	var published int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		series, err := s.seriesRepo.WithTx(tx).LockByID(ctx, seriesID)
+		if err != nil || series.OrganizerID.String() != organizerID {
+			return ErrSeriesNotFound
+		}
+		now := time.Now()
+		result := tx.Model(&models.Event{}).
+			Where("series_id = ? AND status = ? AND event_date > ?", series.ID, models.EventStatusDraft, now).
+			Updates(map[string]any{"status": models.EventStatusPublished, "published_at": now})
+		published = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Event series published", "series_id", seriesID, "sessions", published)
+	return s.GetSeries(ctx, organizerID, seriesID)
+}
+
+func (s *seriesService) UpdateSeries(ctx context.Context, organizerID, seriesID string, update SeriesUpdate) (_ *models.EventSeries, err error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.UpdateSeries", attribute.String("series.id", seriesID))
+	defer func() { tracing.End(span, err) }()
+
+	var updated int
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seriesRepo := s.seriesRepo.WithTx(tx)
+		series, err := seriesRepo.LockByID(ctx, seriesID)
+		if err != nil || series.OrganizerID.String() != organizerID {
+			return ErrSeriesNotFound
+		}
+		if update.Location != nil && series.VenueID != nil {
+			return ErrVenueLocation
+		}
+
+		sessions, err := lockSessions(ctx, tx, seriesRepo, seriesID, "series_update", models.EventStatusDraft, models.EventStatusPublished)
+		if err != nil {
+			return err
+		}
+		eventUpdate := EventUpdate{Title: update.Title, Description: update.Description, Location: update.Location, Capacity: update.Capacity}
+		for i := range sessions {
+			if err := applyEventDetails(ctx, tx, s.waitRepo, s.auditRepo, &sessions[i], eventUpdate); err != nil {
+				return fmt.Errorf("session on %s: %w", sessions[i].EventDate.In(sessions[i].Zone()).Format(time.RFC3339), err)
+			}
+			if sessions[i].VenueID != nil && update.Capacity != nil {
+				if err := scheduleAtVenue(ctx, tx, s.venueRepo, s.eventRepo, &sessions[i]); err != nil {
+					return err
+				}
+			}
+		}
+		updated = len(sessions)
+
+		if update.Title != nil {
+			series.Title = strings.TrimSpace(*update.Title)
+		}
+		if update.Description != nil {
+			series.Description = *update.Description
+		}
+		if update.Location != nil {
+			series.Location = *update.Location
+		}
+		if update.Capacity != nil {
+			series.Capacity = *update.Capacity
+		}
+		if series.Title == "" || series.Capacity < 1 {
+			return errors.New("title must not be empty and capacity must be at least 1")
+		}
+		return seriesRepo.Update(ctx, series)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Event series updated", "series_id", seriesID, "sessions", updated)
+	return s.GetSeries(ctx, organizerID, seriesID)
+}
+
+// lockSessions locks the series' upcoming sessions in the given statuses,
+// in ID order so that two callers locking sessions of one series cannot
+// deadlock, and returns them in date order.
+func lockSessions(ctx context.Context, tx *gorm.DB, seriesRepo repositories.SeriesRepository, seriesID, operation string, statuses ...models.EventStatus) ([]models.Event, error) {
+	upcoming, err := seriesRepo.FindUpcoming(ctx, seriesID, time.Now(), statuses...)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(upcoming))
+	for i, event := range upcoming {
+		ids[i] = event.ID.String()
+	}
+	slices.Sort(ids)
+
+	locked := make(map[string]models.Event, len(ids))
+	for _, id := range ids {
+		event, err := lockEvent(ctx, tx, id, operation)
+		if err != nil {
+			return nil, err
+		}
+		locked[id] = event
+	}
+	sessions := make([]models.Event, 0, len(upcoming))
+	for _, event := range upcoming {
+		sessions = append(sessions, locked[event.ID.String()])
+	}
+	return sessions, nil
+}
+
+func (s *seriesService) SubscribeSeries(ctx context.Context, userID, seriesID string) (_ []models.Registration, err error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.SubscribeSeries",
+		attribute.String("series.id", seriesID),
+		attribute.String("user.id", userID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var regs []models.Registration
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sessions, err := lockSessions(ctx, tx, s.seriesRepo.WithTx(tx), seriesID, "series_subscribe", models.EventStatusPublished)
+		if err != nil {
+			return err
+		}
+		if len(sessions) == 0 {
+			return ErrSeriesNoSessions
+		}
+
+		ids := make([]uuid.UUID, len(sessions))
+		for i, event := range sessions {
+			ids[i] = event.ID
+		}
+		// Admission tokens are per event, so sessions behind a waiting room
+		// are booked one by one.
+		var rooms int64
+		if err := tx.Model(&models.WaitingRoom{}).Where("event_id IN ? AND enabled", ids).Count(&rooms).Error; err != nil {
+			return err
+		}
+		if rooms > 0 {
+			return ErrSeriesWaitingRoom
+		}
+
+		for i := range sessions {
+			event := &sessions[i]
+			if err := validateBookable(*event); err != nil {
+				return err
+			}
+			if event.IsPaid() {
+				return ErrPaymentRequired
+			}
+			if event.AdmissionMode == models.AdmissionQueue {
+				return ErrSeriesQueued
+			}
+			if err := ensureNotBooked(tx, event.ID.String(), userID); err != nil {
+				return fmt.Errorf("session on %s: %w", event.EventDate.In(event.Zone()).Format(time.RFC3339), err)
+			}
+			if event.SeatsRemaining < 1 {
+				return fmt.Errorf("%w: the session on %s", ErrSoldOut, event.EventDate.In(event.Zone()).Format(time.RFC3339))
+			}
+			if err := takeSeat(tx, event); err != nil {
+				return err
+			}
+			reg, err := confirmSeat(ctx, tx, s.auditRepo, event.ID, userUUID)
+			if err != nil {
+				return err
+			}
+			regs = append(regs, *reg)
+		}
+		return nil
+	})
+	if err != nil {
+		metrics.BookingFailuresTotal.WithLabelValues(bookingFailureReason(err)).Inc()
+		return nil, err
+	}
+
+	metrics.BookingsTotal.Add(float64(len(regs)))
+	span.SetAttributes(attribute.Int("series.sessions", len(regs)))
+	slog.InfoContext(ctx, "Series subscribed", "series_id", seriesID, "user_id", userID, "sessions", len(regs))
+	return regs, nil
+}
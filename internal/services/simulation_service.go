@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	maxSimulationUsers       = 10000
+	maxSimulationWorkers     = 500
+	defaultSimulationWorkers = 50
+)
+
+type SimulationService interface {
+	// Start validates the scenario and runs it in the background; poll
+	// GetJob for the report.
+	Start(ctx context.Context, eventID, requestedBy string, scenario models.SimulationScenario) (*models.SimulationJob, error)
+	// Run executes the scenario on the calling goroutine and returns the
+	// finished job.
+	Run(ctx context.Context, eventID, requestedBy string, scenario models.SimulationScenario) (*models.SimulationJob, error)
+	GetJob(ctx context.Context, jobID string) (*models.SimulationJob, error)
+	// Close stops background runs, which still clean up after themselves,
+	// and waits for them or for ctx to expire.
+	Close(ctx context.Context) error
+}
+
+type simulationService struct {
+	simRepo    repositories.SimulationRepository
+	eventRepo  repositories.EventRepository
+	regService RegistrationService
+	reconciler ReconciliationService
+	allocators *SeatAllocators
+
+	runCtx   context.Context
+	stopRuns context.CancelFunc
+	running  sync.WaitGroup
+}
+
+func NewSimulationService(simRepo repositories.SimulationRepository, eventRepo repositories.EventRepository, regService RegistrationService, reconciler ReconciliationService, allocators *SeatAllocators) SimulationService {
+	runCtx, stopRuns := context.WithCancel(context.Background())
+	return &simulationService{
+		simRepo:    simRepo,
+		eventRepo:  eventRepo,
+		regService: regService,
+		reconciler: reconciler,
+		allocators: allocators,
+		runCtx:     runCtx,
+		stopRuns:   stopRuns,
+	}
+}
+
+func (s *simulationService) Start(ctx context.Context, eventID, requestedBy string, scenario models.SimulationScenario) (*models.SimulationJob, error) {
+	job, event, err := s.prepare(ctx, eventID, requestedBy, scenario)
+	if err != nil {
+		return nil, err
+	}
+
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		s.execute(s.runCtx, job, event)
+	}()
+	return job, nil
+}
+
+func (s *simulationService) Run(ctx context.Context, eventID, requestedBy string, scenario models.SimulationScenario) (*models.SimulationJob, error) {
+	job, event, err := s.prepare(ctx, eventID, requestedBy, scenario)
+	if err != nil {
+		return nil, err
+	}
+	s.execute(ctx, job, event)
+	return job, nil
+}
+
+func (s *simulationService) GetJob(ctx context.Context, jobID string) (*models.SimulationJob, error) {
+	job, err := s.simRepo.FindByID(ctx, jobID)
+	if err != nil {
+		return nil, errors.New("simulation not found")
+	}
+	return job, nil
+}
+
+func (s *simulationService) Close(ctx context.Context) error {
+	s.stopRuns()
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// prepare validates the scenario, fills in defaults and records the job.
+func (s *simulationService) prepare(ctx context.Context, eventID, requestedBy string, scenario models.SimulationScenario) (*models.SimulationJob, *models.Event, error) {
+	if scenario.Users <= 0 || scenario.Users > maxSimulationUsers {
+		return nil, nil, fmt.Errorf("users must be between 1 and %d", maxSimulationUsers)
+	}
+	if scenario.Workers == 0 {
+		scenario.Workers = min(scenario.Users, defaultSimulationWorkers)
+	}
+	if scenario.Workers < 0 || scenario.Workers > maxSimulationWorkers {
+		return nil, nil, fmt.Errorf("workers must be between 1 and %d", maxSimulationWorkers)
+	}
+	if scenario.CancelRatio < 0 || scenario.CancelRatio > 1 || scenario.RebookRatio < 0 || scenario.RebookRatio > 1 {
+		return nil, nil, errors.New("cancel_ratio and rebook_ratio must be between 0 and 1")
+	}
+	if scenario.BurstSize < 0 || scenario.BurstIntervalMs < 0 {
+		return nil, nil, errors.New("burst_size and burst_interval_ms must not be negative")
+	}
+	if scenario.Strategy != "" && !scenario.Strategy.Valid() {
+		return nil, nil, errors.New("strategy must be pessimistic, optimistic or atomic")
+	}
+	if scenario.Seed == 0 {
+		scenario.Seed = time.Now().UnixNano()
+	}
+
+	event, err := s.eventRepo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, nil, ErrEventNotFound
+	}
+
+	job := &models.SimulationJob{
+		EventID:  event.ID,
+		Status:   models.SimulationJobRunning,
+		Scenario: scenario,
+	}
+	if requestedBy != "" {
+		id, err := uuid.Parse(requestedBy)
+		if err != nil {
+			return nil, nil, errors.New("invalid requester ID")
+		}
+		job.RequestedBy = &id
+	}
+	if err := s.simRepo.Create(ctx, job); err != nil {
+		return nil, nil, err
+	}
+	return job, event, nil
+}
+
+func (s *simulationService) execute(ctx context.Context, job *models.SimulationJob, event *models.Event) {
+	ctx, span := tracing.Start(ctx, "SimulationService.execute",
+		attribute.String("event.id", event.ID.String()),
+		attribute.String("simulation.id", job.ID.String()),
+		attribute.Int("simulation.users", job.Scenario.Users),
+		attribute.Int("simulation.workers", job.Scenario.Workers),
+	)
+
+	report, err := s.simulate(ctx, job, event)
+	tracing.End(span, err)
+
+	now := time.Now()
+	job.Report = report
+	job.FinishedAt = &now
+	job.Status = models.SimulationJobCompleted
+	if err != nil {
+		job.Status = models.SimulationJobFailed
+		job.Error = err.Error()
+	}
+	// Record the outcome even when the run was stopped by shutdown.
+	if err := s.simRepo.Update(context.WithoutCancel(ctx), job); err != nil {
+		slog.ErrorContext(ctx, "Failed to save simulation result", "simulation_id", job.ID, "error", err)
+	}
+}
+
+func (s *simulationService) simulate(ctx context.Context, job *models.SimulationJob, event *models.Event) (_ *models.SimulationReport, err error) {
+	scenario := job.Scenario
+	eventID := event.ID.String()
+	report := &models.SimulationReport{TotalAttempted: scenario.Users}
+
+	// Synthetic users satisfy the registrations foreign key. The job ID keeps
+	// emails unique across runs.
+	users := make([]models.User, scenario.Users)
+	for i := range users {
+		users[i] = models.User{
+			ID:           uuid.New(),
+			Name:         fmt.Sprintf("Sim User %d", i),
+			Email:        fmt.Sprintf("sim%d_%s@sim.local", i, job.ID.String()[:8]),
+			PasswordHash: "not_needed",
+			Role:         models.RoleAudience,
+		}
+	}
+	if err := s.simRepo.CreateUsers(ctx, users); err != nil {
+		return nil, fmt.Errorf("failed to create simulated users: %w", err)
+	}
+	defer func() {
+		cleaned, cleanupErr := s.cleanup(context.WithoutCancel(ctx), job, users)
+		report.CleanedUpUsers = cleaned
+		if err == nil {
+			err = cleanupErr
+		}
+	}()
+
+	strategy := event.AllocationStrategy
+	if scenario.Strategy != "" && scenario.Strategy != strategy {
+		if err := s.setStrategy(ctx, eventID, scenario.Strategy); err != nil {
+			return report, err
+		}
+		defer func() {
+			if err := s.setStrategy(context.WithoutCancel(ctx), eventID, strategy); err != nil {
+				slog.ErrorContext(ctx, "Failed to restore allocation strategy", "event_id", eventID, "error", err)
+			}
+		}()
+		strategy = scenario.Strategy
+	}
+	report.Strategy = s.allocators.For(strategy).Strategy()
+
+	collector := newSimulationCollector()
+	started := time.Now()
+	s.drive(ctx, scenario, eventID, users, report, collector)
+	report.DurationMs = time.Since(started).Milliseconds()
+	report.Latency, report.Errors = collector.summarize()
+
+	snapshot, err := s.simRepo.SeatSnapshot(context.WithoutCancel(ctx), eventID)
+	if err != nil {
+		return report, err
+	}
+	report.FinalSeatsRemaining = snapshot.SeatsRemaining
+	report.Invariants = checkInvariants(snapshot, report)
+	for _, inv := range report.Invariants {
+		if !inv.OK {
+			slog.WarnContext(ctx, "Simulation invariant violated", "simulation_id", job.ID, "event_id", eventID, "invariant", inv.Name, "detail", inv.Detail)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return report, errors.New("simulation stopped before all users arrived")
+	}
+	return report, nil
+}
+
+// drive releases users in bursts to a bounded pool of workers, each of which
+// plays one user's book / cancel / rebook sequence at a time.
+func (s *simulationService) drive(ctx context.Context, scenario models.SimulationScenario, eventID string, users []models.User, report *models.SimulationReport, collector *simulationCollector) {
+	arrivals := make(chan int)
+	go func() {
+		defer close(arrivals)
+		interval := time.Duration(scenario.BurstIntervalMs) * time.Millisecond
+		for i := range users {
+			if scenario.BurstSize > 0 && i > 0 && i%scenario.BurstSize == 0 && interval > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(interval):
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case arrivals <- i:
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(scenario.Workers)
+	for w := 0; w < scenario.Workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range arrivals {
+				// Per-user dice keep a seed's cancel/rebook choices stable
+				// regardless of which worker picks the user up.
+				dice := rand.New(rand.NewSource(scenario.Seed + int64(i)))
+				s.playUser(ctx, scenario, eventID, users[i].ID.String(), dice, report, collector)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *simulationService) playUser(ctx context.Context, scenario models.SimulationScenario, eventID, userID string, dice *rand.Rand, report *models.SimulationReport, collector *simulationCollector) {
+	start := time.Now()
+	reg, waitlist, err := s.regService.BookEventWithoutAdmission(ctx, userID, eventID)
+	collector.observe("book", time.Since(start), err)
+	switch {
+	case err != nil:
+		atomic.AddInt64(&report.FailedCount, 1)
+		return
+	case waitlist != nil:
+		atomic.AddInt64(&report.WaitlistedCount, 1)
+		return
+	default:
+		atomic.AddInt64(&report.SuccessCount, 1)
+	}
+
+	if dice.Float64() >= scenario.CancelRatio {
+		return
+	}
+	start = time.Now()
+	err = s.regService.CancelRegistration(ctx, userID, reg.ID.String())
+	collector.observe("cancel", time.Since(start), err)
+	if err != nil {
+		return
+	}
+	atomic.AddInt64(&report.CancelledCount, 1)
+
+	if dice.Float64() >= scenario.RebookRatio {
+		return
+	}
+	start = time.Now()
+	reg, _, err = s.regService.BookEventWithoutAdmission(ctx, userID, eventID)
+	collector.observe("rebook", time.Since(start), err)
+	if err == nil && reg != nil {
+		atomic.AddInt64(&report.RebookedCount, 1)
+	}
+}
+
+func (s *simulationService) setStrategy(ctx context.Context, eventID string, strategy models.AllocationStrategy) error {
+	return s.eventRepo.SetAllocationStrategy(ctx, eventID, strategy)
+}
+
+// cleanup deletes the synthetic users and everything they booked, then has
+// the reconciler recompute the event's seats and promote real waitlisted
+// users into seats the simulation held.
+func (s *simulationService) cleanup(ctx context.Context, job *models.SimulationJob, users []models.User) (int, error) {
+	ids := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	if err := s.simRepo.DeleteUsers(ctx, ids); err != nil {
+		return 0, fmt.Errorf("failed to clean up simulated users: %w", err)
+	}
+
+	actor := ""
+	if job.RequestedBy != nil {
+		actor = job.RequestedBy.String()
+	}
+	if _, err := s.reconciler.RepairEvent(ctx, job.EventID.String(), actor); err != nil {
+		return len(ids), fmt.Errorf("failed to reconcile event after cleanup: %w", err)
+	}
+	return len(ids), nil
+}
+
+func checkInvariants(snap *models.SeatSnapshot, report *models.SimulationReport) []models.InvariantResult {
+	check := func(name string, ok bool, format string, args ...any) models.InvariantResult {
+		r := models.InvariantResult{Name: name, OK: ok}
+		if !ok {
+			r.Detail = fmt.Sprintf(format, args...)
+		}
+		return r
+	}
+
+	free := snap.Capacity - int(snap.Confirmed) - int(snap.Held)
+	outcomes := report.SuccessCount + report.WaitlistedCount + report.FailedCount
+	return []models.InvariantResult{
+		check("no_overbooking", snap.Confirmed+snap.Held <= int64(snap.Capacity),
+			"%d confirmed registrations and %d held seats for %d seats", snap.Confirmed, snap.Held, snap.Capacity),
+		check("seat_counter_matches_registrations", snap.SeatsRemaining == free,
+			"seats_remaining is %d, capacity minus confirmed and held is %d", snap.SeatsRemaining, free),
+		check("seats_remaining_not_negative", snap.SeatsRemaining >= 0,
+			"seats_remaining is %d", snap.SeatsRemaining),
+		check("waitlist_positions_unique", snap.DuplicatePositions == 0,
+			"%d duplicate waitlist positions", snap.DuplicatePositions),
+		check("no_user_confirmed_and_waitlisted", snap.ConfirmedAndWaitlisted == 0,
+			"%d users both hold a seat and wait for one", snap.ConfirmedAndWaitlisted),
+		check("no_waitlist_with_free_seats", snap.Waitlisted == 0 || free <= 0,
+			"%d waitlisted while %d seats are free", snap.Waitlisted, free),
+		check("every_user_has_an_outcome", outcomes == int64(report.TotalAttempted),
+			"%d outcomes for %d users", outcomes, report.TotalAttempted),
+	}
+}
+
+// simulationCollector gathers per-operation latencies and failure reasons
+// from the worker pool.
+type simulationCollector struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]map[string]int64
+}
+
+func newSimulationCollector() *simulationCollector {
+	return &simulationCollector{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]map[string]int64),
+	}
+}
+
+func (c *simulationCollector) observe(operation string, took time.Duration, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.latencies[operation] = append(c.latencies[operation], took)
+	if err != nil {
+		if c.errors[operation] == nil {
+			c.errors[operation] = make(map[string]int64)
+		}
+		c.errors[operation][bookingFailureReason(err)]++
+	}
+}
+
+func (c *simulationCollector) summarize() (map[string]models.LatencySummary, map[string]map[string]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	summaries := make(map[string]models.LatencySummary, len(c.latencies))
+	for operation, samples := range c.latencies {
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		summaries[operation] = models.LatencySummary{
+			Count: len(samples),
+			P50Ms: percentileMs(samples, 0.50),
+			P95Ms: percentileMs(samples, 0.95),
+			P99Ms: percentileMs(samples, 0.99),
+			MaxMs: percentileMs(samples, 1),
+		}
+	}
+	return summaries, c.errors
+}
+
+// percentileMs returns the nearest-rank percentile of sorted samples.
+func percentileMs(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	rank = max(rank, 0)
+	return float64(sorted[rank].Microseconds()) / 1000
+}
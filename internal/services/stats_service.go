@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+	statsTopN        = 10
+)
+
+// PlatformStats is the admin dashboard payload. Event and booking figures are
+// read from materialized views, so they lag by at most one refresh interval.
+type PlatformStats struct {
+	GeneratedAt         time.Time                 `json:"generated_at"`
+	Days                int                       `json:"days"`
+	ActiveUsersByRole   []models.RoleCount        `json:"active_users_by_role"`
+	EventsByStatus      []models.EventStatusCount `json:"events_by_status"`
+	BookingsPerDay      []models.DailyBookings    `json:"bookings_per_day"`
+	TopEventsByFillRate []models.EventStats       `json:"top_events_by_fill_rate"`
+	TopOrganizers       []models.OrganizerVolume  `json:"top_organizers"`
+	WaitlistPressure    []models.EventStats       `json:"waitlist_pressure"`
+}
+
+type StatsService interface {
+	GetPlatformStats(ctx context.Context, days int) (*PlatformStats, error)
+	RunRefreshJob(ctx context.Context, interval time.Duration)
+}
+
+type statsService struct {
+	statsRepo repositories.StatsRepository
+}
+
+func NewStatsService(statsRepo repositories.StatsRepository) StatsService {
+	return &statsService{statsRepo: statsRepo}
+}
+
+func (s *statsService) GetPlatformStats(ctx context.Context, days int) (*PlatformStats, error) {
+	if days == 0 {
+		days = defaultStatsDays
+	}
+	if days < 0 || days > maxStatsDays {
+		return nil, errors.New("days must be between 1 and 365")
+	}
+
+	now := time.Now().UTC()
+	stats := &PlatformStats{GeneratedAt: now, Days: days}
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -(days - 1))
+
+	var err error
+	if stats.ActiveUsersByRole, err = s.statsRepo.CountActiveUsersByRole(ctx); err != nil {
+		return nil, err
+	}
+	if stats.EventsByStatus, err = s.statsRepo.CountEventsByStatus(ctx); err != nil {
+		return nil, err
+	}
+	if stats.BookingsPerDay, err = s.statsRepo.DailyBookingsSince(ctx, since); err != nil {
+		return nil, err
+	}
+	if stats.TopEventsByFillRate, err = s.statsRepo.TopEventsByFillRate(ctx, statsTopN); err != nil {
+		return nil, err
+	}
+	if stats.TopOrganizers, err = s.statsRepo.TopOrganizersByVolume(ctx, statsTopN); err != nil {
+		return nil, err
+	}
+	if stats.WaitlistPressure, err = s.statsRepo.EventsWithWaitlistPressure(ctx, statsTopN); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// RunRefreshJob refreshes the dashboard views every interval until ctx is done.
+func (s *statsService) RunRefreshJob(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *statsService) refresh(ctx context.Context) {
+	refreshed, err := s.statsRepo.RefreshViews(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to refresh dashboard views", "error", err)
+		return
+	}
+	if !refreshed {
+		slog.DebugContext(ctx, "Dashboard views are being refreshed by another instance. Skipping.")
+	}
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTransferNotFound      = errors.New("transfer not found")
+	ErrTransferNotPending    = errors.New("transfer is no longer pending")
+	ErrTransfersClosed       = errors.New("transfers are closed for this event")
+	ErrTransferStale         = errors.New("the ticket is no longer held by the sender")
+	ErrTransferToSelf        = errors.New("cannot transfer a ticket to yourself")
+	ErrTransferPending       = errors.New("a transfer for this ticket is already pending")
+	ErrTicketNotTransferable = errors.New("only confirmed tickets that are not checked in can be transferred")
+)
+
+type TransferService interface {
+	// OfferTransfer offers the user's registration to whoever signs in with
+	// email. The ticket stays with the sender until the offer is accepted.
+	OfferTransfer(ctx context.Context, userID, registrationID, email string) (*models.TicketTransfer, error)
+	// AcceptTransfer moves the registration to the recipient and rotates its
+	// ticket token in one transaction.
+	AcceptTransfer(ctx context.Context, userID, transferID string) (*models.TicketTransfer, error)
+	DeclineTransfer(ctx context.Context, userID, transferID string) (*models.TicketTransfer, error)
+	CancelTransfer(ctx context.Context, userID, transferID string) (*models.TicketTransfer, error)
+	ListTransfers(ctx context.Context, userID string) ([]models.TicketTransfer, error)
+}
+
+type transferService struct {
+	db           *gorm.DB
+	transferRepo repositories.TransferRepository
+	userRepo     repositories.UserRepository
+	orderRepo    repositories.OrderRepository
+	waitRepo     repositories.WaitlistRepository
+	auditRepo    repositories.AuditLogRepository
+}
+
+func NewTransferService(db *gorm.DB, transferRepo repositories.TransferRepository, userRepo repositories.UserRepository, orderRepo repositories.OrderRepository, waitRepo repositories.WaitlistRepository, auditRepo repositories.AuditLogRepository) TransferService {
+	return &transferService{
+		db:           db,
+		transferRepo: transferRepo,
+		userRepo:     userRepo,
+		orderRepo:    orderRepo,
+		waitRepo:     waitRepo,
+		auditRepo:    auditRepo,
+	}
+}
+
+func (s *transferService) OfferTransfer(ctx context.Context, userID, registrationID, email string) (_ *models.TicketTransfer, err error) {
+	ctx, span := tracing.Start(ctx, "TransferService.OfferTransfer",
+		attribute.String("registration.id", registrationID),
+		attribute.String("user.id", userID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	email = models.NormalizeEmail(email)
+	if email == "" {
+		return nil, errors.New("email is required")
+	}
+	sender, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if models.NormalizeEmail(sender.Email) == email {
+		return nil, ErrTransferToSelf
+	}
+
+	var transfer *models.TicketTransfer
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// The registration row lock keeps a cancellation from slipping in
+		// between the checks and the offer.
+		var reg models.Registration
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Event").Where("id = ?", registrationID).First(&reg).Error; err != nil {
+			return errors.New("registration not found")
+		}
+		if reg.UserID.String() != userID {
+			return errors.New("unauthorized to transfer this registration")
+		}
+		if reg.Status != models.RegistrationStatusConfirmed || reg.CheckedInAt != nil {
+			return ErrTicketNotTransferable
+		}
+		if reg.Event.Status != models.EventStatusPublished || !reg.Event.TransferPolicy.Open(reg.Event.EventDate, time.Now()) {
+			return ErrTransfersClosed
+		}
+
+		var pending int64
+		if err := tx.Model(&models.TicketTransfer{}).
+			Where("registration_id = ? AND status = ?", reg.ID, models.TransferStatusPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrTransferPending
+		}
+
+		transfer = &models.TicketTransfer{
+			RegistrationID: reg.ID,
+			EventID:        reg.EventID,
+			FromUserID:     reg.UserID,
+			ToEmail:        email,
+			Status:         models.TransferStatusPending,
+		}
+		if err := s.transferRepo.WithTx(tx).Create(ctx, transfer); err != nil {
+			return err
+		}
+		return recordActivity(ctx, tx, s.auditRepo, reg.UserID, models.AuditActionTransferOffered, models.AuditEntityTransfer, transfer.ID, reg.EventID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.String("transfer.id", transfer.ID.String()))
+	slog.InfoContext(ctx, "Ticket transfer offered", "transfer_id", transfer.ID, "registration_id", registrationID, "user_id", userID)
+	return transfer, nil
+}
+
+// recipientTransfer loads a transfer offered to the user's email.
+func (s *transferService) recipientTransfer(ctx context.Context, userID, transferID string) (*models.TicketTransfer, error) {
+	transfer, err := s.transferRepo.FindByID(ctx, transferID)
+	if err != nil {
+		return nil, ErrTransferNotFound
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil || models.NormalizeEmail(user.Email) != transfer.ToEmail {
+		return nil, ErrTransferNotFound
+	}
+	return transfer, nil
+}
+
+func (s *transferService) AcceptTransfer(ctx context.Context, userID, transferID string) (_ *models.TicketTransfer, err error) {
+	ctx, span := tracing.Start(ctx, "TransferService.AcceptTransfer",
+		attribute.String("transfer.id", transferID),
+		attribute.String("user.id", userID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	transfer, err := s.recipientTransfer(ctx, userID, transferID)
+	if err != nil {
+		return nil, err
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, errors.New("invalid user ID")
+	}
+
+	var accepted *models.TicketTransfer
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Event, then transfer, then registration: cancellation takes the
+		// event lock before the registration too.
+		event, err := lockEvent(ctx, tx, transfer.EventID.String(), "transfer")
+		if err != nil {
+			return ErrEventNotFound
+		}
+		locked, err := s.transferRepo.WithTx(tx).LockByID(ctx, transferID)
+		if err != nil {
+			return ErrTransferNotFound
+		}
+		if locked.Status != models.TransferStatusPending {
+			return ErrTransferNotPending
+		}
+		now := time.Now()
+		if event.Status != models.EventStatusPublished || !event.TransferPolicy.Open(event.EventDate, now) {
+			return ErrTransfersClosed
+		}
+
+		var reg models.Registration
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", locked.RegistrationID).First(&reg).Error; err != nil {
+			return err
+		}
+		if reg.UserID != locked.FromUserID || reg.Status != models.RegistrationStatusConfirmed || reg.CheckedInAt != nil {
+			return ErrTransferStale
+		}
+
+		// The recipient ends up with the seat as their own booking, so the
+		// one-seat-per-user rules apply. A place on the waitlist is given
+		// up: the transferred seat is what they were waiting for.
+		var confirmed int64
+		if err := tx.Model(&models.Registration{}).
+			Where("event_id = ? AND user_id = ? AND group_booking_id IS NULL AND status = ?", event.ID, userID, models.RegistrationStatusConfirmed).
+			Count(&confirmed).Error; err != nil {
+			return err
+		}
+		if confirmed > 0 {
+			return ErrAlreadyRegistered
+		}
+		if _, err := s.orderRepo.WithTx(tx).FindPendingByEventAndUser(ctx, event.ID.String(), userID); err == nil {
+			return ErrOrderPending
+		}
+		if entry, err := s.waitRepo.WithTx(tx).FindByEventAndUser(ctx, event.ID.String(), userID); err == nil {
+			if entry.GroupBookingID != nil {
+				return ErrAlreadyWaitlisted
+			}
+			if err := s.waitRepo.WithTx(tx).Delete(ctx, entry.ID.String()); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&reg).Updates(map[string]any{
+			"user_id":          userUUID,
+			"group_booking_id": nil,
+			"attendee_name":    "",
+			"attendee_email":   "",
+			"ticket_token":     models.NewTicketToken(),
+		}).Error; err != nil {
+			return err
+		}
+
+		locked.Status = models.TransferStatusAccepted
+		locked.ToUserID = &userUUID
+		locked.RespondedAt = &now
+		if err := s.transferRepo.WithTx(tx).Update(ctx, locked); err != nil {
+			return err
+		}
+		if err := recordActivity(ctx, tx, s.auditRepo, userUUID, models.AuditActionTransferAccepted, models.AuditEntityTransfer, locked.ID, event.ID); err != nil {
+			return err
+		}
+		accepted = locked
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Ticket transfer accepted", "transfer_id", transferID, "registration_id", accepted.RegistrationID,
+		"from_user_id", accepted.FromUserID, "to_user_id", userID)
+	return accepted, nil
+}
+
+func (s *transferService) DeclineTransfer(ctx context.Context, userID, transferID string) (*models.TicketTransfer, error) {
+	if _, err := s.recipientTransfer(ctx, userID, transferID); err != nil {
+		return nil, err
+	}
+	return s.close(ctx, transferID, models.TransferStatusDeclined)
+}
+
+func (s *transferService) CancelTransfer(ctx context.Context, userID, transferID string) (*models.TicketTransfer, error) {
+	transfer, err := s.transferRepo.FindByID(ctx, transferID)
+	if err != nil || transfer.FromUserID.String() != userID {
+		return nil, ErrTransferNotFound
+	}
+	return s.close(ctx, transferID, models.TransferStatusCancelled)
+}
+
+// close ends a pending transfer without moving the ticket.
+func (s *transferService) close(ctx context.Context, transferID string, status models.TransferStatus) (closed *models.TicketTransfer, err error) {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		transfer, err := s.transferRepo.WithTx(tx).LockByID(ctx, transferID)
+		if err != nil {
+			return ErrTransferNotFound
+		}
+		if transfer.Status != models.TransferStatusPending {
+			return ErrTransferNotPending
+		}
+		now := time.Now()
+		transfer.Status = status
+		transfer.RespondedAt = &now
+		closed = transfer
+		return s.transferRepo.WithTx(tx).Update(ctx, transfer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Ticket transfer closed", "transfer_id", transferID, "status", status)
+	return closed, nil
+}
+
+func (s *transferService) ListTransfers(ctx context.Context, userID string) ([]models.TicketTransfer, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return s.transferRepo.ListForUser(ctx, userID, models.NormalizeEmail(user.Email))
+}
+
+// cancelPendingTransfers withdraws open offers for registrations that are
+// being cancelled.
+func cancelPendingTransfers(tx *gorm.DB, registrationIDs ...uuid.UUID) error {
+	if len(registrationIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.TicketTransfer{}).
+		Where("registration_id IN ? AND status = ?", registrationIDs, models.TransferStatusPending).
+		Updates(map[string]any{
+			"status":       models.TransferStatusCancelled,
+			"responded_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	_ "time/tzdata" // venue time zones must resolve even without a system zoneinfo
+
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrVenueNotFound     = errors.New("venue not found")
+	ErrOverVenueCapacity = errors.New("capacity exceeds the venue's maximum capacity")
+	ErrVenueDoubleBooked = errors.New("venue is already booked at that time")
+	ErrVenueInUse        = errors.New("venue has events and cannot be deleted")
+)
+
+type VenueService interface {
+	CreateVenue(ctx context.Context, organizerID string, venue *models.Venue) (*models.Venue, error)
+	// UpdateVenue replaces the venue's details. The maximum capacity cannot
+	// drop below the capacity of an event already scheduled there.
+	UpdateVenue(ctx context.Context, organizerID, venueID string, venue *models.Venue) (*models.Venue, error)
+	// DeleteVenue removes a venue no event has ever used.
+	DeleteVenue(ctx context.Context, organizerID, venueID string) error
+	GetVenue(ctx context.Context, organizerID, venueID string) (*models.Venue, error)
+	ListVenues(ctx context.Context, organizerID string) ([]models.Venue, error)
+}
+
+type venueService struct {
+	db        *gorm.DB
+	venueRepo repositories.VenueRepository
+}
+
+func NewVenueService(db *gorm.DB, venueRepo repositories.VenueRepository) VenueService {
+	return &venueService{db: db, venueRepo: venueRepo}
+}
+
+func (s *venueService) CreateVenue(ctx context.Context, organizerID string, venue *models.Venue) (_ *models.Venue, err error) {
+	ctx, span := tracing.Start(ctx, "VenueService.CreateVenue", attribute.String("organizer.id", organizerID))
+	defer func() { tracing.End(span, err) }()
+
+	orgUUID, err := uuid.Parse(organizerID)
+	if err != nil {
+		return nil, errors.New("invalid organizer ID")
+	}
+	venue.ID = uuid.Nil
+	venue.OrganizerID = orgUUID
+	if err := validateVenue(venue); err != nil {
+		return nil, err
+	}
+	if err := s.venueRepo.Create(ctx, venue); err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Venue created", "venue_id", venue.ID, "organizer_id", organizerID)
+	return venue, nil
+}
+
+func (s *venueService) UpdateVenue(ctx context.Context, organizerID, venueID string, update *models.Venue) (_ *models.Venue, err error) {
+	ctx, span := tracing.Start(ctx, "VenueService.UpdateVenue", attribute.String("venue.id", venueID))
+	defer func() { tracing.End(span, err) }()
+
+	var venue *models.Venue
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		venueRepo := s.venueRepo.WithTx(tx)
+		if err := venueRepo.LockEvents(ctx, venueID); err != nil {
+			return err
+		}
+		// Locked so no event is scheduled against the old capacity meanwhile.
+		venue, err = venueRepo.LockByID(ctx, venueID)
+		if err != nil || venue.OrganizerID.String() != organizerID {
+			return ErrVenueNotFound
+		}
+		oldTimezone := venue.Timezone
+
+		update.ID = venue.ID
+		update.OrganizerID = venue.OrganizerID
+		update.CreatedAt = venue.CreatedAt
+		if err := validateVenue(update); err != nil {
+			return err
+		}
+		largest, err := venueRepo.LargestEventCapacity(ctx, venueID)
+		if err != nil {
+			return err
+		}
+		if update.MaxCapacity < largest {
+			return fmt.Errorf("%w: an event there has capacity %d", ErrOverVenueCapacity, largest)
+		}
+		venue = update
+		if err := venueRepo.Update(ctx, venue); err != nil {
+			return err
+		}
+		// Events copy their location, and by default their timezone, from
+		// the venue, so they follow the update.
+		return venueRepo.SyncEvents(ctx, venue, oldTimezone)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return venue, nil
+}
+
+func (s *venueService) DeleteVenue(ctx context.Context, organizerID, venueID string) (err error) {
+	ctx, span := tracing.Start(ctx, "VenueService.DeleteVenue", attribute.String("venue.id", venueID))
+	defer func() { tracing.End(span, err) }()
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		venueRepo := s.venueRepo.WithTx(tx)
+		venue, err := venueRepo.LockByID(ctx, venueID)
+		if err != nil || venue.OrganizerID.String() != organizerID {
+			return ErrVenueNotFound
+		}
+		// Cancelled events still point at the venue, so they count too.
+		count, err := venueRepo.CountEvents(ctx, venueID)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrVenueInUse
+		}
+		return venueRepo.Delete(ctx, venueID)
+	})
+}
+
+func (s *venueService) GetVenue(ctx context.Context, organizerID, venueID string) (*models.Venue, error) {
+	venue, err := s.venueRepo.FindByID(ctx, venueID)
+	if err != nil || venue.OrganizerID.String() != organizerID {
+		return nil, ErrVenueNotFound
+	}
+	return venue, nil
+}
+
+func (s *venueService) ListVenues(ctx context.Context, organizerID string) ([]models.Venue, error) {
+	return s.venueRepo.FindByOrganizer(ctx, organizerID)
+}
+
+func validateVenue(venue *models.Venue) error {
+	venue.Name = strings.TrimSpace(venue.Name)
+	venue.Address = strings.TrimSpace(venue.Address)
+	if venue.Name == "" {
+		return errors.New("venue name is required")
+	}
+	if venue.Address == "" {
+		return errors.New("venue address is required")
+	}
+	if venue.MaxCapacity < 1 {
+		return errors.New("max_capacity must be at least 1")
+	}
+	if venue.Timezone == "" {
+		return errors.New("venue timezone is required")
+	}
+	if _, err := time.LoadLocation(venue.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %q", venue.Timezone)
+	}
+	if (venue.Latitude == nil) != (venue.Longitude == nil) {
+		return errors.New("latitude and longitude must be given together")
+	}
+	if venue.Latitude != nil && (*venue.Latitude < -90 || *venue.Latitude > 90) {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if venue.Longitude != nil && (*venue.Longitude < -180 || *venue.Longitude > 180) {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand"
+	"time"
+
+	"event_registration/internal/metrics"
+	"event_registration/internal/models"
+	"event_registration/internal/repositories"
+	"event_registration/internal/tracing"
+	"event_registration/internal/utils"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoWaitingRoom     = errors.New("event has no active waiting room")
+	ErrNotInWaitingRoom  = errors.New("not in the waiting room for this event")
+	ErrAdmissionRequired = errors.New("admission token required: join the waiting room first")
+	ErrAdmissionInvalid  = errors.New("admission token is invalid or expired")
+	ErrAdmissionUsed     = errors.New("admission has already been used for a booking: join the waiting room again")
+)
+
+// Ranks below lateArrivalRank are random and go to users who joined before
+// the sale opened, so arriving early does not buy a better spot. Later users
+// are ranked by arrival time after all of them.
+const lateArrivalRank = int64(1) << 62
+
+// Waiting room entry states reported to users.
+const (
+	WaitingRoomWaiting  = "WAITING"
+	WaitingRoomAdmitted = "ADMITTED"
+	WaitingRoomExpired  = "EXPIRED"
+)
+
+// WaitingRoomStatus is what a user in the room polls for. Once admitted it
+// carries the token to send with the booking as X-Admission-Token.
+type WaitingRoomStatus struct {
+	EventID        uuid.UUID  `json:"event_id"`
+	State          string     `json:"state"`
+	Position       int64      `json:"position,omitempty"`
+	ETASeconds     int64      `json:"eta_seconds,omitempty"`
+	SaleStartsAt   *time.Time `json:"sale_starts_at,omitempty"`
+	AdmissionToken string     `json:"admission_token,omitempty"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+}
+
+// WaitingRoomSettings are the organizer controls for a room.
+type WaitingRoomSettings struct {
+	Enabled        bool       `json:"enabled"`
+	SaleStartsAt   *time.Time `json:"sale_starts_at"`
+	AdmitPerMinute int        `json:"admit_per_minute"`
+}
+
+type WaitingRoomOverview struct {
+	Room  *models.WaitingRoom      `json:"waiting_room"`
+	Stats *models.WaitingRoomStats `json:"stats"`
+}
+
+type WaitingRoomService interface {
+	Join(ctx context.Context, userID, eventID string) (*WaitingRoomStatus, error)
+	Status(ctx context.Context, userID, eventID string) (*WaitingRoomStatus, error)
+	// CheckAdmission allows a booking: always for events without an
+	// enabled room, otherwise only with a valid admission token.
+	CheckAdmission(ctx context.Context, userID, eventID, token string) error
+	Configure(ctx context.Context, organizerID, eventID string, settings WaitingRoomSettings) (*models.WaitingRoom, error)
+	Overview(ctx context.Context, organizerID, eventID string) (*WaitingRoomOverview, error)
+	RunAdmitter(ctx context.Context, interval time.Duration)
+}
+
+type waitingRoomService struct {
+	db        *gorm.DB
+	roomRepo  repositories.WaitingRoomRepository
+	eventRepo repositories.EventRepository
+	secret    string
+	tokenTTL  time.Duration
+}
+
+func NewWaitingRoomService(db *gorm.DB, roomRepo repositories.WaitingRoomRepository, eventRepo repositories.EventRepository, secret string, tokenTTL time.Duration) WaitingRoomService {
+	return &waitingRoomService{
+		db:        db,
+		roomRepo:  roomRepo,
+		eventRepo: eventRepo,
+		secret:    secret,
+		tokenTTL:  tokenTTL,
+	}
+}
+
+func (s *waitingRoomService) Join(ctx context.Context, userID, eventID string) (_ *WaitingRoomStatus, err error) {
+	ctx, span := tracing.Start(ctx, "WaitingRoomService.Join",
+		attribute.String("event.id", eventID),
+		attribute.String("user.id", userID),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	room, err := s.roomRepo.FindRoom(ctx, eventID)
+	if err != nil || !room.Enabled {
+		return nil, ErrNoWaitingRoom
+	}
+	event, err := s.eventRepo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+	if err := validateOnSale(*event); err != nil {
+		return nil, err
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry, err := s.roomRepo.FindEntry(ctx, eventID, userID)
+	switch {
+	case err == nil:
+		// An expired admission goes to the back of the line.
+		if s.state(entry, now) == WaitingRoomExpired {
+			entry.Rank = entryRank(room, now)
+			entry.JoinedAt = now
+			entry.AdmittedAt = nil
+			if err := s.roomRepo.Requeue(ctx, entry); err != nil {
+				return nil, err
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		entry = &models.WaitingRoomEntry{
+			EventID:  event.ID,
+			UserID:   userUUID,
+			Rank:     entryRank(room, now),
+			JoinedAt: now,
+		}
+		if err := s.roomRepo.CreateEntry(ctx, entry); err != nil {
+			// A concurrent join from the same user won the unique index.
+			if entry, err = s.roomRepo.FindEntry(ctx, eventID, userID); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, err
+	}
+
+	return s.status(ctx, room, entry, now)
+}
+
+func entryRank(room *models.WaitingRoom, now time.Time) int64 {
+	if !room.Open(now) {
+		return rand.Int63n(lateArrivalRank)
+	}
+	return lateArrivalRank + now.UnixMicro()
+}
+
+func (s *waitingRoomService) Status(ctx context.Context, userID, eventID string) (*WaitingRoomStatus, error) {
+	room, err := s.roomRepo.FindRoom(ctx, eventID)
+	if err != nil || !room.Enabled {
+		return nil, ErrNoWaitingRoom
+	}
+	entry, err := s.roomRepo.FindEntry(ctx, eventID, userID)
+	if err != nil {
+		return nil, ErrNotInWaitingRoom
+	}
+	return s.status(ctx, room, entry, time.Now())
+}
+
+func (s *waitingRoomService) state(entry *models.WaitingRoomEntry, now time.Time) string {
+	switch {
+	case entry.AdmittedAt == nil:
+		return WaitingRoomWaiting
+	case entry.UsedAt == nil && now.Before(entry.AdmittedAt.Add(s.tokenTTL)):
+		return WaitingRoomAdmitted
+	default:
+		return WaitingRoomExpired
+	}
+}
+
+func (s *waitingRoomService) status(ctx context.Context, room *models.WaitingRoom, entry *models.WaitingRoomEntry, now time.Time) (*WaitingRoomStatus, error) {
+	status := &WaitingRoomStatus{
+		EventID:      entry.EventID,
+		State:        s.state(entry, now),
+		SaleStartsAt: room.SaleStartsAt,
+	}
+
+	switch status.State {
+	case WaitingRoomWaiting:
+		ahead, err := s.roomRepo.CountAhead(ctx, entry.EventID.String(), entry.Rank)
+		if err != nil {
+			return nil, err
+		}
+		status.Position = ahead + 1
+		eta := time.Duration(float64(status.Position) / float64(room.AdmitPerMinute) * float64(time.Minute))
+		if !room.Open(now) {
+			eta += room.SaleStartsAt.Sub(now)
+		}
+		status.ETASeconds = int64(math.Ceil(eta.Seconds()))
+	case WaitingRoomAdmitted:
+		expiresAt := entry.AdmittedAt.Add(s.tokenTTL)
+		token, err := utils.GenerateAdmissionToken(entry.UserID.String(), entry.EventID.String(), s.secret, expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		status.AdmissionToken = token
+		status.TokenExpiresAt = &expiresAt
+	}
+	return status, nil
+}
+
+func (s *waitingRoomService) CheckAdmission(ctx context.Context, userID, eventID, token string) error {
+	room, err := s.roomRepo.FindRoom(ctx, eventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !room.Enabled {
+		return nil
+	}
+
+	if token == "" {
+		return ErrAdmissionRequired
+	}
+	claims, err := utils.ValidateAdmissionToken(token, s.secret)
+	if err != nil || claims.Subject != userID || claims.EventID != eventID {
+		return ErrAdmissionInvalid
+	}
+	return nil
+}
+
+// useAdmission spends the user's admission inside the booking transaction
+// when the event has an enabled waiting room, so one admission books once.
+// A booking that rolls back leaves the admission unspent. Two bookings with
+// the same token serialize on the entry row and the second one fails.
+func useAdmission(ctx context.Context, tx *gorm.DB, eventID, userID string) error {
+	var room models.WaitingRoom
+	err := tx.WithContext(ctx).Where("event_id = ?", eventID).First(&room).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !room.Enabled {
+		return nil
+	}
+
+	result := tx.WithContext(ctx).Model(&models.WaitingRoomEntry{}).
+		Where("event_id = ? AND user_id = ? AND admitted_at IS NOT NULL AND used_at IS NULL", eventID, userID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAdmissionUsed
+	}
+	return nil
+}
+
+func (s *waitingRoomService) Configure(ctx context.Context, organizerID, eventID string, settings WaitingRoomSettings) (_ *models.WaitingRoom, err error) {
+	ctx, span := tracing.Start(ctx, "WaitingRoomService.Configure",
+		attribute.String("event.id", eventID),
+		attribute.Bool("waiting_room.enabled", settings.Enabled),
+		attribute.Int("waiting_room.admit_per_minute", settings.AdmitPerMinute),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	if settings.AdmitPerMinute < 1 {
+		return nil, errors.New("admit_per_minute must be at least 1")
+	}
+	event, err := s.eventRepo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+	if event.OrganizerID.String() != organizerID {
+		return nil, errors.New("unauthorized to manage this waiting room")
+	}
+
+	room, err := s.roomRepo.FindRoom(ctx, eventID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		room = &models.WaitingRoom{EventID: event.ID}
+	} else if err != nil {
+		return nil, err
+	}
+	// Re-enabling starts the admission clock afresh rather than admitting
+	// the backlog of the time the room was off.
+	if settings.Enabled && !room.Enabled {
+		room.LastAdmissionAt = nil
+	}
+	room.Enabled = settings.Enabled
+	room.SaleStartsAt = settings.SaleStartsAt
+	room.AdmitPerMinute = settings.AdmitPerMinute
+	room.UpdatedAt = time.Now()
+
+	if err := s.roomRepo.SaveRoom(ctx, room); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+func (s *waitingRoomService) Overview(ctx context.Context, organizerID, eventID string) (*WaitingRoomOverview, error) {
+	event, err := s.eventRepo.FindByID(ctx, eventID)
+	if err != nil {
+		return nil, ErrEventNotFound
+	}
+	if event.OrganizerID.String() != organizerID {
+		return nil, errors.New("unauthorized to manage this waiting room")
+	}
+	room, err := s.roomRepo.FindRoom(ctx, eventID)
+	if err != nil {
+		return nil, ErrNoWaitingRoom
+	}
+	stats, err := s.roomRepo.Stats(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	return &WaitingRoomOverview{Room: room, Stats: stats}, nil
+}
+
+// RunAdmitter admits users from every open room every interval until ctx
+// is done.
+func (s *waitingRoomService) RunAdmitter(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		eventIDs, err := s.roomRepo.FindOpenRooms(ctx, now)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to list open waiting rooms", "error", err)
+			}
+			continue
+		}
+		for _, eventID := range eventIDs {
+			if err := s.admit(ctx, eventID, now, interval); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to admit from waiting room", "event_id", eventID, "error", err)
+			}
+		}
+	}
+}
+
+// admit lets in as many users as the room's rate allows since the last
+// admission. The room row lock keeps replicas from admitting the same slice
+// twice; whole users only are admitted and the remainder carries over.
+func (s *waitingRoomService) admit(ctx context.Context, eventID string, now time.Time, interval time.Duration) error {
+	var admitted int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		roomRepo := s.roomRepo.WithTx(tx)
+		room, err := roomRepo.LockRoom(ctx, eventID)
+		if err != nil {
+			return err
+		}
+		if !room.Enabled || !room.Open(now) {
+			return nil
+		}
+
+		since := now.Add(-interval)
+		if room.LastAdmissionAt != nil {
+			since = *room.LastAdmissionAt
+		}
+		if room.SaleStartsAt != nil && since.Before(*room.SaleStartsAt) {
+			since = *room.SaleStartsAt
+		}
+		// Never release more than a minute's worth at once.
+		if now.Sub(since) > time.Minute {
+			since = now.Add(-time.Minute)
+		}
+
+		due := int(now.Sub(since).Minutes() * float64(room.AdmitPerMinute))
+		if due == 0 {
+			return nil
+		}
+		if admitted, err = roomRepo.AdmitNext(ctx, eventID, due, now); err != nil {
+			return err
+		}
+
+		// Carry the fraction of a user not yet admitted; an empty queue
+		// does not bank capacity for later arrivals.
+		last := since.Add(time.Duration(float64(due) / float64(room.AdmitPerMinute) * float64(time.Minute)))
+		if admitted < int64(due) {
+			last = now
+		}
+		room.LastAdmissionAt = &last
+		return roomRepo.SaveRoom(ctx, room)
+	})
+	if err == nil && admitted > 0 {
+		metrics.WaitingRoomAdmissionsTotal.Add(float64(admitted))
+		slog.InfoContext(ctx, "Admitted users from waiting room", "event_id", eventID, "admitted", admitted)
+	}
+	return err
+}
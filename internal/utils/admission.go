@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const admissionAudience = "waiting-room-admission"
+
+// AdmissionClaims let one user book one waiting-room event until they
+// expire. Subject is the user ID.
+type AdmissionClaims struct {
+	EventID string `json:"event_id"`
+	jwt.RegisteredClaims
+}
+
+// admissionKey derives a separate signing key so an admission token can
+// never pass as a login token, or the other way round.
+func admissionKey(secret string) []byte {
+	return []byte("admission:" + secret)
+}
+
+func GenerateAdmissionToken(userID, eventID, secret string, expiresAt time.Time) (string, error) {
+	claims := &AdmissionClaims{
+		EventID: eventID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{admissionAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(admissionKey(secret))
+}
+
+func ValidateAdmissionToken(tokenString, secret string) (*AdmissionClaims, error) {
+	claims := &AdmissionClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return admissionKey(secret), nil
+	}, jwt.WithAudience(admissionAudience), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+	return claims, nil
+}
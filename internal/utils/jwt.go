@@ -14,8 +14,8 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID string, role models.Role, secret string) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+func GenerateToken(userID string, role models.Role, secret string, ttl time.Duration) (string, error) {
+	expirationTime := time.Now().Add(ttl)
 	claims := &Claims{
 		UserID: userID,
 		Role:   role,
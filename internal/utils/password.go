@@ -4,8 +4,12 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// BcryptCost is the work factor for new password hashes. Set from config at
+// startup; existing hashes keep verifying regardless of their cost.
+var BcryptCost = 14
+
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost)
 	return string(bytes), err
 }
 
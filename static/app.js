@@ -164,16 +164,24 @@ async function loadAllEvents() {
         data.events.forEach(ev => {
             const isFull = ev.seats_remaining === 0;
             const seatClass = isFull ? 'full' : (ev.seats_remaining < 5 ? 'low' : '');
-
-            let btnAction = `<button onclick="bookEvent('${ev.id}')" class="btn btn-primary" style="${isFull ? 'background:var(--waitlist)' : ''}">
-                ${isFull ? 'Join Waitlist' : 'Book Seat'}
-            </button>`;
+            const isPaid = ev.price && ev.price.amount > 0;
+
+            // Paid events have no waitlist: a sold-out paid event cannot be booked.
+            let btnAction = isPaid
+                ? `<button onclick="buyTicket('${ev.id}')" class="btn btn-primary" ${isFull ? 'disabled' : ''}>
+                    ${isFull ? 'Sold Out' : `Buy Ticket (${formatMoney(ev.price)})`}
+                </button>`
+                : `<button onclick="bookEvent('${ev.id}')" class="btn btn-primary" style="${isFull ? 'background:var(--waitlist)' : ''}">
+                    ${isFull ? 'Join Waitlist' : 'Book Seat'}
+                </button>
+                <button onclick="bookGroup('${ev.id}')" class="btn" style="margin-top:0.5rem">Book for a Group</button>`;
 
             // Show admin simulation button
             let simulationBtn = currentUser.role === 'ADMIN' ?
                 `<button onclick="openSimModal('${ev.id}', '${ev.title.replace(/'/g, "\\'")}')" class="btn btn-danger" style="margin-top:0.5rem">⚡ Run Concurrency Sim</button>` : '';
 
-            const dateStr = new Date(ev.event_date).toLocaleDateString(undefined, { weekday: 'short', month: 'short', day: 'numeric' });
+            // Shown in the event's own zone, not the browser's.
+            const dateStr = new Date(ev.event_date).toLocaleString(undefined, { weekday: 'short', month: 'short', day: 'numeric', hour: '2-digit', minute: '2-digit', timeZone: ev.timezone });
 
             eventsGrid.innerHTML += `
                 <div class="event-card">
@@ -208,6 +216,91 @@ async function bookEvent(eventId) {
     }
 }
 
+// Group bookings take every seat or none; when the group does not fit, the
+// user chooses between the seats that are left and waiting as a group.
+async function bookGroup(eventId) {
+    const seats = parseInt(prompt('How many seats?', '2'), 10);
+    if (!seats) return;
+    const names = (prompt('Attendee names, comma separated (optional):') || '')
+        .split(',').map(n => n.trim()).filter(Boolean);
+    const body = { seats, attendees: names.map(name => ({ name })) };
+
+    try {
+        let res = await fetchWithAuth(`/events/${eventId}/group-bookings`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+        let data = await res.json();
+        if (res.status === 409 && data.error.startsWith('not enough seats')) {
+            const partial = confirm(`${data.error}.\n\nOK books the seats that are left, Cancel puts the whole group on the waitlist.`);
+            res = await fetchWithAuth(`/events/${eventId}/group-bookings`, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ ...body, on_shortfall: partial ? 'PARTIAL' : 'WAITLIST' })
+            });
+            data = await res.json();
+        }
+        if (!res.ok) throw new Error(data.error || 'Failed to book');
+
+        showToast(data.message, data.group_booking.status === 'WAITLISTED' ? 'waitlist' : 'success');
+        loadAllEvents();
+    } catch (err) {
+        showToast(err.message, 'error');
+    }
+}
+
+// Money amounts travel in the currency's minor unit (cents for USD).
+function currencyDigits(currency) {
+    return new Intl.NumberFormat(undefined, { style: 'currency', currency }).resolvedOptions().maximumFractionDigits;
+}
+
+function formatMoney(money) {
+    return new Intl.NumberFormat(undefined, { style: 'currency', currency: money.currency })
+        .format(money.amount / Math.pow(10, currencyDigits(money.currency)));
+}
+
+// Paid events: place an order that holds a seat, then pay at checkout. With
+// the fake provider in development, checkout is a confirm dialog.
+async function buyTicket(eventId) {
+    const promoCode = prompt('Promo code (leave empty for none):');
+    if (promoCode === null) return;
+    try {
+        const res = await fetchWithAuth(`/events/${eventId}/orders`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ promo_code: promoCode.trim() })
+        });
+        const data = await res.json();
+        if (!res.ok) throw new Error(data.error || 'Failed to place order');
+
+        const order = data.order;
+        if (!order.checkout_url || !order.checkout_url.startsWith('/payments/fake/')) {
+            showToast(data.message, 'success');
+            loadAllEvents();
+            return;
+        }
+
+        const succeed = confirm(`Pay ${formatMoney(order.total)} for this ticket?\n\nOK simulates a successful payment, Cancel a declined one.`);
+        const payRes = await fetchWithAuth(order.checkout_url, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ succeed })
+        });
+        const payData = await payRes.json();
+        if (!payRes.ok) throw new Error(payData.error || 'Payment failed');
+
+        if (payData.order.status === 'PAID') {
+            showToast('Payment received. Your seat is confirmed!', 'success');
+        } else {
+            showToast(payData.order.failure_reason || 'Payment failed', 'error');
+        }
+        loadAllEvents();
+    } catch (err) {
+        showToast(err.message, 'error');
+    }
+}
+
 // --- Organizer ---
 async function handleCreateEvent(e) {
     e.preventDefault();
@@ -216,8 +309,20 @@ async function handleCreateEvent(e) {
         description: document.getElementById('ev-desc').value,
         location: document.getElementById('ev-location').value,
         event_date: new Date(document.getElementById('ev-date').value).toISOString(),
+        ends_at: new Date(document.getElementById('ev-end').value).toISOString(),
+        timezone: Intl.DateTimeFormat().resolvedOptions().timeZone,
         capacity: parseInt(document.getElementById('ev-capacity').value, 10)
     };
+    const price = parseFloat(document.getElementById('ev-price').value || '0');
+    if (price > 0) {
+        const currency = document.getElementById('ev-currency').value.trim().toUpperCase();
+        payload.price = { amount: Math.round(price * Math.pow(10, currencyDigits(currency))), currency };
+        payload.refund_policy = {
+            full_refund_days: parseInt(document.getElementById('ev-refund-days').value || '0', 10),
+            partial_percent: parseInt(document.getElementById('ev-refund-percent').value || '0', 10),
+            no_refund_hours: parseInt(document.getElementById('ev-refund-hours').value || '0', 10)
+        };
+    }
 
     try {
         const res = await fetchWithAuth(`/organizer/events`, {
@@ -290,6 +395,18 @@ function closeSimModal() {
     simModal.classList.add('hidden');
 }
 
+// Simulations run as background jobs; poll until the report is in.
+async function waitForSimulation(job) {
+    while (job.status === 'RUNNING') {
+        await new Promise(resolve => setTimeout(resolve, 1000));
+        const res = await fetchWithAuth(`/admin/simulations/${job.id}`);
+        const data = await res.json();
+        if (!res.ok) throw new Error(data.error || 'Simulation Failed');
+        job = data.simulation;
+    }
+    return job;
+}
+
 async function handleSimulation(e) {
     e.preventDefault();
     const eventId = document.getElementById('sim-event-id').value;
@@ -304,12 +421,18 @@ async function handleSimulation(e) {
     document.getElementById('sim-results').classList.add('hidden');
 
     try {
-        const res = await fetchWithAuth(`/admin/events/${eventId}/simulate?users=${users}`, { method: 'POST' });
+        const res = await fetchWithAuth(`/admin/events/${eventId}/simulate`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ users: parseInt(users, 10) })
+        });
         const data = await res.json();
 
         if (!res.ok) throw new Error(data.error || 'Simulation Failed');
 
-        const r = data.simulation_results;
+        const job = await waitForSimulation(data.simulation);
+        if (job.status === 'FAILED') throw new Error(job.error || 'Simulation Failed');
+        const r = job.report;
 
         // Display
         document.getElementById('sim-results').classList.remove('hidden');